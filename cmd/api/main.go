@@ -4,6 +4,10 @@ import (
 	_ "api/docs"
 	"api/internal/config"
 	"api/internal/pkg/app"
+
+	// time zones of statistics are loaded from the binary, as the runtime
+	// image has no zoneinfo
+	_ "time/tzdata"
 )
 
 // @title        yodreik API
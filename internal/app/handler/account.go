@@ -5,9 +5,9 @@ import (
 	"api/internal/app/handler/response"
 	"api/internal/app/handler/response/responsebody"
 	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
 	repoerr "api/internal/repository/errors"
 	"api/pkg/requestid"
-	"api/pkg/sha256"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -122,7 +122,21 @@ func (h *Handler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	err = h.repository.User.UpdatePasswordByEmail(c, passwordResetRequest.Email, sha256.String(body.Password))
+	user, err := h.repository.User.GetByEmail(c, passwordResetRequest.Email)
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	passwordHash, err := h.hasher.Hash(body.Password)
+	if err != nil {
+		log.Error("can't hash password", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	err = h.repository.User.UpdatePasswordByEmail(c, passwordResetRequest.Email, passwordHash)
 	if err != nil {
 		log.Error("can't update password", sl.Err(err))
 		response.InternalServerError(c)
@@ -134,6 +148,11 @@ func (h *Handler) UpdatePassword(c *gin.Context) {
 		log.Error("can't mark token as used", sl.Err(err))
 	}
 
+	err = h.repository.Session.RevokeAllUserSessions(c, user.ID)
+	if err != nil {
+		log.Error("can't revoke sessions after password reset", sl.Err(err))
+	}
+
 	c.Status(http.StatusOK)
 }
 
@@ -210,19 +229,24 @@ func (h *Handler) GetCurrentAccount(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, responsebody.Account{
-		ID:          user.ID,
-		Email:       user.Email,
-		Username:    user.Username,
-		DisplayName: user.DisplayName,
-		AvatarURL:   user.AvatarURL,
-		IsPrivate:   user.IsPrivate,
-		IsConfirmed: user.IsConfirmed,
-		CreatedAt:   user.CreatedAt.Format(time.RFC3339),
+		ID:                user.ID,
+		Email:             user.Email,
+		Username:          user.Username,
+		DisplayName:       user.DisplayName,
+		AvatarURL:         user.AvatarURL,
+		IsPrivate:         user.IsPrivate,
+		IsConfirmed:       user.IsConfirmed,
+		TwoFactor:         user.IsTOTPEnabled,
+		Role:              user.Role,
+		Units:             user.Units,
+		TimeZone:          user.TimeZone,
+		DefaultVisibility: user.DefaultVisibility,
+		CreatedAt:         user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
 // @Summary      Update personal information
-// @Description  updates user entity in storage
+// @Description  updates user entity in storage. Time zone is an IANA name, e.g. Europe/Berlin, it's used to tell dates of imported workouts and which day is today. Default visibility of workouts is public, followers or only_me, set `apply_to_workouts` to change visibility of existing workouts too. Making a private profile public accepts pending follow requests
 // @Security     AccessToken
 // @Tags         account
 // @Accept       json
@@ -245,6 +269,12 @@ func (h *Handler) UpdateAccount(c *gin.Context) {
 		return
 	}
 
+	if body.TimeZone != nil && !validTimeZone(*body.TimeZone) {
+		log.Debug("unknown time zone", slog.String("time_zone", *body.TimeZone))
+		response.WithMessage(c, http.StatusBadRequest, "unknown time zone")
+		return
+	}
+
 	userID := c.GetString("UserID")
 	user, err := h.repository.User.GetByID(c, userID)
 	if errors.Is(err, repoerr.ErrUserNotFound) {
@@ -307,22 +337,89 @@ func (h *Handler) UpdateAccount(c *gin.Context) {
 		user.DisplayName = *body.DisplayName
 	}
 	if body.Password != nil {
-		user.PasswordHash = sha256.String(*body.Password)
+		passwordHash, err := h.hasher.Hash(*body.Password)
+		if err != nil {
+			log.Error("can't hash password", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+
+		user.PasswordHash = passwordHash
 	}
 	if body.IsPrivate != nil {
 		user.IsPrivate = *body.IsPrivate
 	}
 
-	err = h.repository.User.UpdateUser(c, userID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken)
+	update := entity.AccountUpdate{
+		Units:             body.Units,
+		TimeZone:          body.TimeZone,
+		DefaultVisibility: body.DefaultVisibility,
+		ApplyToWorkouts:   body.ApplyToWorkouts,
+
+		// pending requests are accepted on every switch to public, not only
+		// on a change, so a retry fixes a failed attempt
+		AcceptFollows: body.IsPrivate != nil && !*body.IsPrivate,
+	}
+
+	err = h.repository.User.UpdateAccount(c, user, update)
 	if err != nil {
 		log.Error("can't update user", sl.Err(err))
 		response.InternalServerError(c)
 		return
 	}
 
+	if body.Password != nil {
+		err = h.repository.Session.RevokeOtherUserSessions(c, userID, c.GetString("SessionID"))
+		if err != nil {
+			log.Error("can't revoke other sessions after password change", sl.Err(err))
+		}
+	}
+
 	c.Status(http.StatusOK)
 }
 
+// @Summary      Get active sessions
+// @Description  returns a list of devices current user is logged in from
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}       responsebody.SessionList
+// @Failure      401 {object}       responsebody.Message
+// @Router       /account/sessions  [get]
+func (h *Handler) GetSessions(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetSessions"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	userID := c.GetString("UserID")
+	sessions, err := h.repository.Session.GetActiveByUserID(c, userID)
+	if err != nil {
+		log.Error("can't get sessions", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	currentSessionID := c.GetString("SessionID")
+	res := responsebody.SessionList{
+		Count:    len(sessions),
+		Sessions: make([]responsebody.Session, 0),
+	}
+
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, responsebody.Session{
+			ID:         session.FamilyID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+			IsCurrent:  session.FamilyID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
 // @Summary      Upload User Avatar
 // @Description  uploads a new avatar image for the user. Only PNG, JPG, and JPEG formats are allowed
 // @Security     AccessToken
@@ -454,3 +551,14 @@ func (h *Handler) DeleteAvatar(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// validTimeZone tells, whether a time zone is an IANA name, that is known.
+// Local is a zone of the server, so it's not accepted
+func validTimeZone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
@@ -11,6 +11,7 @@ import (
 	repoerr "api/internal/repository/errors"
 	"api/internal/token"
 	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
 	"api/pkg/sha256"
 	"database/sql/driver"
 	"errors"
@@ -32,7 +33,7 @@ func TestResetPassword(t *testing.T) {
 	tokenSecret := "some-supa-secret-characters"
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
 	user := entity.User{
 		ID:                "USER_ID",
@@ -169,7 +170,7 @@ func TestUpdatePassword(t *testing.T) {
 
 	c := config.Config{}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
 	user := entity.User{
 		ID:                "USER_ID",
@@ -205,13 +206,24 @@ func TestUpdatePassword(t *testing.T) {
 					WithArgs(request.Token).
 					WillReturnRows(rows)
 
+				userRows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
+					WillReturnRows(userRows)
+
 				mock.ExpectExec("UPDATE users SET password_hash = $1 WHERE email = $2").
-					WithArgs(sha256.String("new-password"), user.Email).
+					WithArgs(sqlmock.AnyArg(), user.Email).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectExec("UPDATE reset_password_requests SET is_used = true WHERE token = $1").
 					WithArgs(request.Token).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectExec("DELETE FROM sessions WHERE user_id = $1").
+					WithArgs(user.ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
 			},
 
 			Request: test.Request{
@@ -341,8 +353,15 @@ func TestUpdatePassword(t *testing.T) {
 					WithArgs(request.Token).
 					WillReturnRows(rows)
 
+				userRows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
+					WillReturnRows(userRows)
+
 				mock.ExpectExec("UPDATE users SET password_hash = $1 WHERE email = $2").
-					WithArgs(user.PasswordHash, user.Email).
+					WithArgs(sqlmock.AnyArg(), user.Email).
 					WillReturnError(errors.New("repo: Some repository error"))
 			},
 
@@ -366,13 +385,24 @@ func TestUpdatePassword(t *testing.T) {
 					WithArgs(request.Token).
 					WillReturnRows(rows)
 
+				userRows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
+					WillReturnRows(userRows)
+
 				mock.ExpectExec("UPDATE users SET password_hash = $1 WHERE email = $2").
-					WithArgs(sha256.String("new-password"), user.Email).
+					WithArgs(sqlmock.AnyArg(), user.Email).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectExec("UPDATE reset_password_requests SET is_used = true WHERE token = $1").
 					WithArgs(request.Token).
 					WillReturnError(errors.New("repo: Some repository error"))
+
+				mock.ExpectExec("DELETE FROM sessions WHERE user_id = $1").
+					WithArgs(user.ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
 			},
 
 			Request: test.Request{
@@ -382,7 +412,9 @@ func TestUpdatePassword(t *testing.T) {
 				},
 			},
 
-			Expect: test.ResponseInternalServerError,
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
 		},
 	}
 
@@ -399,7 +431,7 @@ func TestConfirmAccount(t *testing.T) {
 
 	c := config.Config{}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
 	tests := []test.Case{
 		{
@@ -512,9 +544,9 @@ func TestGetCurrentAccount(t *testing.T) {
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
 	tokenManager := token.New(c.Token)
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
-	accessToken, err := tokenManager.GenerateJWT("USER_ID")
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
 	if err != nil {
 		t.Fatal("unexpected error while generating mock token")
 	}
@@ -537,8 +569,10 @@ func TestGetCurrentAccount(t *testing.T) {
 			Name: "ok",
 
 			Repo: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
-					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "time_zone", "default_visibility", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, "Europe/Berlin", "followers", user.CreatedAt)
 
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs("USER_ID").WillReturnRows(rows)
 			},
@@ -551,13 +585,15 @@ func TestGetCurrentAccount(t *testing.T) {
 
 			Expect: test.Expect{
 				Status: http.StatusOK,
-				Body:   fmt.Sprintf(`{"id":"USER_ID","email":"john.doe@example.com","username":"johndoe","display_name":"John Doe","avatar_url":"https://cdn.domain.com/avatar.jpeg","is_private":false,"is_confirmed":true,"created_at":"%s"}`, user.CreatedAt.Format(time.RFC3339)),
+				Body:   fmt.Sprintf(`{"id":"USER_ID","email":"john.doe@example.com","username":"johndoe","display_name":"John Doe","avatar_url":"https://cdn.domain.com/avatar.jpeg","is_private":false,"is_confirmed":true,"two_factor_enabled":false,"role":"","units":"","time_zone":"Europe/Berlin","default_visibility":"followers","created_at":"%s"}`, user.CreatedAt.Format(time.RFC3339)),
 			},
 		},
 		{
 			Name: "user not found",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs("USER_ID").WillReturnError(repoerr.ErrUserNotFound)
 			},
 
@@ -576,6 +612,8 @@ func TestGetCurrentAccount(t *testing.T) {
 			Name: "repository error",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs("USER_ID").WillReturnError(errors.New("repo: Some repository error"))
 			},
 
@@ -607,7 +645,7 @@ func TestUpdateAccount(t *testing.T) {
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
 	tokenManager := token.New(c.Token)
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
 	user := entity.User{
 		ID:                "USER_ID",
@@ -622,7 +660,7 @@ func TestUpdateAccount(t *testing.T) {
 		CreatedAt:         time.Now(),
 	}
 
-	accessToken, err := tokenManager.GenerateJWT(user.ID)
+	accessToken, err := tokenManager.GenerateJWT(user.ID, "SESSION_ID", "user")
 	if err != nil {
 		t.Fatal("unexpected error while generating mock token")
 	}
@@ -632,6 +670,8 @@ func TestUpdateAccount(t *testing.T) {
 			Name: "ok: username",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
@@ -639,9 +679,11 @@ func TestUpdateAccount(t *testing.T) {
 
 				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").WithArgs("johndoe2").WillReturnRows(rows)
 
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
 					WithArgs(user.Email, "johndoe2", user.DisplayName, user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
 					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
 			},
 
 			Request: test.Request{
@@ -655,18 +697,227 @@ func TestUpdateAccount(t *testing.T) {
 				Status: http.StatusOK,
 			},
 		},
+		{
+			Name: "ok: units",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectExec("UPDATE users SET units = $1 WHERE id = $2").
+					WithArgs("imperial", user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Body: `{"units":"imperial"}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "unknown units",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Body: `{"units":"nautical"}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "ok: time_zone",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectExec("UPDATE users SET time_zone = $1 WHERE id = $2").
+					WithArgs("Europe/Berlin", user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Body: `{"time_zone":"Europe/Berlin"}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "ok: default_visibility applied to workouts",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectExec("UPDATE users SET default_visibility = $1 WHERE id = $2").
+					WithArgs("followers", user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectExec("UPDATE workouts SET visibility = $1 WHERE user_id = $2").
+					WithArgs("followers", user.ID).
+					WillReturnResult(driver.RowsAffected(3))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Body: `{"default_visibility":"followers","apply_to_workouts":true}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "failed back-fill rolls back the whole update",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectExec("UPDATE users SET default_visibility = $1 WHERE id = $2").
+					WithArgs("followers", user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectExec("UPDATE workouts SET visibility = $1 WHERE user_id = $2").
+					WithArgs("followers", user.ID).
+					WillReturnError(errors.New("repo: Some repository error"))
+				mock.ExpectRollback()
+			},
+
+			Request: test.Request{
+				Body: `{"default_visibility":"followers","apply_to_workouts":true}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+		{
+			Name: "unknown default_visibility",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Body: `{"default_visibility":"friends"}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "apply_to_workouts without default_visibility",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Body: `{"apply_to_workouts":true}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "unknown time zone",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Body: `{"time_zone":"Mars/Olympus"}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "unknown time zone",
+				},
+			},
+		},
 		{
 			Name: "ok: display_name",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
 
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
 					WithArgs(user.Email, user.Username, "John Doe Ver2", user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
 					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
 			},
 
 			Request: test.Request{
@@ -684,13 +935,21 @@ func TestUpdateAccount(t *testing.T) {
 			Name: "ok: password",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
 
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
-					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, sha256.String("newpassword"), false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, sqlmock.AnyArg(), false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+
+				mock.ExpectExec("DELETE FROM sessions WHERE user_id = $1 AND family_id <> $2").
+					WithArgs(user.ID, "SESSION_ID").
 					WillReturnResult(driver.RowsAffected(1))
 			},
 
@@ -709,14 +968,18 @@ func TestUpdateAccount(t *testing.T) {
 			Name: "ok: is_private",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
 
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
 					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, true, user.IsConfirmed, user.ConfirmationToken, user.ID).
 					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
 			},
 
 			Request: test.Request{
@@ -730,9 +993,46 @@ func TestUpdateAccount(t *testing.T) {
 				Status: http.StatusOK,
 			},
 		},
+		{
+			Name: "ok: is_private turned off accepts pending requests",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, true, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, false, user.IsConfirmed, user.ConfirmationToken, user.ID).
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectExec("UPDATE follows SET is_accepted = true WHERE followee_id = $1 AND is_accepted = false").
+					WithArgs(user.ID).
+					WillReturnResult(driver.RowsAffected(2))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Body: `{"is_private":false}`,
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
 		{
 			Name: "invalid request body",
 
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
 			Request: test.Request{
 				Body: `{"invalid":"request, "body}`,
 				Headers: map[string]string{
@@ -749,6 +1049,8 @@ func TestUpdateAccount(t *testing.T) {
 			Name: "user not found",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnError(repoerr.ErrUserNotFound)
 			},
 
@@ -768,6 +1070,8 @@ func TestUpdateAccount(t *testing.T) {
 			Name: "get: repository error",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnError(errors.New("repo: Some repository error"))
 			},
 
@@ -787,14 +1091,18 @@ func TestUpdateAccount(t *testing.T) {
 			Name: "update: repository error",
 
 			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
 				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(user.ID).WillReturnRows(rows)
 
-				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6 WHERE id = $7").
-					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, true, user.ID).
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs(user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, true, user.IsConfirmed, user.ConfirmationToken, user.ID).
 					WillReturnError(errors.New("repo: Some repository error"))
+				mock.ExpectRollback()
 			},
 
 			Request: test.Request{
@@ -815,3 +1123,91 @@ func TestUpdateAccount(t *testing.T) {
 		test.Endpoint(t, tc, mock, http.MethodPatch, "/api/account", "/api/account", handler.UserIdentity, handler.UpdateAccount)
 	}
 }
+
+func TestGetSessions(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	lastSeenAt := time.Now()
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "family_id", "user_id", "device", "user_agent", "ip_address", "last_seen_at"}).
+					AddRow("TOKEN_ID_1", "SESSION_ID", "USER_ID", "Pixel 9", "okhttp/4.12.0", "10.0.0.1", lastSeenAt).
+					AddRow("TOKEN_ID_2", "OTHER_SESSION_ID", "USER_ID", "", "Mozilla/5.0", "10.0.0.2", lastSeenAt)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE user_id = $1 AND is_used = false AND expires_at > now() ORDER BY last_seen_at DESC").
+					WithArgs("USER_ID").WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.SessionList{
+					Count: 2,
+					Sessions: []responsebody.Session{
+						{
+							ID:         "SESSION_ID",
+							Device:     "Pixel 9",
+							UserAgent:  "okhttp/4.12.0",
+							IPAddress:  "10.0.0.1",
+							LastSeenAt: lastSeenAt.Format(time.RFC3339),
+							IsCurrent:  true,
+						},
+						{
+							ID:         "OTHER_SESSION_ID",
+							UserAgent:  "Mozilla/5.0",
+							IPAddress:  "10.0.0.2",
+							LastSeenAt: lastSeenAt.Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE user_id = $1 AND is_used = false AND expires_at > now() ORDER BY last_seen_at DESC").
+					WithArgs("USER_ID").WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/account/sessions", "/api/account/sessions", handler.UserIdentity, handler.GetSessions)
+	}
+}
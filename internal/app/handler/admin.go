@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/role"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultUsersLimit = 20
+	maxUsersLimit     = 100
+)
+
+// @Summary      Search users
+// @Description  returns users, whose email or username contain given query, newest first. Requires moderator role
+// @Security     AccessToken
+// @Tags         admin
+// @Produce      json
+// @Param        q query       string false "Part of email or username"
+// @Param        limit query   int    false "Number of users to return, 20 by default, 100 at most"
+// @Param        offset query  int    false "Number of users to skip"
+// @Success      200 {object}  responsebody.UserList
+// @Failure      400 {object}  responsebody.Message
+// @Failure      401 {object}  responsebody.Message
+// @Failure      403 {object}  responsebody.Message
+// @Router       /admin/users  [get]
+func (h *Handler) GetUsers(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetUsers"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	limit := defaultUsersLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxUsersLimit {
+			log.Debug("invalid limit", slog.String("limit", value))
+			response.WithMessage(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	var offset int
+	if value := c.Query("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			log.Debug("invalid offset", slog.String("offset", value))
+			response.WithMessage(c, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		offset = n
+	}
+
+	users, err := h.repository.User.Search(c, c.Query("q"), limit, offset)
+	if err != nil {
+		log.Error("can't search users", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	list := make([]responsebody.User, 0, len(users))
+	for _, user := range users {
+		list = append(list, userResponse(&user))
+	}
+
+	c.JSON(http.StatusOK, responsebody.UserList{
+		Count: len(list),
+		Users: list,
+	})
+}
+
+// @Summary      Confirm user's email
+// @Description  marks user's email as confirmed without a confirmation link. Requires admin role
+// @Security     AccessToken
+// @Tags         admin
+// @Produce      json
+// @Param        id path                         string true "User ID"
+// @Success      200
+// @Failure      401 {object}                    responsebody.Message
+// @Failure      403 {object}                    responsebody.Message
+// @Failure      404 {object}                    responsebody.Message
+// @Router       /admin/users/{id}/confirmation  [post]
+func (h *Handler) ConfirmUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.ConfirmUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	userID := c.Param("id")
+
+	err := h.repository.User.Confirm(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusNotFound, "user not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't confirm user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user confirmed by admin", slog.String("user_id", userID), slog.String("admin_id", c.GetString("UserID")))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Suspend a user
+// @Description  prevents the user from signing in and using existing sessions and API keys. Requires moderator role, higher than user's one
+// @Security     AccessToken
+// @Tags         admin
+// @Produce      json
+// @Param        id path                       string true "User ID"
+// @Success      200
+// @Failure      401 {object}                  responsebody.Message
+// @Failure      403 {object}                  responsebody.Message
+// @Failure      404 {object}                  responsebody.Message
+// @Router       /admin/users/{id}/suspension  [post]
+func (h *Handler) SuspendUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.SuspendUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.moderatedUser(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.User.Suspend(c, user.ID)
+	if err != nil {
+		log.Error("can't suspend user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user suspended", slog.String("user_id", user.ID), slog.String("moderator_id", c.GetString("UserID")))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Unsuspend a user
+// @Description  lifts a suspension of the user. Requires moderator role, higher than user's one
+// @Security     AccessToken
+// @Tags         admin
+// @Produce      json
+// @Param        id path                       string true "User ID"
+// @Success      200
+// @Failure      401 {object}                  responsebody.Message
+// @Failure      403 {object}                  responsebody.Message
+// @Failure      404 {object}                  responsebody.Message
+// @Router       /admin/users/{id}/suspension  [delete]
+func (h *Handler) UnsuspendUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UnsuspendUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.moderatedUser(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.User.Unsuspend(c, user.ID)
+	if err != nil {
+		log.Error("can't unsuspend user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user unsuspended", slog.String("user_id", user.ID), slog.String("moderator_id", c.GetString("UserID")))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Reset user's avatar
+// @Description  removes user's avatar. Requires moderator role, higher than user's one
+// @Security     AccessToken
+// @Tags         admin
+// @Produce      json
+// @Param        id path                   string true "User ID"
+// @Success      200
+// @Failure      401 {object}              responsebody.Message
+// @Failure      403 {object}              responsebody.Message
+// @Failure      404 {object}              responsebody.Message
+// @Router       /admin/users/{id}/avatar  [delete]
+func (h *Handler) ResetUserAvatar(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.ResetUserAvatar"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.moderatedUser(c, log)
+	if !ok {
+		return
+	}
+
+	user.AvatarURL = ""
+
+	err := h.repository.User.UpdateUser(c, user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken)
+	if err != nil {
+		log.Error("could not update user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user's avatar reset", slog.String("user_id", user.ID), slog.String("moderator_id", c.GetString("UserID")))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Change user's role
+// @Description  changes user's role and revokes their sessions, so the new role applies immediately. Requires admin role, higher than user's one
+// @Security     AccessToken
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path                 string true "User ID"
+// @Param        input body              requestbody.UpdateRole true "New role"
+// @Success      200
+// @Failure      400 {object}            responsebody.Message
+// @Failure      401 {object}            responsebody.Message
+// @Failure      403 {object}            responsebody.Message
+// @Failure      404 {object}            responsebody.Message
+// @Router       /admin/users/{id}/role  [patch]
+func (h *Handler) UpdateUserRole(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UpdateUserRole"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.UpdateRole
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	user, ok := h.moderatedUser(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.User.SetRole(c, user.ID, body.Role)
+	if err != nil {
+		log.Error("can't update user's role", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	err = h.repository.Session.RevokeAllUserSessions(c, user.ID)
+	if err != nil {
+		log.Error("can't revoke user's sessions", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user's role updated", slog.String("user_id", user.ID), slog.String("role", body.Role), slog.String("admin_id", c.GetString("UserID")))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Delete a workout
+// @Description  deletes any user's workout. Requires moderator role
+// @Security     AccessToken
+// @Tags         admin
+// @Produce      json
+// @Param        id path               string true "Workout ID"
+// @Success      200
+// @Failure      401 {object}          responsebody.Message
+// @Failure      403 {object}          responsebody.Message
+// @Failure      404 {object}          responsebody.Message
+// @Router       /admin/workouts/{id}  [delete]
+func (h *Handler) DeleteUserWorkout(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DeleteUserWorkout"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workoutID := c.Param("id")
+
+	workout, err := h.repository.Workout.GetByID(c, workoutID)
+	if errors.Is(err, repoerr.ErrWorkoutNotFound) {
+		log.Debug("workout not found", slog.String("id", workoutID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't find workout", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	err = h.repository.Workout.Delete(c, workout.ID)
+	if errors.Is(err, repoerr.ErrWorkoutNotFound) {
+		log.Debug("workout deleted concurrently", slog.String("id", workout.ID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't delete workout", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("workout deleted by moderator", slog.String("workout_id", workout.ID), slog.String("user_id", workout.UserID), slog.String("moderator_id", c.GetString("UserID")))
+
+	c.Status(http.StatusOK)
+}
+
+// moderatedUser finds a user by `id` path parameter, that the current user
+// is allowed to manage, and responds with an error otherwise
+func (h *Handler) moderatedUser(c *gin.Context, log *slog.Logger) (*entity.User, bool) {
+	userID := c.Param("id")
+
+	user, err := h.repository.User.GetByID(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	if !role.Outranks(c.GetString("Role"), user.Role) {
+		log.Debug("user has equal or higher role", slog.String("user_id", user.ID), slog.String("role", user.Role))
+		response.WithMessage(c, http.StatusForbidden, "insufficient role")
+		return nil, false
+	}
+
+	return user, true
+}
+
+func userResponse(user *entity.User) responsebody.User {
+	r := responsebody.User{
+		ID:          user.ID,
+		Email:       user.Email,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarURL,
+		Role:        user.Role,
+		IsPrivate:   user.IsPrivate,
+		IsConfirmed: user.IsConfirmed,
+		CreatedAt:   user.CreatedAt.Format(time.RFC3339),
+	}
+
+	if user.SuspendedAt != nil {
+		r.SuspendedAt = user.SuspendedAt.Format(time.RFC3339)
+	}
+
+	return r
+}
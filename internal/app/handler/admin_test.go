@@ -0,0 +1,674 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/role"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestGetUsers(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("MODERATOR_ID", "SESSION_ID", role.Moderator)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	createdAt := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	suspendedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query string
+		tc    test.Case
+	}{
+		{
+			query: "?q=john_&limit=10&offset=20",
+			tc: test.Case{
+				Name: "ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "is_private", "is_confirmed", "created_at", "role", "suspended_at"}).
+						AddRow("USER_ID", "john.doe@example.com", "john_doe", "John Doe", "", false, true, createdAt, role.User, suspendedAt)
+
+					mock.ExpectQuery("SELECT * FROM users WHERE email ILIKE $1 OR username ILIKE $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3").
+						WithArgs(`%john\_%`, 10, 20).
+						WillReturnRows(rows)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.UserList{
+						Count: 1,
+						Users: []responsebody.User{
+							{
+								ID:          "USER_ID",
+								Email:       "john.doe@example.com",
+								Username:    "john_doe",
+								DisplayName: "John Doe",
+								Role:        role.User,
+								IsConfirmed: true,
+								SuspendedAt: suspendedAt.Format(time.RFC3339),
+								CreatedAt:   createdAt.Format(time.RFC3339),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "?limit=1000",
+			tc: test.Case{
+				Name: "invalid limit",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid limit",
+					},
+				},
+			},
+		},
+		{
+			query: "",
+			tc: test.Case{
+				Name: "repository error",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					mock.ExpectQuery("SELECT * FROM users WHERE email ILIKE $1 OR username ILIKE $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3").
+						WithArgs("%%", defaultUsersLimit, 0).
+						WillReturnError(errors.New("repo: Some repository error"))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.ResponseInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/admin/users", "/api/admin/users"+tt.query, handler.UserIdentity, handler.RequireRole(role.Moderator), handler.GetUsers)
+	}
+}
+
+func TestConfirmUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("ADMIN_ID", "SESSION_ID", role.Admin)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	moderatorToken, err := tokenManager.GenerateJWT("MODERATOR_ID", "SESSION_ID", role.Moderator)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("UPDATE users SET is_confirmed = true WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "user not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("UPDATE users SET is_confirmed = true WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "user not found",
+				},
+			},
+		},
+		{
+			Name: "moderator",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", moderatorToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "insufficient role",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/admin/users/:id/confirmation", "/api/admin/users/USER_ID/confirmation", handler.UserIdentity, handler.RequireRole(role.Admin), handler.ConfirmUser)
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("MODERATOR_ID", "SESSION_ID", role.Moderator)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectExec("UPDATE users SET suspended_at = now() WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "user not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "user not found",
+				},
+			},
+		},
+		{
+			Name: "equal role",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.Moderator, nil))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "insufficient role",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectExec("UPDATE users SET suspended_at = now() WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/admin/users/:id/suspension", "/api/admin/users/USER_ID/suspension", handler.UserIdentity, handler.RequireRole(role.Moderator), handler.SuspendUser)
+	}
+}
+
+func TestUnsuspendUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("MODERATOR_ID", "SESSION_ID", role.Moderator)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	suspendedAt := time.Now()
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.User, &suspendedAt))
+
+				mock.ExpectExec("UPDATE users SET suspended_at = NULL WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/admin/users/:id/suspension", "/api/admin/users/USER_ID/suspension", handler.UserIdentity, handler.RequireRole(role.Moderator), handler.UnsuspendUser)
+	}
+}
+
+func TestResetUserAvatar(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("MODERATOR_ID", "SESSION_ID", role.Moderator)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectExec("UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9").
+					WithArgs("john.doe@example.com", "johndoe", "John Doe", "", "PASSWORD_HASH", false, true, "", "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/admin/users/:id/avatar", "/api/admin/users/USER_ID/avatar", handler.UserIdentity, handler.RequireRole(role.Moderator), handler.ResetUserAvatar)
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("ADMIN_ID", "SESSION_ID", role.Admin)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectExec("UPDATE users SET role = $1 WHERE id = $2").
+					WithArgs(role.Moderator, "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectExec("DELETE FROM sessions WHERE user_id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateRole{
+					Role: role.Moderator,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "unknown role",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateRole{
+					Role: "superuser",
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "another admin",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(roleUserRows(role.Admin, nil))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateRole{
+					Role: role.User,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "insufficient role",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPatch, "/api/admin/users/:id/role", "/api/admin/users/USER_ID/role", handler.UserIdentity, handler.RequireRole(role.Admin), handler.UpdateUserRole)
+	}
+}
+
+func TestDeleteUserWorkout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("MODERATOR_ID", "SESSION_ID", role.Moderator)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	userToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", role.User)
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "date", "duration", "kind", "created_at"}).
+					AddRow("WORKOUT_ID", "USER_ID", time.Now(), 69, "Calisthenics", time.Now())
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(rows)
+
+				mock.ExpectExec("DELETE FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "workout not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "workout not found",
+				},
+			},
+		},
+		{
+			Name: "regular user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", userToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "insufficient role",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/admin/workouts/:id", "/api/admin/workouts/WORKOUT_ID", handler.UserIdentity, handler.RequireRole(role.Moderator), handler.DeleteUserWorkout)
+	}
+}
+
+func roleUserRows(r string, suspendedAt *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at", "role", "suspended_at"}).
+		AddRow("USER_ID", "john.doe@example.com", "johndoe", "John Doe", "https://cdn.domain.com/avatar.jpeg", "PASSWORD_HASH", false, true, "", time.Now(), r, suspendedAt)
+}
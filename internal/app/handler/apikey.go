@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/random"
+	"api/pkg/requestid"
+	"api/pkg/sha256"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// apiKeyPrefix tells API keys apart from access tokens and makes leaked
+	// keys easy to find with secret scanners
+	apiKeyPrefix = "ydk_"
+
+	apiKeyLength        = 40
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+// @Summary      Create an API key
+// @Description  creates a personal API key with given scopes. The key is returned only once
+// @Security     AccessToken
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        input body         requestbody.CreateAPIKey true "API key information"
+// @Success      201 {object}       responsebody.CreatedAPIKey
+// @Failure      400 {object}       responsebody.Message
+// @Failure      401 {object}       responsebody.Message
+// @Failure      403 {object}       responsebody.Message
+// @Router       /account/api-keys  [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.CreateAPIKey"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.CreateAPIKey
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		log.Debug("expiration date is in the past", slog.Time("expires_at", *body.ExpiresAt))
+		response.WithMessage(c, http.StatusBadRequest, "expiration date must be in the future")
+		return
+	}
+
+	key := apiKeyPrefix + random.String(apiKeyLength)
+
+	created, err := h.repository.APIKey.Create(c, c.GetString("UserID"), body.Name, key[:apiKeyDisplayLength], sha256.String(key), body.Scopes, body.ExpiresAt)
+	if err != nil {
+		log.Error("can't create API key", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("API key created", slog.String("user_id", created.UserID), slog.String("api_key_id", created.ID))
+
+	c.JSON(http.StatusCreated, responsebody.CreatedAPIKey{
+		APIKey: apiKeyResponse(created),
+		Key:    key,
+	})
+}
+
+// @Summary      Get API keys
+// @Description  returns personal API keys of the current user
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}       responsebody.APIKeyList
+// @Failure      401 {object}       responsebody.Message
+// @Failure      403 {object}       responsebody.Message
+// @Router       /account/api-keys  [get]
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetAPIKeys"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	keys, err := h.repository.APIKey.GetByUserID(c, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get API keys", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	list := make([]responsebody.APIKey, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, apiKeyResponse(&key))
+	}
+
+	c.JSON(http.StatusOK, responsebody.APIKeyList{
+		Count:   len(list),
+		APIKeys: list,
+	})
+}
+
+// @Summary      Delete an API key
+// @Description  revokes personal API key
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Param        id path                 string true "API key ID"
+// @Success      200
+// @Failure      401 {object}            responsebody.Message
+// @Failure      403 {object}            responsebody.Message
+// @Failure      404 {object}            responsebody.Message
+// @Router       /account/api-keys/{id}  [delete]
+func (h *Handler) DeleteAPIKey(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DeleteAPIKey"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	id := c.Param("id")
+
+	err := h.repository.APIKey.Delete(c, c.GetString("UserID"), id)
+	if errors.Is(err, repoerr.ErrAPIKeyNotFound) {
+		log.Debug("API key not found", slog.String("id", id))
+		response.WithMessage(c, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't delete API key", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func apiKeyResponse(key *entity.APIKey) responsebody.APIKey {
+	r := responsebody.APIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+
+	if key.ExpiresAt != nil {
+		r.ExpiresAt = key.ExpiresAt.Format(time.RFC3339)
+	}
+	if key.LastUsedAt != nil {
+		r.LastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+	}
+
+	return r
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	pastDate := time.Now().Add(-time.Hour)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *").
+					WithArgs("USER_ID", "CI", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+					WillReturnRows(apiKeyRows(nil))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateAPIKey{
+					Name:   "CI",
+					Scopes: []string{"workouts:read"},
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusCreated,
+				BodyFields: []string{"id", "key"},
+			},
+		},
+		{
+			Name: "invalid scope",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateAPIKey{
+					Name:   "CI",
+					Scopes: []string{"account"},
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "expiration date in the past",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateAPIKey{
+					Name:      "CI",
+					Scopes:    []string{"workouts:read"},
+					ExpiresAt: &pastDate,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "expiration date must be in the future",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *").
+					WithArgs("USER_ID", "CI", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateAPIKey{
+					Name:   "CI",
+					Scopes: []string{"workouts:read"},
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/account/api-keys", "/api/account/api-keys", handler.UserIdentity, handler.CreateAPIKey)
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	createdAt := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "created_at"}).
+					AddRow("API_KEY_ID", "USER_ID", "CI", "ydk_01234567", "KEY_HASH", "{workouts:read,profile:read}", nil, nil, createdAt)
+
+				mock.ExpectQuery("SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC").
+					WithArgs("USER_ID").
+					WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.APIKeyList{
+					Count: 1,
+					APIKeys: []responsebody.APIKey{
+						{
+							ID:        "API_KEY_ID",
+							Name:      "CI",
+							Prefix:    "ydk_01234567",
+							Scopes:    []string{"workouts:read", "profile:read"},
+							CreatedAt: createdAt.Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC").
+					WithArgs("USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/account/api-keys", "/api/account/api-keys", handler.UserIdentity, handler.GetAPIKeys)
+	}
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM api_keys WHERE id = $1 AND user_id = $2").
+					WithArgs("API_KEY_ID", "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM api_keys WHERE id = $1 AND user_id = $2").
+					WithArgs("API_KEY_ID", "USER_ID").
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "API key not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/account/api-keys/:id", "/api/account/api-keys/API_KEY_ID", handler.UserIdentity, handler.DeleteAPIKey)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"api/internal/lib/logger/sl"
 	"api/internal/repository/entity"
 	repoerr "api/internal/repository/errors"
+	tokenpkg "api/internal/token"
+	"api/pkg/ratelimit"
 	"api/pkg/requestid"
 	"api/pkg/sha256"
 	"errors"
@@ -16,8 +18,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// dummyPasswordHash is verified against, when there is no password hash to
+// check, so unknown logins take as long to reject as wrong passwords
+const dummyPasswordHash = "$argon2id$v=19$m=19456,t=2,p=1$vumN1fLq/XEUvini06ezRw$DrILWqQ/wGlUxOwkNE//1I3No4tYmMrXOP16PIwsYPE"
+
 // @Summary      Create new account
 // @Description  create user in database
 // @Tags         auth
@@ -41,14 +48,20 @@ func (h *Handler) CreateAccount(c *gin.Context) {
 		return
 	}
 
-	_, err := mail.ParseAddress(body.Email)
-	if err != nil {
+	if _, err := mail.ParseAddress(body.Email); err != nil {
 		log.Debug("email is invalid", slog.String("email", body.Email))
 		response.WithMessage(c, http.StatusBadRequest, "invalid email format")
 		return
 	}
 
-	user, err := h.repository.User.Create(c, body.Email, body.Username, sha256.String(body.Password))
+	passwordHash, err := h.hasher.Hash(body.Password)
+	if err != nil {
+		log.Error("can't hash password", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	user, err := h.repository.User.Create(c, body.Email, body.Username, passwordHash)
 	if errors.Is(err, repoerr.ErrUserAlreadyExists) {
 		log.Info("user already exists", sl.Err(err))
 		response.WithMessage(c, http.StatusConflict, "user already exists")
@@ -76,19 +89,25 @@ func (h *Handler) CreateAccount(c *gin.Context) {
 		DisplayName: user.DisplayName,
 		IsPrivate:   user.IsPrivate,
 		IsConfirmed: user.IsConfirmed,
+		TwoFactor:   user.IsTOTPEnabled,
+		Role:        user.Role,
+		Units:       user.Units,
 		CreatedAt:   user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
 // @Summary      Create a session for existing account
-// @Description  check if user exists, and return an access token
+// @Description  check if user exists, and return an access token. If two-factor authentication is enabled, returns a challenge token to complete the login with
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        input body     requestbody.CreateSession true "User information"
 // @Success      200 {object}   responsebody.Token
+// @Success      202 {object}   responsebody.TwoFactorChallenge
 // @Failure      400 {object}   responsebody.Message
 // @Failure      401 {object}   responsebody.Message
+// @Failure      403 {object}   responsebody.Message
+// @Failure      429 {object}   responsebody.Message
 // @Router       /auth/session  [post]
 func (h *Handler) CreateSession(c *gin.Context) {
 	log := slog.With(
@@ -106,13 +125,14 @@ func (h *Handler) CreateSession(c *gin.Context) {
 	var err error
 	var user *entity.User
 	if _, mailErr := mail.ParseAddress(body.Login); mailErr == nil {
-		user, err = h.repository.User.GetByCredentialsWithEmail(c, body.Login, sha256.String(body.Password))
+		user, err = h.repository.User.GetByEmail(c, body.Login)
 	} else {
-		user, err = h.repository.User.GetByCredentialsWithUsername(c, body.Login, sha256.String(body.Password))
+		user, err = h.repository.User.GetByUsername(c, body.Login)
 	}
 
 	if errors.Is(err, repoerr.ErrUserNotFound) {
 		log.Debug("user not found", slog.String("login", body.Login))
+		_, _ = h.hasher.Verify(body.Password, dummyPasswordHash)
 		response.WithMessage(c, http.StatusUnauthorized, "user not found")
 		return
 	}
@@ -122,28 +142,462 @@ func (h *Handler) CreateSession(c *gin.Context) {
 		return
 	}
 
+	if h.isLocked(c, log, user) {
+		return
+	}
+
+	if user.PasswordHash == "" {
+		log.Debug("user has no password, signs in with external providers only", slog.String("login", body.Login))
+		_, _ = h.hasher.Verify(body.Password, dummyPasswordHash)
+		response.WithMessage(c, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	match, err := h.hasher.Verify(body.Password, user.PasswordHash)
+	if err != nil {
+		log.Error("can't verify password", sl.Err(err), slog.String("user_id", user.ID))
+		response.InternalServerError(c)
+		return
+	}
+	if !match {
+		log.Debug("wrong password", slog.String("login", body.Login))
+		h.registerFailedLogin(c, log, user)
+		response.WithMessage(c, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	if h.hasher.NeedsRehash(user.PasswordHash) {
+		h.rehashPassword(c, log, user, body.Password)
+	}
+
 	if user.IsConfirmed {
-		token, err := h.token.GenerateJWT(user.ID)
+		h.signIn(c, log, user, body.Device)
+		return
+	}
+
+	log.Debug("user's email not confirmed")
+
+	go func() {
+		err = h.mailer.SendConfirmationEmail(user.Email, user.ConfirmationToken)
 		if err != nil {
-			log.Error("can't generate JWT", sl.Err(err))
+			log.Error("can't send confirmation email", sl.Err(err))
+		}
+	}()
+
+	response.WithMessage(c, http.StatusForbidden, "email confirmation needed")
+}
+
+// @Summary      Complete two-factor login
+// @Description  exchanges a challenge token and a code from authenticator app (or a recovery code) for access and refresh tokens. A challenge token can be used once, a new one is issued by signing in again
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input body         requestbody.CompleteTwoFactor true "Challenge token and code"
+// @Success      200 {object}       responsebody.Token
+// @Failure      400 {object}       responsebody.Message
+// @Failure      401 {object}       responsebody.Message
+// @Failure      403 {object}       responsebody.Message
+// @Failure      429 {object}       responsebody.Message
+// @Router       /auth/session/2fa  [post]
+func (h *Handler) CompleteTwoFactor(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.CompleteTwoFactor"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.CompleteTwoFactor
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	claims, err := h.token.ParseChallenge(body.ChallengeToken)
+	if errors.Is(err, tokenpkg.ErrTokenExpired) {
+		log.Debug("challenge token expired")
+		response.WithMessage(c, http.StatusUnauthorized, "challenge token expired")
+		return
+	}
+	if err != nil {
+		log.Debug("can't parse challenge token", sl.Err(err))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid challenge token")
+		return
+	}
+
+	user, err := h.repository.User.GetByID(c, claims.UserID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", claims.UserID))
+		response.WithMessage(c, http.StatusUnauthorized, "user not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if !user.IsTOTPEnabled {
+		log.Debug("two-factor authentication is not enabled")
+		response.WithMessage(c, http.StatusUnauthorized, "invalid challenge token")
+		return
+	}
+
+	if h.isLocked(c, log, user) {
+		return
+	}
+
+	if h.isSuspended(c, log, user) {
+		return
+	}
+
+	// the challenge is consumed before the code is checked, so a replayed
+	// challenge can't burn a recovery code or a TOTP step
+	err = h.repository.User.UseChallenge(c, claims.ID, claims.ExpiresAt.Time)
+	if errors.Is(err, repoerr.ErrChallengeUsed) {
+		log.Debug("challenge token already used", slog.String("user_id", user.ID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid challenge token")
+		return
+	}
+	if err != nil {
+		log.Error("can't use challenge token", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	ok, err := h.verifySecondFactor(c, user, body.Code)
+	if err != nil {
+		log.Error("can't verify second factor", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+	if !ok {
+		log.Debug("invalid second factor code", slog.String("user_id", user.ID))
+		h.registerFailedLogin(c, log, user)
+		response.WithMessage(c, http.StatusUnauthorized, "invalid verification code")
+		return
+	}
+
+	h.resetFailedLogins(c, log, user)
+
+	tokens, err := h.createSession(c, user, uuid.NewString(), claims.Device)
+	if err != nil {
+		log.Error("can't create session", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary      Refresh a session
+// @Description  exchanges a refresh token for a new pair of access and refresh tokens. Every refresh token can be used only once, reusing it revokes the whole session
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input body     requestbody.RefreshSession true "Refresh token"
+// @Success      200 {object}   responsebody.Token
+// @Failure      400 {object}   responsebody.Message
+// @Failure      401 {object}   responsebody.Message
+// @Failure      403 {object}   responsebody.Message
+// @Router       /auth/refresh  [post]
+func (h *Handler) RefreshSession(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.RefreshSession"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.RefreshSession
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	session, err := h.repository.Session.GetByRefreshTokenHash(c, sha256.String(body.RefreshToken))
+	if errors.Is(err, repoerr.ErrSessionNotFound) {
+		log.Debug("session not found")
+		response.WithMessage(c, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+	if err != nil {
+		log.Error("can't find session", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if session.IsUsed {
+		h.revokeSessionFamily(c, log, session)
+		response.WithMessage(c, http.StatusUnauthorized, "refresh token reuse detected")
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		log.Debug("refresh token expired", slog.String("session_id", session.ID))
+		response.WithMessage(c, http.StatusUnauthorized, "refresh token expired")
+		return
+	}
+
+	user, err := h.repository.User.GetByID(c, session.UserID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", session.UserID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if h.isSuspended(c, log, user) {
+		return
+	}
+
+	tokens, err := h.rotateSession(c, user, session)
+	if errors.Is(err, repoerr.ErrSessionNotFound) {
+		// Somebody has rotated this token in between, so treat it as a replay
+		h.revokeSessionFamily(c, log, session)
+		response.WithMessage(c, http.StatusUnauthorized, "refresh token reuse detected")
+		return
+	}
+	if err != nil {
+		log.Error("can't rotate refresh token", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary      Log out
+// @Description  revokes current session, so its access and refresh tokens can't be used anymore
+// @Security     AccessToken
+// @Tags         auth
+// @Produce      json
+// @Success      200
+// @Failure      401 {object}   responsebody.Message
+// @Router       /auth/session  [delete]
+func (h *Handler) DeleteSession(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DeleteSession"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	sessionID := c.GetString("SessionID")
+	err := h.repository.Session.RevokeFamily(c, sessionID)
+	if err != nil {
+		log.Error("can't revoke session", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Log out everywhere
+// @Description  revokes all sessions of current user, including current one
+// @Security     AccessToken
+// @Tags         auth
+// @Produce      json
+// @Success      200
+// @Failure      401 {object}    responsebody.Message
+// @Router       /auth/sessions  [delete]
+func (h *Handler) DeleteAllSessions(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DeleteAllSessions"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	userID := c.GetString("UserID")
+	err := h.repository.Session.RevokeAllUserSessions(c, userID)
+	if err != nil {
+		log.Error("can't revoke sessions", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("all sessions revoked", slog.String("user_id", userID))
+
+	c.Status(http.StatusOK)
+}
+
+// createSession stores a new refresh token in given session family and
+// issues an access token bound to it
+func (h *Handler) createSession(c *gin.Context, user *entity.User, familyID string, device string) (*responsebody.Token, error) {
+	refreshToken, expiresAt := h.token.GenerateRefreshToken()
+
+	_, err := h.repository.Session.Create(c, user.ID, familyID, sha256.String(refreshToken), expiresAt, device, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+
+	return h.sessionTokens(user, familyID, refreshToken)
+}
+
+// rotateSession replaces a refresh token of given session with a new one of
+// the same family and issues an access token bound to it
+func (h *Handler) rotateSession(c *gin.Context, user *entity.User, session *entity.Session) (*responsebody.Token, error) {
+	refreshToken, expiresAt := h.token.GenerateRefreshToken()
+
+	_, err := h.repository.Session.Rotate(c, session.ID, user.ID, session.FamilyID, sha256.String(refreshToken), expiresAt, session.Device, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+
+	return h.sessionTokens(user, session.FamilyID, refreshToken)
+}
+
+func (h *Handler) sessionTokens(user *entity.User, familyID string, refreshToken string) (*responsebody.Token, error) {
+	accessToken, err := h.token.GenerateJWT(user.ID, familyID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &responsebody.Token{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (h *Handler) revokeSessionFamily(c *gin.Context, log *slog.Logger, session *entity.Session) {
+	log.Warn("refresh token reuse detected, revoking session", slog.String("family_id", session.FamilyID), slog.String("user_id", session.UserID))
+
+	err := h.repository.Session.RevokeFamily(c, session.FamilyID)
+	if err != nil {
+		log.Error("can't revoke session", sl.Err(err))
+	}
+}
+
+// signIn responds with tokens of a new session for an authenticated user, or
+// with a challenge token, if the user has two-factor authentication enabled
+func (h *Handler) signIn(c *gin.Context, log *slog.Logger, user *entity.User, device string) {
+	if h.isSuspended(c, log, user) {
+		return
+	}
+
+	if user.IsTOTPEnabled {
+		challenge, err := h.token.GenerateChallenge(user.ID, device)
+		if err != nil {
+			log.Error("can't generate two-factor challenge", sl.Err(err))
 			response.InternalServerError(c)
 			return
 		}
 
-		c.JSON(http.StatusOK, responsebody.Token{
-			Token: token,
+		c.JSON(http.StatusAccepted, responsebody.TwoFactorChallenge{
+			ChallengeToken: challenge,
 		})
 		return
 	}
 
-	log.Debug("user's email not confirmed")
+	h.resetFailedLogins(c, log, user)
+
+	tokens, err := h.createSession(c, user, uuid.NewString(), device)
+	if err != nil {
+		log.Error("can't create session", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// rehashPassword upgrades stored password hash to the current algorithm and
+// parameters. Failures are only logged, because the user is already authenticated
+func (h *Handler) rehashPassword(c *gin.Context, log *slog.Logger, user *entity.User, password string) {
+	passwordHash, err := h.hasher.Hash(password)
+	if err != nil {
+		log.Error("can't rehash password", sl.Err(err))
+		return
+	}
+
+	err = h.repository.User.UpdatePasswordByEmail(c, user.Email, passwordHash)
+	if err != nil {
+		log.Error("can't save rehashed password", sl.Err(err))
+		return
+	}
+
+	user.PasswordHash = passwordHash
+	log.Info("password hash upgraded", slog.String("user_id", user.ID))
+}
+
+// isSuspended responds with 403 if the user's account was suspended by a
+// moderator, and reports whether it did
+func (h *Handler) isSuspended(c *gin.Context, log *slog.Logger, user *entity.User) bool {
+	if user.SuspendedAt == nil {
+		return false
+	}
+
+	log.Debug("user is suspended", slog.String("user_id", user.ID))
+	response.WithMessage(c, http.StatusForbidden, "account suspended")
+	return true
+}
+
+// isLocked responds with 429 if the user's account is locked after too many
+// failed sign in attempts, and reports whether it did
+func (h *Handler) isLocked(c *gin.Context, log *slog.Logger, user *entity.User) bool {
+	if user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+		return false
+	}
+
+	log.Debug("account is locked", slog.String("user_id", user.ID), slog.Time("locked_until", *user.LockedUntil))
+	ratelimit.SetRetryAfter(c, time.Until(*user.LockedUntil))
+	response.WithMessage(c, http.StatusTooManyRequests, "account temporarily locked")
+	return true
+}
+
+// registerFailedLogin counts a failed sign in attempt and locks the account on
+// every Lockout.Threshold consecutive failures. Every subsequent lock lasts
+// twice as long as the previous one. Failures are only logged
+func (h *Handler) registerFailedLogin(c *gin.Context, log *slog.Logger, user *entity.User) {
+	threshold := h.config.Lockout.Threshold
+	if threshold <= 0 {
+		return
+	}
+
+	attempts, err := h.repository.User.RegisterFailedLogin(c, user.ID)
+	if err != nil {
+		log.Error("can't register failed login attempt", sl.Err(err))
+		return
+	}
+
+	if attempts%threshold != 0 {
+		return
+	}
+
+	duration := h.config.Lockout.Duration
+	maxDuration := h.config.Lockout.MaxDuration
+	for i := 1; i < attempts/threshold && (maxDuration <= 0 || duration < maxDuration); i++ {
+		duration *= 2
+	}
+	if maxDuration > 0 && duration > maxDuration {
+		duration = maxDuration
+	}
+
+	lockedUntil := time.Now().Add(duration)
+	err = h.repository.User.Lock(c, user.ID, lockedUntil)
+	if err != nil {
+		log.Error("can't lock account", sl.Err(err))
+		return
+	}
+
+	log.Info("account locked", slog.String("user_id", user.ID), slog.Int("attempts", attempts), slog.Duration("duration", duration))
 
 	go func() {
-		err = h.mailer.SendConfirmationEmail(user.Email, user.ConfirmationToken)
+		err := h.mailer.SendLockoutEmail(user.Email, lockedUntil)
 		if err != nil {
-			log.Error("can't send confirmation email", sl.Err(err))
+			log.Error("can't send lockout email", sl.Err(err))
 		}
 	}()
+}
 
-	response.WithMessage(c, http.StatusForbidden, "email confirmation needed")
+// resetFailedLogins resets failed sign in attempts after a successful one
+func (h *Handler) resetFailedLogins(c *gin.Context, log *slog.Logger, user *entity.User) {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return
+	}
+
+	err := h.repository.User.ResetFailedLogins(c, user.ID)
+	if err != nil {
+		log.Error("can't reset failed login attempts", sl.Err(err))
+	}
 }
@@ -3,21 +3,28 @@ package handler
 import (
 	"api/internal/app/handler/request/requestbody"
 	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/role"
 	"api/internal/app/handler/test"
 	"api/internal/config"
 	mockmailer "api/internal/mailer/mock"
 	"api/internal/repository"
 	"api/internal/repository/entity"
 	repoerr "api/internal/repository/errors"
+	"api/internal/token"
 	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
 	"api/pkg/sha256"
+	"api/pkg/totp"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 func TestCreateAccount(t *testing.T) {
@@ -29,7 +36,7 @@ func TestCreateAccount(t *testing.T) {
 	tokenSecret := "some-supa-secret-characters"
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
 	user := entity.User{
 		ID:                "USER_ID",
@@ -53,7 +60,7 @@ func TestCreateAccount(t *testing.T) {
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
 				mock.ExpectQuery("INSERT INTO users (email, username, password_hash) VALUES ($1, $2, $3) RETURNING *").
-					WithArgs(user.Email, user.Username, user.PasswordHash).WillReturnRows(rows)
+					WithArgs(user.Email, user.Username, sqlmock.AnyArg()).WillReturnRows(rows)
 			},
 
 			Request: test.Request{
@@ -113,7 +120,7 @@ func TestCreateAccount(t *testing.T) {
 
 			Repo: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO users (email, username, password_hash) VALUES ($1, $2, $3) RETURNING *").
-					WithArgs(user.Email, user.Username, user.PasswordHash).WillReturnError(repoerr.ErrUserAlreadyExists)
+					WithArgs(user.Email, user.Username, sqlmock.AnyArg()).WillReturnError(repoerr.ErrUserAlreadyExists)
 			},
 
 			Request: test.Request{
@@ -136,7 +143,7 @@ func TestCreateAccount(t *testing.T) {
 
 			Repo: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO users (email, username, password_hash) VALUES ($1, $2, $3) RETURNING *").
-					WithArgs(user.Email, user.Username, user.PasswordHash).
+					WithArgs(user.Email, user.Username, sqlmock.AnyArg()).
 					WillReturnError(errors.New("repo: Some repository error"))
 			},
 
@@ -166,7 +173,13 @@ func TestCreateSession(t *testing.T) {
 	tokenSecret := "some-supa-secret-characters"
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	hasher := password.NewArgon2id()
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), hasher)
+
+	passwordHash, err := hasher.Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error while hashing password: %v\n", err)
+	}
 
 	user := entity.User{
 		ID:                "USER_ID",
@@ -174,7 +187,7 @@ func TestCreateSession(t *testing.T) {
 		Username:          "johndoe",
 		DisplayName:       "John Doe",
 		AvatarURL:         "https://cdn.content.com/avatar.jpeg",
-		PasswordHash:      sha256.String("testword"),
+		PasswordHash:      passwordHash,
 		IsPrivate:         false,
 		IsConfirmed:       true,
 		ConfirmationToken: "CONFIRMATION_TOKEN",
@@ -189,8 +202,12 @@ func TestCreateSession(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
 
-				mock.ExpectQuery("SELECT * FROM users WHERE email = $1 AND password_hash = $2").
-					WithArgs(user.Email, user.PasswordHash).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).WillReturnRows(rows)
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs(user.ID, sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
 			},
 
 			Request: test.Request{
@@ -202,8 +219,32 @@ func TestCreateSession(t *testing.T) {
 
 			Expect: test.Expect{
 				Status:     http.StatusOK,
-				BodyFields: []string{"token"},
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "session repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).WillReturnRows(rows)
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs(user.ID, sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    user.Email,
+					Password: "testword",
+				},
 			},
+
+			Expect: test.ResponseInternalServerError,
 		},
 		{
 			Name: "invalid request body",
@@ -217,12 +258,67 @@ func TestCreateSession(t *testing.T) {
 
 			Expect: test.ResponseInvalidRequestBody,
 		},
+		{
+			Name: "ok: legacy password hash upgraded",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, sha256.String("testword"), user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").
+					WithArgs(user.Username).WillReturnRows(rows)
+
+				mock.ExpectExec("UPDATE users SET password_hash = $1 WHERE email = $2").
+					WithArgs(sqlmock.AnyArg(), user.Email).WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs(user.ID, sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    user.Username,
+					Password: "testword",
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "wrong password",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    user.Email,
+					Password: "wrong-password",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "user not found",
+				},
+			},
+		},
 		{
 			Name: "user not found",
 
 			Repo: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT * FROM users WHERE email = $1 AND password_hash = $2").
-					WithArgs("john.doe@example.com", sha256.String("testword")).WillReturnError(repoerr.ErrUserNotFound)
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).WillReturnError(repoerr.ErrUserNotFound)
 			},
 
 			Request: test.Request{
@@ -243,8 +339,8 @@ func TestCreateSession(t *testing.T) {
 			Name: "repository error",
 
 			Repo: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT * FROM users WHERE email = $1 AND password_hash = $2").
-					WithArgs(user.Email, user.PasswordHash).
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
 					WillReturnError(errors.New("repo: Some repository error"))
 			},
 
@@ -257,6 +353,30 @@ func TestCreateSession(t *testing.T) {
 
 			Expect: test.ResponseInternalServerError,
 		},
+		{
+			Name: "two-factor authentication required",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at", "totp_secret", "is_totp_enabled"}).
+					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt, "JBSWY3DPEHPK3PXP", true)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
+					WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    user.Email,
+					Password: "testword",
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusAccepted,
+				BodyFields: []string{"challenge_token"},
+			},
+		},
 		{
 			Name: "user not confirmed",
 
@@ -264,8 +384,8 @@ func TestCreateSession(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
 					AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, false, user.ConfirmationToken, user.CreatedAt)
 
-				mock.ExpectQuery("SELECT * FROM users WHERE email = $1 AND password_hash = $2").
-					WithArgs(user.Email, user.PasswordHash).
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
 					WillReturnRows(rows)
 			},
 
@@ -289,3 +409,769 @@ func TestCreateSession(t *testing.T) {
 		test.Endpoint(t, tc, mock, http.MethodPost, "/api/auth/session", "/api/auth/session", handler.CreateSession)
 	}
 }
+
+func TestCreateSessionLockout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{
+		Token:   config.Token{Secret: tokenSecret},
+		Lockout: config.Lockout{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour},
+	}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	hasher := password.NewArgon2id()
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), hasher)
+
+	passwordHash, err := hasher.Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error while hashing password: %v\n", err)
+	}
+
+	userRows := func(failedLoginAttempts int, lockedUntil *time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at", "failed_login_attempts", "locked_until"}).
+			AddRow("USER_ID", "john.doe@example.com", "johndoe", "John Doe", "", passwordHash, false, true, "CONFIRMATION_TOKEN", time.Now(), failedLoginAttempts, lockedUntil)
+	}
+
+	lockedUntil := time.Now().Add(time.Minute)
+	lockExpired := time.Now().Add(-time.Minute)
+
+	tests := []test.Case{
+		{
+			Name: "wrong password: attempt counted",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs("john.doe@example.com").
+					WillReturnRows(userRows(0, nil))
+
+				mock.ExpectQuery("UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE id = $1 RETURNING failed_login_attempts").
+					WithArgs("USER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts"}).AddRow(1))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    "john.doe@example.com",
+					Password: "wrongword",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "user not found",
+				},
+			},
+		},
+		{
+			Name: "wrong password: account locked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs("john.doe@example.com").
+					WillReturnRows(userRows(2, nil))
+
+				mock.ExpectQuery("UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE id = $1 RETURNING failed_login_attempts").
+					WithArgs("USER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts"}).AddRow(3))
+
+				mock.ExpectExec("UPDATE users SET locked_until = $1 WHERE id = $2").
+					WithArgs(sqlmock.AnyArg(), "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    "john.doe@example.com",
+					Password: "wrongword",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "user not found",
+				},
+			},
+		},
+		{
+			Name: "locked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs("john.doe@example.com").
+					WillReturnRows(userRows(3, &lockedUntil))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    "john.doe@example.com",
+					Password: "testword",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusTooManyRequests,
+				Body: responsebody.Message{
+					Message: "account temporarily locked",
+				},
+			},
+		},
+		{
+			Name: "ok: lock expired",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs("john.doe@example.com").
+					WillReturnRows(userRows(3, &lockExpired))
+
+				mock.ExpectExec("UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs("USER_ID", sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+			},
+
+			Request: test.Request{
+				Body: requestbody.CreateSession{
+					Login:    "john.doe@example.com",
+					Password: "testword",
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/auth/session", "/api/auth/session", handler.CreateSession)
+	}
+}
+
+func TestCompleteTwoFactor(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := mocktoken.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), tokenManager, password.NewArgon2id())
+
+	secret := "JBSWY3DPEHPK3PXP"
+
+	challenge, err := tokenManager.GenerateChallenge("USER_ID", "Laptop")
+	if err != nil {
+		t.Fatal("unexpected error while generating challenge token")
+	}
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error while generating TOTP code: %v\n", err)
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				expectUseChallenge(mock, nil)
+				expectTOTPCounter(mock, 1)
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs("USER_ID", sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "Laptop", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: challenge,
+					Code:           code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "ok: recovery code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				expectUseChallenge(mock, nil)
+
+				mock.ExpectExec("UPDATE recovery_codes SET is_used = true WHERE user_id = $1 AND code_hash = $2 AND is_used = false").
+					WithArgs("USER_ID", sha256.String("abcde12345")).
+					WillReturnResult(driver.RowsAffected(1))
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs("USER_ID", sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "Laptop", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: challenge,
+					Code:           "ABCDE-12345",
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "invalid request body",
+
+			Request: test.Request{
+				Body: map[string]string{
+					"invalid": "body",
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "access token used as challenge",
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: accessToken,
+					Code:           code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "invalid challenge token",
+				},
+			},
+		},
+		{
+			Name: "invalid code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				expectUseChallenge(mock, nil)
+
+				mock.ExpectExec("UPDATE recovery_codes SET is_used = true WHERE user_id = $1 AND code_hash = $2 AND is_used = false").
+					WithArgs("USER_ID", sqlmock.AnyArg()).
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: challenge,
+					Code:           "000000",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "invalid verification code",
+				},
+			},
+		},
+		{
+			Name: "reused code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				expectUseChallenge(mock, nil)
+				expectTOTPCounter(mock, 0)
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: challenge,
+					Code:           code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "invalid verification code",
+				},
+			},
+		},
+		{
+			Name: "reused challenge",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				expectUseChallenge(mock, &pq.Error{Code: "23505"})
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: challenge,
+					Code:           code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "invalid challenge token",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteTwoFactor{
+					ChallengeToken: challenge,
+					Code:           code,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/auth/session/2fa", "/api/auth/session/2fa", handler.CompleteTwoFactor)
+	}
+}
+
+func expectUseChallenge(mock sqlmock.Sqlmock, err error) {
+	expect := mock.ExpectExec("INSERT INTO used_challenges (jti, expires_at) VALUES ($1, $2)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg())
+	if err != nil {
+		expect.WillReturnError(err)
+		return
+	}
+	expect.WillReturnResult(driver.RowsAffected(1))
+}
+
+func TestRefreshSession(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	session := entity.Session{
+		ID:               "SESSION_ID",
+		FamilyID:         "FAMILY_ID",
+		UserID:           "USER_ID",
+		RefreshTokenHash: sha256.String("OLD_REFRESH_TOKEN"),
+		IsUsed:           false,
+		ExpiresAt:        time.Now().Add(time.Hour),
+		CreatedAt:        time.Now(),
+		Device:           "Pixel 9",
+	}
+
+	columns := []string{"id", "family_id", "user_id", "refresh_token_hash", "is_used", "expires_at", "created_at", "device"}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(session.ID, session.FamilyID, session.UserID, session.RefreshTokenHash, session.IsUsed, session.ExpiresAt, session.CreatedAt, session.Device)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs(session.UserID).WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sessions SET is_used = true WHERE id = $1 AND is_used = false").
+					WithArgs(session.ID).WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs(session.UserID, session.FamilyID, sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), session.Device, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "invalid request body",
+
+			Request: test.Request{
+				Body: map[string]string{
+					"some": "invalid body",
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "unknown refresh token",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnError(repoerr.ErrSessionNotFound)
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "invalid refresh token",
+				},
+			},
+		},
+		{
+			Name: "reuse detected",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(session.ID, session.FamilyID, session.UserID, session.RefreshTokenHash, true, session.ExpiresAt, session.CreatedAt, session.Device)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				mock.ExpectExec("DELETE FROM sessions WHERE family_id = $1").
+					WithArgs(session.FamilyID).WillReturnResult(driver.RowsAffected(2))
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "refresh token reuse detected",
+				},
+			},
+		},
+		{
+			Name: "concurrent rotation",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(session.ID, session.FamilyID, session.UserID, session.RefreshTokenHash, session.IsUsed, session.ExpiresAt, session.CreatedAt, session.Device)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs(session.UserID).WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sessions SET is_used = true WHERE id = $1 AND is_used = false").
+					WithArgs(session.ID).WillReturnResult(driver.RowsAffected(0))
+				mock.ExpectRollback()
+
+				mock.ExpectExec("DELETE FROM sessions WHERE family_id = $1").
+					WithArgs(session.FamilyID).WillReturnResult(driver.RowsAffected(2))
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "refresh token reuse detected",
+				},
+			},
+		},
+		{
+			Name: "failed rotation keeps token unused",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(session.ID, session.FamilyID, session.UserID, session.RefreshTokenHash, session.IsUsed, session.ExpiresAt, session.CreatedAt, session.Device)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs(session.UserID).WillReturnRows(roleUserRows(role.User, nil))
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE sessions SET is_used = true WHERE id = $1 AND is_used = false").
+					WithArgs(session.ID).WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs(session.UserID, session.FamilyID, sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), session.Device, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(errors.New("repo: Some repository error"))
+				mock.ExpectRollback()
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+		{
+			Name: "suspended",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(session.ID, session.FamilyID, session.UserID, session.RefreshTokenHash, session.IsUsed, session.ExpiresAt, session.CreatedAt, session.Device)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				suspendedAt := time.Now()
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs(session.UserID).WillReturnRows(roleUserRows(role.User, &suspendedAt))
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "account suspended",
+				},
+			},
+		},
+		{
+			Name: "refresh token expired",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(session.ID, session.FamilyID, session.UserID, session.RefreshTokenHash, session.IsUsed, time.Now().Add(-time.Hour), session.CreatedAt, session.Device)
+
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "refresh token expired",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM sessions WHERE refresh_token_hash = $1").
+					WithArgs(session.RefreshTokenHash).WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Body: requestbody.RefreshSession{
+					RefreshToken: "OLD_REFRESH_TOKEN",
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/auth/refresh", "/api/auth/refresh", handler.RefreshSession)
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM sessions WHERE family_id = $1").
+					WithArgs("SESSION_ID").WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM sessions WHERE family_id = $1").
+					WithArgs("SESSION_ID").WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/auth/session", "/api/auth/session", handler.UserIdentity, handler.DeleteSession)
+	}
+}
+
+func TestDeleteAllSessions(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM sessions WHERE user_id = $1").
+					WithArgs("USER_ID").WillReturnResult(driver.RowsAffected(3))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM sessions WHERE user_id = $1").
+					WithArgs("USER_ID").WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/auth/sessions", "/api/auth/sessions", handler.UserIdentity, handler.DeleteAllSessions)
+	}
+}
+
+func sessionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "family_id", "user_id", "refresh_token_hash", "is_used", "expires_at", "created_at", "device", "user_agent", "ip_address", "last_seen_at"}).
+		AddRow("NEW_SESSION_ID", "FAMILY_ID", "USER_ID", sha256.String("REFRESH_TOKEN"), false, time.Now().Add(time.Hour), time.Now(), "", "", "", time.Now())
+}
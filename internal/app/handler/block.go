@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Block a user
+// @Description  blocks a user. Blocked users can't see current user's profile and workouts, follow, comment or give kudos, and both users disappear from each other's feeds. Blocking removes follows between the users
+// @Security     AccessToken
+// @Tags         user
+// @Produce      json
+// @Param        username path            string true "Username"
+// @Success      201
+// @Failure      400 {object}             responsebody.Message
+// @Failure      401 {object}             responsebody.Message
+// @Failure      403 {object}             responsebody.Message
+// @Failure      404 {object}             responsebody.Message
+// @Failure      409 {object}             responsebody.Message
+// @Router       /user/{username}/block   [post]
+func (h *Handler) BlockUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.BlockUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+	if user.ID == userID {
+		log.Debug("user tried to block themselves")
+		response.WithMessage(c, http.StatusBadRequest, "can't block yourself")
+		return
+	}
+
+	err := h.repository.Block.Create(c, userID, user.ID)
+	if errors.Is(err, repoerr.ErrBlockExists) {
+		log.Debug("user is already blocked", slog.String("blocked_id", user.ID))
+		response.WithMessage(c, http.StatusConflict, "user is already blocked")
+		return
+	}
+	if err != nil {
+		log.Error("can't block user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user blocked", slog.String("blocker_id", userID), slog.String("blocked_id", user.ID))
+
+	c.Status(http.StatusCreated)
+}
+
+// @Summary      Unblock a user
+// @Description  unblocks a user. Follows removed by blocking are not restored
+// @Security     AccessToken
+// @Tags         user
+// @Produce      json
+// @Param        username path            string true "Username"
+// @Success      200
+// @Failure      401 {object}             responsebody.Message
+// @Failure      403 {object}             responsebody.Message
+// @Failure      404 {object}             responsebody.Message
+// @Router       /user/{username}/block   [delete]
+func (h *Handler) UnblockUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UnblockUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+
+	err := h.repository.Block.Delete(c, userID, user.ID)
+	if errors.Is(err, repoerr.ErrBlockNotFound) {
+		log.Debug("user is not blocked", slog.String("blocked_id", user.ID))
+		response.WithMessage(c, http.StatusNotFound, "user is not blocked")
+		return
+	}
+	if err != nil {
+		log.Error("can't unblock user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user unblocked", slog.String("blocker_id", userID), slog.String("blocked_id", user.ID))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Get blocked users
+// @Description  returns users, that current user blocked, the latest first
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}      responsebody.ListedUserList
+// @Failure      401 {object}      responsebody.Message
+// @Failure      403 {object}      responsebody.Message
+// @Router       /account/blocks   [get]
+func (h *Handler) GetBlockedUsers(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetBlockedUsers"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	users, err := h.repository.Block.Blocked(c, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get blocked users", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, userListResponse(users))
+}
+
+// unblocked makes sure, that current user and given one don't block each
+// other. Otherwise it responds as if the user didn't exist with given message
+func (h *Handler) unblocked(c *gin.Context, log *slog.Logger, otherID string, notFound string) bool {
+	userID := c.GetString("UserID")
+	if userID == "" || userID == otherID {
+		return true
+	}
+
+	blocked, err := h.repository.Block.Exists(c, userID, otherID)
+	if err != nil {
+		log.Error("can't check block", sl.Err(err))
+		response.InternalServerError(c)
+		return false
+	}
+
+	if blocked {
+		log.Debug("users block each other", slog.String("user_id", otherID))
+		response.WithMessage(c, http.StatusNotFound, notFound)
+		return false
+	}
+
+	return true
+}
+
+func userListResponse(users []entity.ListedUser) responsebody.ListedUserList {
+	list := make([]responsebody.ListedUser, 0, len(users))
+	for _, user := range users {
+		list = append(list, responsebody.ListedUser{
+			ID:          user.ID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarURL,
+			ListedAt:    user.ListedAt.Format(time.RFC3339),
+		})
+	}
+
+	return responsebody.ListedUserList{
+		Count: len(list),
+		Users: list,
+	}
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	blockedQuery            = "SELECT EXISTS (SELECT 1 FROM blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))"
+	createBlockQuery        = "INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)"
+	deleteBlockFollowsQuery = "DELETE FROM follows WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)"
+	deleteBlockQuery        = "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2"
+	blockedUsersQuery       = "SELECT users.id, users.username, users.display_name, users.avatar_url, blocks.created_at AS listed_at FROM blocks JOIN users ON users.id = blocks.blocked_id WHERE blocks.blocker_id = $1 ORDER BY blocks.created_at DESC"
+)
+
+func TestBlockUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []struct {
+		username string
+		tc       test.Case
+	}{
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectBegin()
+					mock.ExpectExec(createBlockQuery).
+						WithArgs("USER_ID", "OTHER_ID").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec(deleteBlockFollowsQuery).
+						WithArgs("USER_ID", "OTHER_ID").
+						WillReturnResult(sqlmock.NewResult(0, 2))
+					mock.ExpectCommit()
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusCreated,
+				},
+			},
+		},
+		{
+			username: "johndoe",
+			tc: test.Case{
+				Name: "yourself",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "USER_ID", "johndoe", false)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "can't block yourself",
+					},
+				},
+			},
+		},
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "already blocked",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectBegin()
+					mock.ExpectExec(createBlockQuery).
+						WithArgs("USER_ID", "OTHER_ID").
+						WillReturnError(&pq.Error{Code: "23505"})
+					mock.ExpectRollback()
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusConflict,
+					Body: responsebody.Message{
+						Message: "user is already blocked",
+					},
+				},
+			},
+		},
+		{
+			username: "nobody",
+			tc: test.Case{
+				Name: "user not found",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					mock.ExpectQuery(userByUsernameQuery).
+						WithArgs("nobody").
+						WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusNotFound,
+					Body: responsebody.Message{
+						Message: "user not found",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodPost, "/api/user/:username/block", fmt.Sprintf("/api/user/%s/block", tt.username), handler.UserIdentity, handler.BlockUser)
+	}
+}
+
+func TestUnblockUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectExec(deleteBlockQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not blocked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectExec(deleteBlockQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "user is not blocked",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/user/:username/block", "/api/user/janedoe/block", handler.UserIdentity, handler.UnblockUser)
+	}
+}
+
+func TestGetBlockedUsers(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	blockedAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery(blockedUsersQuery).
+					WithArgs("USER_ID").
+					WillReturnRows(listedUserRows("OTHER_ID", "janedoe", "Jane Doe", blockedAt))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.ListedUserList{
+					Count: 1,
+					Users: []responsebody.ListedUser{
+						{ID: "OTHER_ID", Username: "janedoe", DisplayName: "Jane Doe", ListedAt: blockedAt.Format(time.RFC3339)},
+					},
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery(blockedUsersQuery).
+					WithArgs("USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/account/blocks", "/api/account/blocks", handler.UserIdentity, handler.GetBlockedUsers)
+	}
+}
+
+func expectBlocked(mock sqlmock.Sqlmock, userID string, otherID string, blocked bool) {
+	mock.ExpectQuery(blockedQuery).
+		WithArgs(userID, otherID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(blocked))
+}
+
+func listedUserRows(id string, username string, displayName string, listedAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "username", "display_name", "avatar_url", "listed_at"}).
+		AddRow(id, username, displayName, "", listedAt)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"api/internal/app/handler/catalog"
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Search the catalog
+// @Description  returns built-in and user's custom activities and exercises, whose name or alias contains given query
+// @Security     AccessToken
+// @Tags         catalog
+// @Produce      json
+// @Param        type query      string false "Entry type: activity or exercise"
+// @Param        category query  string false "Entry category"
+// @Param        q query         string false "Part of name or alias"
+// @Success      200 {object}    responsebody.CatalogEntryList
+// @Failure      400 {object}    responsebody.Message
+// @Failure      401 {object}    responsebody.Message
+// @Router       /catalog        [get]
+func (h *Handler) GetCatalog(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetCatalog"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	entryType := c.Query("type")
+	if entryType != "" && entryType != catalog.Activity && entryType != catalog.Exercise {
+		log.Debug("invalid entry type", slog.String("type", entryType))
+		response.WithMessage(c, http.StatusBadRequest, "invalid type")
+		return
+	}
+
+	category := c.Query("category")
+	validCategory := catalog.HasCategory(entryType, category)
+	if entryType == "" {
+		validCategory = catalog.HasCategory(catalog.Activity, category) || catalog.HasCategory(catalog.Exercise, category)
+	}
+	if category != "" && !validCategory {
+		log.Debug("invalid category", slog.String("category", category))
+		response.WithMessage(c, http.StatusBadRequest, "invalid category")
+		return
+	}
+
+	userID := c.GetString("UserID")
+	entries, err := h.repository.Catalog.Search(c, userID, entryType, category, strings.TrimSpace(c.Query("q")))
+	if err != nil {
+		log.Error("can't search catalog", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	list := make([]responsebody.CatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, catalogEntryResponse(&entry))
+	}
+
+	c.JSON(http.StatusOK, responsebody.CatalogEntryList{
+		Count:   len(list),
+		Entries: list,
+	})
+}
+
+// @Summary      Create a custom catalog entry
+// @Description  creates an activity or exercise, visible to current user only. Its name can't match a name or alias of another entry
+// @Security     AccessToken
+// @Tags         catalog
+// @Accept       json
+// @Produce      json
+// @Param        input body    requestbody.CreateCatalogEntry true "Catalog entry"
+// @Success      201 {object}  responsebody.CatalogEntry
+// @Failure      400 {object}  responsebody.Message
+// @Failure      401 {object}  responsebody.Message
+// @Failure      409 {object}  responsebody.Message
+// @Router       /catalog      [post]
+func (h *Handler) CreateCatalogEntry(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.CreateCatalogEntry"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.CreateCatalogEntry
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		log.Debug("empty entry name")
+		response.WithMessage(c, http.StatusBadRequest, "name can't be empty")
+		return
+	}
+
+	if !catalog.HasCategory(body.Type, body.Category) {
+		log.Debug("invalid category", slog.String("type", body.Type), slog.String("category", body.Category))
+		response.WithMessage(c, http.StatusBadRequest, "invalid category")
+		return
+	}
+
+	userID := c.GetString("UserID")
+
+	_, err := h.repository.Catalog.GetByName(c, userID, body.Type, name)
+	if err == nil {
+		log.Debug("entry already exists", slog.String("name", name))
+		response.WithMessage(c, http.StatusConflict, "entry already exists")
+		return
+	}
+	if !errors.Is(err, repoerr.ErrCatalogEntryNotFound) {
+		log.Error("can't find entry", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	entry, err := h.repository.Catalog.Create(c, userID, body.Type, name, body.Category, body.MuscleGroups, body.MET)
+	if errors.Is(err, repoerr.ErrCatalogEntryExists) {
+		log.Debug("entry created concurrently", slog.String("name", name))
+		response.WithMessage(c, http.StatusConflict, "entry already exists")
+		return
+	}
+	if err != nil {
+		log.Error("can't create entry", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("created a catalog entry", slog.String("id", entry.ID))
+
+	c.JSON(http.StatusCreated, catalogEntryResponse(entry))
+}
+
+// @Summary      Delete a custom catalog entry
+// @Description  deletes user's custom entry. Activities of recorded workouts can't be deleted, exercises get unlinked from workouts
+// @Security     AccessToken
+// @Tags         catalog
+// @Produce      json
+// @Param        id path        string true "Entry ID"
+// @Success      200
+// @Failure      401 {object}   responsebody.Message
+// @Failure      404 {object}   responsebody.Message
+// @Failure      409 {object}   responsebody.Message
+// @Router       /catalog/{id}  [delete]
+func (h *Handler) DeleteCatalogEntry(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DeleteCatalogEntry"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	entryID := c.Param("id")
+
+	err := h.repository.Catalog.Delete(c, c.GetString("UserID"), entryID)
+	if errors.Is(err, repoerr.ErrCatalogEntryNotFound) {
+		log.Debug("entry not found", slog.String("id", entryID))
+		response.WithMessage(c, http.StatusNotFound, "entry not found")
+		return
+	}
+	if errors.Is(err, repoerr.ErrCatalogEntryInUse) {
+		log.Debug("entry is in use", slog.String("id", entryID))
+		response.WithMessage(c, http.StatusConflict, "entry is in use")
+		return
+	}
+	if err != nil {
+		log.Error("can't delete entry", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("deleted a catalog entry", slog.String("id", entryID))
+
+	c.Status(http.StatusOK)
+}
+
+func catalogEntryResponse(entry *entity.CatalogEntry) responsebody.CatalogEntry {
+	muscleGroups := []string(entry.MuscleGroups)
+	if muscleGroups == nil {
+		muscleGroups = make([]string, 0)
+	}
+
+	return responsebody.CatalogEntry{
+		ID:           entry.ID,
+		Type:         entry.Type,
+		Name:         entry.Name,
+		Category:     entry.Category,
+		MuscleGroups: muscleGroups,
+		MET:          entry.MET,
+		IsCustom:     entry.UserID != nil,
+	}
+}
@@ -0,0 +1,27 @@
+package catalog
+
+const (
+	Activity = "activity"
+	Exercise = "exercise"
+
+	// Fallback is a name of the built-in activity, that workouts of unknown
+	// kind are attached to. Their kind is kept as is
+	Fallback = "Other"
+)
+
+// Categories lists categories, allowed for each type of catalog entries
+var Categories = map[string][]string{
+	Activity: {"cardio", "strength", "flexibility", "sports", "other"},
+	Exercise: {"barbell", "dumbbell", "kettlebell", "bodyweight", "machine", "cable", "band", "other"},
+}
+
+// HasCategory reports whether given category is allowed for the type
+func HasCategory(entryType string, category string) bool {
+	for _, c := range Categories[entryType] {
+		if c == category {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,493 @@
+package handler
+
+import (
+	"api/internal/app/handler/catalog"
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const catalogEntryID = "9b2f8e52-3c1d-4a8e-b1f0-6d7c5e4a3b21"
+
+var catalogColumns = []string{"id", "user_id", "type", "name", "category", "muscle_groups", "aliases", "met", "created_at"}
+
+func catalogRows(id string, entryType string, name string) *sqlmock.Rows {
+	return sqlmock.NewRows(catalogColumns).
+		AddRow(id, nil, entryType, name, "other", "{}", "{}", "4.0", time.Now())
+}
+
+func expectCatalogName(mock sqlmock.Sqlmock, entryType string, name string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery("SELECT * FROM catalog WHERE type = $1 AND (user_id IS NULL OR user_id = $2) AND (lower(name) = lower($3) OR lower($3) = ANY(aliases)) ORDER BY user_id NULLS LAST LIMIT 1").
+		WithArgs(entryType, "USER_ID", name)
+}
+
+func TestGetCatalog(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	query := "SELECT * FROM catalog WHERE (user_id IS NULL OR user_id = $1) AND ($2 = '' OR type = $2) AND ($3 = '' OR category = $3) AND (name ILIKE $4 OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE alias ILIKE $4)) ORDER BY type, name"
+
+	tests := []struct {
+		query string
+		tc    test.Case
+	}{
+		{
+			query: "?type=exercise&category=bodyweight&q=pull",
+			tc: test.Case{
+				Name: "ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					rows := sqlmock.NewRows(catalogColumns).
+						AddRow("PULL_UP_ID", nil, catalog.Exercise, "Pull-up", "bodyweight", "{back,biceps}", "{pullup,chin-up}", "8.0", time.Now()).
+						AddRow("CUSTOM_ID", "USER_ID", catalog.Exercise, "Pull-up negative", "bodyweight", "{}", "{}", "6.5", time.Now())
+
+					mock.ExpectQuery(query).
+						WithArgs("USER_ID", catalog.Exercise, "bodyweight", "%pull%").
+						WillReturnRows(rows)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.CatalogEntryList{
+						Count: 2,
+						Entries: []responsebody.CatalogEntry{
+							{
+								ID:           "PULL_UP_ID",
+								Type:         catalog.Exercise,
+								Name:         "Pull-up",
+								Category:     "bodyweight",
+								MuscleGroups: []string{"back", "biceps"},
+								MET:          8,
+							},
+							{
+								ID:           "CUSTOM_ID",
+								Type:         catalog.Exercise,
+								Name:         "Pull-up negative",
+								Category:     "bodyweight",
+								MuscleGroups: []string{},
+								MET:          6.5,
+								IsCustom:     true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "?q=100%25",
+			tc: test.Case{
+				Name: "escaped query",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					mock.ExpectQuery(query).
+						WithArgs("USER_ID", "", "", `%100\%%`).
+						WillReturnRows(sqlmock.NewRows(catalogColumns))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.CatalogEntryList{
+						Entries: []responsebody.CatalogEntry{},
+					},
+				},
+			},
+		},
+		{
+			query: "?type=sport",
+			tc: test.Case{
+				Name: "invalid type",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid type",
+					},
+				},
+			},
+		},
+		{
+			query: "?type=activity&category=barbell",
+			tc: test.Case{
+				Name: "category of another type",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid category",
+					},
+				},
+			},
+		},
+		{
+			query: "?category=cardio",
+			tc: test.Case{
+				Name: "repository error",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					mock.ExpectQuery(query).
+						WithArgs("USER_ID", "", "cardio", "%%").
+						WillReturnError(errors.New("repo: Some repository error"))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.ResponseInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/catalog", "/api/catalog"+tt.query, handler.UserIdentity, handler.GetCatalog)
+	}
+}
+
+func TestCreateCatalogEntry(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	insert := "INSERT INTO catalog (user_id, type, name, category, muscle_groups, met) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *"
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectCatalogName(mock, catalog.Exercise, "Nordic curl").
+					WillReturnRows(sqlmock.NewRows(catalogColumns))
+
+				rows := sqlmock.NewRows(catalogColumns).
+					AddRow("CUSTOM_ID", "USER_ID", catalog.Exercise, "Nordic curl", "bodyweight", "{hamstrings}", "{}", "5.0", time.Now())
+
+				mock.ExpectQuery(insert).
+					WithArgs("USER_ID", catalog.Exercise, "Nordic curl", "bodyweight", pq.Array([]string{"hamstrings"}), 5.0).
+					WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateCatalogEntry{
+					Type:         catalog.Exercise,
+					Name:         " Nordic curl ",
+					Category:     "bodyweight",
+					MuscleGroups: []string{"hamstrings"},
+					MET:          5,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.CatalogEntry{
+					ID:           "CUSTOM_ID",
+					Type:         catalog.Exercise,
+					Name:         "Nordic curl",
+					Category:     "bodyweight",
+					MuscleGroups: []string{"hamstrings"},
+					MET:          5,
+					IsCustom:     true,
+				},
+			},
+		},
+		{
+			Name: "name of built-in entry",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectCatalogName(mock, catalog.Activity, "jog").
+					WillReturnRows(catalogRows("RUNNING_ID", catalog.Activity, "Running"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateCatalogEntry{
+					Type:     catalog.Activity,
+					Name:     "jog",
+					Category: "cardio",
+					MET:      7,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "entry already exists",
+				},
+			},
+		},
+		{
+			Name: "invalid category",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateCatalogEntry{
+					Type:     catalog.Activity,
+					Name:     "Parkour",
+					Category: "barbell",
+					MET:      8,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid category",
+				},
+			},
+		},
+		{
+			Name: "unknown muscle group",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateCatalogEntry{
+					Type:         catalog.Exercise,
+					Name:         "Neck curl",
+					Category:     "other",
+					MuscleGroups: []string{"neck"},
+					MET:          2,
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "created concurrently",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectCatalogName(mock, catalog.Activity, "Parkour").
+					WillReturnRows(sqlmock.NewRows(catalogColumns))
+
+				mock.ExpectQuery(insert).
+					WithArgs("USER_ID", catalog.Activity, "Parkour", "sports", pq.Array([]string(nil)), 8.0).
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateCatalogEntry{
+					Type:     catalog.Activity,
+					Name:     "Parkour",
+					Category: "sports",
+					MET:      8,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "entry already exists",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/catalog", "/api/catalog", handler.UserIdentity, handler.CreateCatalogEntry)
+	}
+}
+
+func TestDeleteCatalogEntry(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM catalog WHERE id = $1 AND user_id = $2").
+					WithArgs("CUSTOM_ID", "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not found or built-in",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM catalog WHERE id = $1 AND user_id = $2").
+					WithArgs("CUSTOM_ID", "USER_ID").
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "entry not found",
+				},
+			},
+		},
+		{
+			Name: "in use",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectExec("DELETE FROM catalog WHERE id = $1 AND user_id = $2").
+					WithArgs("CUSTOM_ID", "USER_ID").
+					WillReturnError(&pq.Error{Code: "23503"})
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "entry is in use",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/catalog/:id", "/api/catalog/CUSTOM_ID", handler.UserIdentity, handler.DeleteCatalogEntry)
+	}
+}
@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commentEditWindow is how long after creation the author can edit a comment
+const commentEditWindow = 15 * time.Minute
+
+// @Summary      Comment a workout
+// @Description  leaves a comment on a workout, that current user can see. A comment with `parent_id` is a reply to another comment of the same workout, users, that block each other, can't reply to each other. Text is up to 1000 characters
+// @Security     AccessToken
+// @Tags         activity
+// @Accept       json
+// @Produce      json
+// @Param        id path                 string true "Workout ID"
+// @Param        input body              requestbody.CreateComment true "Comment"
+// @Success      201 {object}            responsebody.Comment
+// @Failure      400 {object}            responsebody.Message
+// @Failure      401 {object}            responsebody.Message
+// @Failure      403 {object}            responsebody.Message
+// @Failure      404 {object}            responsebody.Message
+// @Router       /workout/{id}/comments  [post]
+func (h *Handler) CreateComment(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.CreateComment"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.CreateComment
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	text, ok := commentText(c, log, body.Text)
+	if !ok {
+		return
+	}
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	var parentID *string
+	if body.ParentID != "" {
+		parent, err := h.repository.Comment.GetByID(c, body.ParentID)
+		if err != nil && !errors.Is(err, repoerr.ErrCommentNotFound) {
+			log.Error("can't find comment", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+		if err != nil || parent.WorkoutID != workout.ID {
+			log.Debug("parent comment not found", slog.String("parent_id", body.ParentID))
+			response.WithMessage(c, http.StatusNotFound, "parent comment not found")
+			return
+		}
+
+		if !h.unblocked(c, log, parent.UserID, "parent comment not found") {
+			return
+		}
+
+		parentID = &parent.ID
+	}
+
+	comment, err := h.repository.Comment.Create(c, workout.ID, c.GetString("UserID"), parentID, text)
+	if errors.Is(err, repoerr.ErrCommentNotFound) {
+		log.Debug("workout or parent comment deleted concurrently", slog.String("workout_id", workout.ID))
+		response.WithMessage(c, http.StatusNotFound, "workout or parent comment not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't create comment", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("created a comment", slog.String("id", comment.ID), slog.String("workout_id", workout.ID))
+
+	c.JSON(http.StatusCreated, commentResponse(comment))
+}
+
+// @Summary      Get comments of a workout
+// @Description  returns comments of a workout as a tree, the oldest first. Comments of a private user's workout are visible to the user and the followers only. Comments of blocked and muted users are hidden together with replies to them
+// @Tags         activity
+// @Produce      json
+// @Param        id path                 string true "Workout ID"
+// @Success      200 {object}            responsebody.CommentList
+// @Failure      401 {object}            responsebody.Message
+// @Failure      403 {object}            responsebody.Message
+// @Failure      404 {object}            responsebody.Message
+// @Router       /workout/{id}/comments  [get]
+func (h *Handler) GetComments(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetComments"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	comments, err := h.repository.Comment.GetByWorkout(c, workout.ID, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get comments", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	tree, count := commentTree(comments)
+
+	c.JSON(http.StatusOK, responsebody.CommentList{
+		Count:    count,
+		Comments: tree,
+	})
+}
+
+// @Summary      Edit a comment
+// @Description  changes a text of a comment. Only the author can edit a comment and only within 15 minutes after it was left
+// @Security     AccessToken
+// @Tags         activity
+// @Accept       json
+// @Produce      json
+// @Param        id path                              string true "Workout ID"
+// @Param        comment_id path                      string true "Comment ID"
+// @Param        input body                           requestbody.UpdateComment true "New text"
+// @Success      200 {object}                         responsebody.Comment
+// @Failure      400 {object}                         responsebody.Message
+// @Failure      401 {object}                         responsebody.Message
+// @Failure      403 {object}                         responsebody.Message
+// @Failure      404 {object}                         responsebody.Message
+// @Router       /workout/{id}/comments/{comment_id}  [patch]
+func (h *Handler) UpdateComment(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UpdateComment"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.UpdateComment
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	text, ok := commentText(c, log, body.Text)
+	if !ok {
+		return
+	}
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.workoutComment(c, log, workout)
+	if !ok {
+		return
+	}
+
+	if comment.UserID != c.GetString("UserID") {
+		log.Debug("user id doesn't match with comment's author id", slog.String("id", comment.ID))
+		response.WithMessage(c, http.StatusForbidden, "forbidden to edit comment")
+		return
+	}
+
+	if time.Since(comment.CreatedAt) > commentEditWindow {
+		log.Debug("edit window is over", slog.String("id", comment.ID))
+		response.WithMessage(c, http.StatusForbidden, "comment can't be edited anymore")
+		return
+	}
+
+	updated, err := h.repository.Comment.Update(c, comment.ID, text)
+	if errors.Is(err, repoerr.ErrCommentNotFound) {
+		log.Debug("comment deleted concurrently", slog.String("id", comment.ID))
+		response.WithMessage(c, http.StatusNotFound, "comment not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't update comment", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, commentResponse(updated))
+}
+
+// @Summary      Delete a comment
+// @Description  deletes a comment together with replies to it. A comment can be deleted by its author and by the owner of the workout
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      json
+// @Param        id path                              string true "Workout ID"
+// @Param        comment_id path                      string true "Comment ID"
+// @Success      200
+// @Failure      401 {object}                         responsebody.Message
+// @Failure      403 {object}                         responsebody.Message
+// @Failure      404 {object}                         responsebody.Message
+// @Router       /workout/{id}/comments/{comment_id}  [delete]
+func (h *Handler) DeleteComment(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DeleteComment"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.workoutComment(c, log, workout)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+	if comment.UserID != userID && workout.UserID != userID {
+		log.Debug("user is neither comment's author nor workout's owner", slog.String("id", comment.ID))
+		response.WithMessage(c, http.StatusForbidden, "forbidden to delete comment")
+		return
+	}
+
+	err := h.repository.Comment.Delete(c, comment.ID)
+	if errors.Is(err, repoerr.ErrCommentNotFound) {
+		log.Debug("comment deleted concurrently", slog.String("id", comment.ID))
+		response.WithMessage(c, http.StatusNotFound, "comment not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't delete comment", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("deleted a comment", slog.String("id", comment.ID))
+
+	c.Status(http.StatusOK)
+}
+
+// workoutComment finds a comment by `comment_id` path parameter, that belongs
+// to given workout
+func (h *Handler) workoutComment(c *gin.Context, log *slog.Logger, workout *entity.Workout) (*entity.Comment, bool) {
+	commentID := c.Param("comment_id")
+
+	comment, err := h.repository.Comment.GetByID(c, commentID)
+	if err != nil && !errors.Is(err, repoerr.ErrCommentNotFound) {
+		log.Error("can't find comment", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+	if err != nil || comment.WorkoutID != workout.ID {
+		log.Debug("comment not found", slog.String("id", commentID))
+		response.WithMessage(c, http.StatusNotFound, "comment not found")
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// commentText trims a text of a comment and makes sure, that it isn't blank
+func commentText(c *gin.Context, log *slog.Logger, value string) (string, bool) {
+	text := strings.TrimSpace(value)
+	if text == "" {
+		log.Debug("comment is blank")
+		response.WithMessage(c, http.StatusBadRequest, "comment can't be blank")
+		return "", false
+	}
+
+	return text, true
+}
+
+// commentTree nests replies into their parent comments keeping the order and
+// returns the number of nested comments. Replies to hidden comments have no
+// parent among given ones, so they are hidden too
+func commentTree(comments []entity.Comment) ([]responsebody.Comment, int) {
+	replies := make(map[string][]entity.Comment)
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+		}
+	}
+
+	count := 0
+
+	var nest func(comment *entity.Comment) responsebody.Comment
+	nest = func(comment *entity.Comment) responsebody.Comment {
+		count++
+
+		res := commentResponse(comment)
+		for i := range replies[comment.ID] {
+			res.Replies = append(res.Replies, nest(&replies[comment.ID][i]))
+		}
+
+		return res
+	}
+
+	tree := make([]responsebody.Comment, 0)
+	for i := range comments {
+		if comments[i].ParentID == nil {
+			tree = append(tree, nest(&comments[i]))
+		}
+	}
+
+	return tree, count
+}
+
+func commentResponse(comment *entity.Comment) responsebody.Comment {
+	res := responsebody.Comment{
+		ID:        comment.ID,
+		Author:    authorResponse(comment.UserID, comment.Author),
+		Text:      comment.Text,
+		CreatedAt: comment.CreatedAt.Format(time.RFC3339),
+	}
+
+	if comment.ParentID != nil {
+		res.ParentID = *comment.ParentID
+	}
+
+	if comment.EditedAt != nil {
+		res.EditedAt = comment.EditedAt.Format(time.RFC3339)
+	}
+
+	return res
+}
+
+func authorResponse(userID string, author entity.Author) responsebody.Author {
+	return responsebody.Author{
+		ID:          userID,
+		Username:    author.Username,
+		DisplayName: author.DisplayName,
+		AvatarURL:   author.AvatarURL,
+	}
+}
@@ -0,0 +1,690 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	createCommentQuery = "WITH comment AS (INSERT INTO comments (workout_id, user_id, parent_id, text) VALUES ($1, $2, $3, $4) RETURNING *) SELECT comment.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comment JOIN users ON users.id = comment.user_id"
+	commentQuery       = "SELECT comments.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comments JOIN users ON users.id = comments.user_id WHERE comments.id = $1"
+	commentsQuery      = "SELECT comments.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comments JOIN users ON users.id = comments.user_id WHERE comments.workout_id = $1 AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = comments.user_id) OR (blocks.blocker_id = comments.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = comments.user_id) ORDER BY comments.created_at ASC"
+	updateCommentQuery = "WITH comment AS (UPDATE comments SET text = $1, edited_at = now() WHERE id = $2 RETURNING *) SELECT comment.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comment JOIN users ON users.id = comment.user_id"
+	deleteCommentQuery = "DELETE FROM comments WHERE id = $1"
+)
+
+var commentColumns = []string{"id", "workout_id", "user_id", "parent_id", "text", "edited_at", "created_at", "author_username", "author_display_name", "author_avatar_url"}
+
+func TestCreateComment(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	createdAt := time.Date(2024, time.May, 3, 10, 30, 0, 0, time.UTC)
+	parentID := "6f1c2b1e-3f1a-4a5e-9a55-0b4c3e1d2a10"
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				mock.ExpectQuery(createCommentQuery).
+					WithArgs("WORKOUT_ID", "USER_ID", nil, "Nice pace!").
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow("COMMENT_ID", "WORKOUT_ID", "USER_ID", nil, "Nice pace!", nil, createdAt, "johndoe", "John Doe", ""))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": "  Nice pace!\n",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Comment{
+					ID:        "COMMENT_ID",
+					Author:    responsebody.Author{ID: "USER_ID", Username: "johndoe", DisplayName: "John Doe"},
+					Text:      "Nice pace!",
+					CreatedAt: "2024-05-03T10:30:00Z",
+				},
+			},
+		},
+		{
+			Name: "reply",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+
+				mock.ExpectQuery(commentQuery).
+					WithArgs(parentID).
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow(parentID, "WORKOUT_ID", "OTHER_ID", nil, "Thanks!", nil, createdAt, "janedoe", "", ""))
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(createCommentQuery).
+					WithArgs("WORKOUT_ID", "USER_ID", parentID, "You're welcome").
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow("COMMENT_ID", "WORKOUT_ID", "USER_ID", parentID, "You're welcome", nil, createdAt, "johndoe", "", ""))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text":      "You're welcome",
+					"parent_id": parentID,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Comment{
+					ID:        "COMMENT_ID",
+					ParentID:  parentID,
+					Author:    responsebody.Author{ID: "USER_ID", Username: "johndoe"},
+					Text:      "You're welcome",
+					CreatedAt: "2024-05-03T10:30:00Z",
+				},
+			},
+		},
+		{
+			Name: "reply to a blocked user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+
+				mock.ExpectQuery(commentQuery).
+					WithArgs(parentID).
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow(parentID, "WORKOUT_ID", "THIRD_USER_ID", nil, "Congrats", nil, createdAt, "jimdoe", "", ""))
+
+				expectBlocked(mock, "USER_ID", "THIRD_USER_ID", true)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text":      "You're welcome",
+					"parent_id": parentID,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "parent comment not found",
+				},
+			},
+		},
+		{
+			Name: "parent of another workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				mock.ExpectQuery(commentQuery).
+					WithArgs(parentID).
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow(parentID, "ANOTHER_WORKOUT_ID", "OTHER_ID", nil, "Thanks!", nil, createdAt, "janedoe", "", ""))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text":      "Hi",
+					"parent_id": parentID,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "parent comment not found",
+				},
+			},
+		},
+		{
+			Name: "private workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": "Hi",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "workout is private",
+				},
+			},
+		},
+		{
+			Name: "blank text",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": " \n\t ",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "comment can't be blank",
+				},
+			},
+		},
+		{
+			Name: "too long text",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": strings.Repeat("a", 1001),
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/workout/:id/comments", "/api/workout/WORKOUT_ID/comments", handler.UserIdentity, handler.CreateComment)
+	}
+}
+
+func TestGetComments(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	createdAt := time.Date(2024, time.May, 3, 10, 30, 0, 0, time.UTC)
+	editedAt := createdAt.Add(5 * time.Minute)
+	firstID := "FIRST_ID"
+	secondID := "SECOND_ID"
+
+	tests := []test.Case{
+		{
+			Name: "tree",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				mock.ExpectQuery(commentsQuery).
+					WithArgs("WORKOUT_ID", nil).
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow(firstID, "WORKOUT_ID", "USER_ID", nil, "Nice pace!", editedAt, createdAt, "johndoe", "", "").
+						AddRow(secondID, "WORKOUT_ID", "OTHER_ID", firstID, "Thanks!", nil, createdAt.Add(time.Minute), "janedoe", "", "").
+						AddRow("THIRD_ID", "WORKOUT_ID", "USER_ID", secondID, "Any time", nil, createdAt.Add(2*time.Minute), "johndoe", "", "").
+						AddRow("FOURTH_ID", "WORKOUT_ID", "THIRD_USER_ID", nil, "Congrats", nil, createdAt.Add(3*time.Minute), "jimdoe", "", ""))
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.CommentList{
+					Count: 4,
+					Comments: []responsebody.Comment{
+						{
+							ID:        firstID,
+							Author:    responsebody.Author{ID: "USER_ID", Username: "johndoe"},
+							Text:      "Nice pace!",
+							CreatedAt: "2024-05-03T10:30:00Z",
+							EditedAt:  "2024-05-03T10:35:00Z",
+							Replies: []responsebody.Comment{
+								{
+									ID:        secondID,
+									ParentID:  firstID,
+									Author:    responsebody.Author{ID: "OTHER_ID", Username: "janedoe"},
+									Text:      "Thanks!",
+									CreatedAt: "2024-05-03T10:31:00Z",
+									Replies: []responsebody.Comment{
+										{
+											ID:        "THIRD_ID",
+											ParentID:  secondID,
+											Author:    responsebody.Author{ID: "USER_ID", Username: "johndoe"},
+											Text:      "Any time",
+											CreatedAt: "2024-05-03T10:32:00Z",
+										},
+									},
+								},
+							},
+						},
+						{
+							ID:        "FOURTH_ID",
+							Author:    responsebody.Author{ID: "THIRD_USER_ID", Username: "jimdoe"},
+							Text:      "Congrats",
+							CreatedAt: "2024-05-03T10:33:00Z",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "reply to a hidden comment",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				mock.ExpectQuery(commentsQuery).
+					WithArgs("WORKOUT_ID", nil).
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow(secondID, "WORKOUT_ID", "OTHER_ID", firstID, "Thanks!", nil, createdAt, "janedoe", "", ""))
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.CommentList{
+					Comments: []responsebody.Comment{},
+				},
+			},
+		},
+		{
+			Name: "private workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "workout is private",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/workout/:id/comments", "/api/workout/WORKOUT_ID/comments", handler.OptionalUserIdentity, handler.GetComments)
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	createdAt := time.Now().Add(-5 * time.Minute).UTC().Truncate(time.Second)
+	editedAt := createdAt.Add(time.Minute)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				expectComment(mock, "WORKOUT_ID", "USER_ID", createdAt)
+
+				mock.ExpectQuery(updateCommentQuery).
+					WithArgs("Nice pace!", "COMMENT_ID").
+					WillReturnRows(sqlmock.NewRows(commentColumns).
+						AddRow("COMMENT_ID", "WORKOUT_ID", "USER_ID", nil, "Nice pace!", editedAt, createdAt, "johndoe", "", ""))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": "Nice pace!",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Comment{
+					ID:        "COMMENT_ID",
+					Author:    responsebody.Author{ID: "USER_ID", Username: "johndoe"},
+					Text:      "Nice pace!",
+					CreatedAt: createdAt.Format(time.RFC3339),
+					EditedAt:  editedAt.Format(time.RFC3339),
+				},
+			},
+		},
+		{
+			Name: "another user's comment",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				expectComment(mock, "WORKOUT_ID", "OTHER_ID", createdAt)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": "Nice pace!",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "forbidden to edit comment",
+				},
+			},
+		},
+		{
+			Name: "edit window is over",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				expectComment(mock, "WORKOUT_ID", "USER_ID", time.Now().Add(-time.Hour))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": "Nice pace!",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "comment can't be edited anymore",
+				},
+			},
+		},
+		{
+			Name: "comment of another workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				expectComment(mock, "ANOTHER_WORKOUT_ID", "USER_ID", createdAt)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]any{
+					"text": "Nice pace!",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "comment not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPatch, "/api/workout/:id/comments/:comment_id", "/api/workout/WORKOUT_ID/comments/COMMENT_ID", handler.UserIdentity, handler.UpdateComment)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	createdAt := time.Date(2024, time.May, 3, 10, 30, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "author",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+
+				expectComment(mock, "WORKOUT_ID", "USER_ID", createdAt)
+
+				mock.ExpectExec(deleteCommentQuery).
+					WithArgs("COMMENT_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "workout owner",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				expectComment(mock, "WORKOUT_ID", "OTHER_ID", createdAt)
+
+				mock.ExpectExec(deleteCommentQuery).
+					WithArgs("COMMENT_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "another user's comment",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+
+				expectComment(mock, "WORKOUT_ID", "THIRD_USER_ID", createdAt)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "forbidden to delete comment",
+				},
+			},
+		},
+		{
+			Name: "not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+
+				mock.ExpectQuery(commentQuery).
+					WithArgs("COMMENT_ID").
+					WillReturnRows(sqlmock.NewRows(commentColumns))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "comment not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/workout/:id/comments/:comment_id", "/api/workout/WORKOUT_ID/comments/COMMENT_ID", handler.UserIdentity, handler.DeleteComment)
+	}
+}
+
+func expectComment(mock sqlmock.Sqlmock, workoutID string, userID string, createdAt time.Time) {
+	mock.ExpectQuery(commentQuery).
+		WithArgs("COMMENT_ID").
+		WillReturnRows(sqlmock.NewRows(commentColumns).
+			AddRow("COMMENT_ID", workoutID, userID, nil, "Nice pace!", nil, createdAt, "johndoe", "", ""))
+}
@@ -0,0 +1,515 @@
+package handler
+
+import (
+	"api/internal/app/handler/catalog"
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"api/pkg/units"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	maxCSVSize = 10 * 1024 * 1024 // 10Mb
+	// maxCSVFormOverhead leaves room for other form fields and boundaries
+	maxCSVFormOverhead = 1024 * 1024 // 1Mb
+	maxCSVRows         = 10000
+)
+
+// csvColumns are indexes of mapped columns, -1 for missing ones
+type csvColumns struct {
+	date, duration, kind, activityID                                       int
+	distance, elevationGain, avgHeartRate, maxHeartRate, cadence, calories int
+
+	// names are column names by struct fields of requestbody.CreateWorkout,
+	// so validation errors can point to a column
+	names map[string]string
+}
+
+// @Summary      Import workouts from CSV
+// @Description  creates workouts from rows of a CSV file with a header. `mapping` is a JSON object, that maps workout fields to column names, omitted fields are read from columns, named as in CSV export. Dates are in DD-MM-YYYY format, distance and elevation are in user's units. Invalid rows and rows, that duplicate existing workouts by date, kind and duration, are skipped and reported, valid ones are created in a single transaction. With `dry_run` nothing is created
+// @Security     AccessToken
+// @Tags         activity
+// @Accept       mpfd
+// @Produce      json
+// @Param        file formData           file true "CSV file, smaller than 10Mb"
+// @Param        mapping formData        string false "Column mapping as a JSON object"
+// @Param        dry_run formData        bool false "Only validate rows"
+// @Success      200 {object}            responsebody.CSVImport
+// @Success      201 {object}            responsebody.CSVImport
+// @Failure      400 {object}            responsebody.Message
+// @Failure      401 {object}            responsebody.Message
+// @Failure      413 {object}            responsebody.Message
+// @Router       /workout/import/csv     [post]
+func (h *Handler) ImportWorkoutsCSV(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.ImportWorkoutsCSV"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCSVSize+maxCSVFormOverhead)
+
+	file, err := c.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		log.Debug("request body too big", sl.Err(err))
+		response.WithMessage(c, http.StatusRequestEntityTooLarge, "file should be smaller than 10Mb")
+		return
+	}
+	if err != nil {
+		log.Debug("can't get file form", sl.Err(err))
+		response.WithMessage(c, http.StatusBadRequest, "no csv file provided")
+		return
+	}
+
+	if file.Size > maxCSVSize {
+		log.Debug("file too big", slog.Int64("size", file.Size))
+		response.WithMessage(c, http.StatusBadRequest, "file should be smaller than 10Mb")
+		return
+	}
+
+	dryRun := false
+	if value := c.PostForm("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Debug("invalid dry run flag", slog.String("dry_run", value))
+			response.WithMessage(c, http.StatusBadRequest, "dry_run should be a boolean")
+			return
+		}
+	}
+
+	var mapping requestbody.CSVMapping
+	if value := c.PostForm("mapping"); value != "" {
+		dec := json.NewDecoder(strings.NewReader(value))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&mapping); err != nil {
+			log.Debug("can't decode mapping", sl.Err(err))
+			response.WithMessage(c, http.StatusBadRequest, "invalid column mapping")
+			return
+		}
+	}
+
+	system, _, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		log.Error("can't open uploaded file", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+	defer f.Close()
+
+	r := csv.NewReader(io.LimitReader(f, maxCSVSize))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		log.Debug("can't read csv header", sl.Err(err))
+		response.WithMessage(c, http.StatusBadRequest, "csv file should start with a header")
+		return
+	}
+
+	columns, err := mapColumns(header, mapping, system)
+	if err != nil {
+		log.Debug("can't map columns", sl.Err(err))
+		response.WithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res := responsebody.CSVImport{
+		DryRun: dryRun,
+		Errors: make([]responsebody.CSVImportError, 0),
+	}
+
+	var (
+		workouts []entity.Workout
+		rows     []int
+		first    time.Time
+		last     time.Time
+		resolver = activityResolver{h: h, c: c, byKind: make(map[string]resolvedActivity), byID: make(map[string]*entity.CatalogEntry)}
+		userID   = c.GetString("UserID")
+	)
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			log.Debug("can't read csv row", sl.Err(err))
+			response.WithMessage(c, http.StatusBadRequest, fmt.Sprintf("malformed csv file at line %d", parseErr.Line))
+			return
+		}
+		if err != nil {
+			log.Error("can't read csv file", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+
+		line, _ := r.FieldPos(0)
+
+		if isBlank(record) {
+			continue
+		}
+
+		res.Total++
+		if res.Total > maxCSVRows {
+			log.Debug("too many rows")
+			response.WithMessage(c, http.StatusBadRequest, fmt.Sprintf("csv file can't have more than %d rows", maxCSVRows))
+			return
+		}
+
+		workout, rowErrors, err := h.parseCSVRow(c, record, columns, system, &resolver, userID)
+		if err != nil {
+			log.Error("can't parse csv row", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+
+		if len(rowErrors) > 0 {
+			for _, e := range rowErrors {
+				e.Row = line
+				res.Errors = append(res.Errors, e)
+			}
+			res.Invalid++
+			continue
+		}
+
+		if len(workouts) == 0 || workout.Date.Before(first) {
+			first = workout.Date
+		}
+		if len(workouts) == 0 || workout.Date.After(last) {
+			last = workout.Date
+		}
+
+		workouts = append(workouts, workout)
+		rows = append(rows, line)
+	}
+
+	// duplicates are looked for among existing workouts and previous rows
+	seen := make(map[string]int)
+	if len(workouts) > 0 {
+		existing, err := h.repository.Workout.GetUserWorkouts(c, userID, first, last)
+		if err != nil {
+			log.Error("can't get workouts", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+
+		for _, workout := range existing {
+			seen[duplicateKey(&workout)] = 0
+		}
+	}
+
+	unique := make([]entity.Workout, 0, len(workouts))
+	for i := range workouts {
+		key := duplicateKey(&workouts[i])
+
+		row, ok := seen[key]
+		if !ok {
+			seen[key] = rows[i]
+			unique = append(unique, workouts[i])
+			continue
+		}
+
+		message := "duplicates an existing workout"
+		if row > 0 {
+			message = fmt.Sprintf("duplicates row %d", row)
+		}
+
+		res.Errors = append(res.Errors, responsebody.CSVImportError{Row: rows[i], Message: message})
+		res.Duplicates++
+	}
+
+	res.Valid = len(unique)
+
+	if dryRun || len(unique) == 0 {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+
+	created, err := h.repository.Workout.CreateBatch(c, userID, unique)
+	if err != nil {
+		log.Error("can't create workouts", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	res.Imported = len(created)
+
+	log.Info("imported workouts from csv", slog.Int("imported", res.Imported), slog.Int("invalid", res.Invalid), slog.Int("duplicates", res.Duplicates))
+
+	c.JSON(http.StatusCreated, res)
+}
+
+// mapColumns finds columns of mapped fields in the header. Date, duration
+// and either kind or activity id columns are required
+func mapColumns(header []string, mapping requestbody.CSVMapping, system string) (*csvColumns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+
+	columns := csvColumns{names: make(map[string]string)}
+
+	fields := []struct {
+		target   *int
+		field    string
+		column   string
+		fallback string
+	}{
+		{&columns.date, "Date", mapping.Date, "date"},
+		{&columns.duration, "Duration", mapping.Duration, "duration"},
+		{&columns.kind, "Kind", mapping.Kind, "kind"},
+		{&columns.activityID, "ActivityID", mapping.ActivityID, "activity_id"},
+		{&columns.distance, "Distance", mapping.Distance, "distance_" + units.DistanceUnit(system)},
+		{&columns.elevationGain, "ElevationGain", mapping.ElevationGain, "elevation_gain_" + units.ElevationUnit(system)},
+		{&columns.avgHeartRate, "AvgHeartRate", mapping.AvgHeartRate, "avg_heart_rate"},
+		{&columns.maxHeartRate, "MaxHeartRate", mapping.MaxHeartRate, "max_heart_rate"},
+		{&columns.cadence, "Cadence", mapping.Cadence, "cadence"},
+		{&columns.calories, "Calories", mapping.Calories, "calories"},
+	}
+
+	for _, f := range fields {
+		name := f.column
+		if name == "" {
+			name = f.fallback
+		}
+
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			if f.column != "" {
+				return nil, fmt.Errorf("column %q not found", f.column)
+			}
+			i = -1
+		}
+
+		*f.target = i
+		columns.names[f.field] = name
+	}
+
+	switch {
+	case columns.date == -1:
+		return nil, fmt.Errorf("column %q not found", columns.names["Date"])
+	case columns.duration == -1:
+		return nil, fmt.Errorf("column %q not found", columns.names["Duration"])
+	case columns.kind == -1 && columns.activityID == -1:
+		return nil, fmt.Errorf("column %q not found", columns.names["Kind"])
+	}
+
+	return &columns, nil
+}
+
+// parseCSVRow validates a row the same way, as a body of CreateWorkout is
+// validated. Returned error is reported only for failures of repository
+func (h *Handler) parseCSVRow(c *gin.Context, record []string, columns *csvColumns, system string, resolver *activityResolver, userID string) (entity.Workout, []responsebody.CSVImportError, error) {
+	var rowErrors []responsebody.CSVImportError
+	fail := func(field string, message string) {
+		rowErrors = append(rowErrors, responsebody.CSVImportError{Column: columns.names[field], Message: message})
+	}
+
+	cell := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	intCell := func(i int, field string) *int {
+		value := cell(i)
+		if value == "" {
+			return nil
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			fail(field, "should be an integer")
+			return nil
+		}
+		return &n
+	}
+
+	floatCell := func(i int, field string) *float64 {
+		value := strings.ReplaceAll(cell(i), ",", ".")
+		if value == "" {
+			return nil
+		}
+
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fail(field, "should be a number")
+			return nil
+		}
+		return &n
+	}
+
+	body := requestbody.CreateWorkout{
+		Date:       cell(columns.date),
+		ActivityID: cell(columns.activityID),
+		Kind:       cell(columns.kind),
+		Metrics: requestbody.Metrics{
+			Distance:      floatCell(columns.distance, "Distance"),
+			ElevationGain: floatCell(columns.elevationGain, "ElevationGain"),
+			AvgHeartRate:  intCell(columns.avgHeartRate, "AvgHeartRate"),
+			MaxHeartRate:  intCell(columns.maxHeartRate, "MaxHeartRate"),
+			Cadence:       intCell(columns.cadence, "Cadence"),
+			Calories:      intCell(columns.calories, "Calories"),
+		},
+	}
+	if duration := intCell(columns.duration, "Duration"); duration != nil {
+		body.Duration = *duration
+	}
+
+	var validationErrors validator.ValidationErrors
+	if err := binding.Validator.ValidateStruct(&body); errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			fail(fe.Field(), validationMessage(fe))
+		}
+	}
+
+	workout := entity.Workout{Duration: body.Duration}
+
+	if body.Date != "" {
+		date, err := validWorkoutDate(body.Date)
+		switch {
+		case errors.Is(err, errFutureWorkout):
+			fail("Date", "can't be in the future")
+		case err != nil:
+			fail("Date", "should be in DD-MM-YYYY format")
+		}
+		workout.Date = date
+	}
+
+	workout.Metrics = applyMetrics(entity.Metrics{}, body.Metrics, system)
+	if workout.AvgHeartRate != nil && workout.MaxHeartRate != nil && *workout.MaxHeartRate < *workout.AvgHeartRate {
+		fail("MaxHeartRate", "can't be lower than average heart rate")
+	}
+
+	if len(rowErrors) > 0 {
+		return workout, rowErrors, nil
+	}
+
+	activity, kind, err := resolver.resolve(userID, body.ActivityID, body.Kind)
+	if errors.Is(err, errUnknownActivity) {
+		fail("ActivityID", "unknown activity")
+		return workout, rowErrors, nil
+	}
+	if err != nil {
+		return workout, nil, err
+	}
+
+	workout.ActivityID = activity.ID
+	workout.Kind = kind
+
+	return workout, nil, nil
+}
+
+// validationMessage describes a failed validation rule of a field
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("should be at least %s", fe.Param())
+	case "max":
+		if fe.Kind().String() == "string" {
+			return fmt.Sprintf("can't be longer than %s characters", fe.Param())
+		}
+		return fmt.Sprintf("should be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("should be greater than %s", fe.Param())
+	case "uuid":
+		return "should be a UUID"
+	}
+
+	return "is invalid"
+}
+
+var errUnknownActivity = errors.New("unknown activity")
+
+type resolvedActivity struct {
+	activity *entity.CatalogEntry
+	kind     string
+}
+
+// activityResolver resolves activities of rows, caching catalog lookups,
+// as most rows share a handful of kinds
+type activityResolver struct {
+	h      *Handler
+	c      *gin.Context
+	byKind map[string]resolvedActivity
+	byID   map[string]*entity.CatalogEntry
+}
+
+func (r *activityResolver) resolve(userID string, activityID string, kind string) (*entity.CatalogEntry, string, error) {
+	if activityID != "" {
+		if activity, ok := r.byID[activityID]; ok {
+			return activity, activity.Name, nil
+		}
+
+		activity, err := r.h.repository.Catalog.GetByID(r.c, userID, activityID)
+		if errors.Is(err, repoerr.ErrCatalogEntryNotFound) || err == nil && activity.Type != catalog.Activity {
+			return nil, "", errUnknownActivity
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		r.byID[activityID] = activity
+		return activity, activity.Name, nil
+	}
+
+	key := strings.ToLower(kind)
+	if resolved, ok := r.byKind[key]; ok {
+		return resolved.activity, resolved.kind, nil
+	}
+
+	activity, kind, err := r.h.activityByKind(r.c, userID, kind)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r.byKind[key] = resolvedActivity{activity: activity, kind: kind}
+	return activity, kind, nil
+}
+
+// duplicateKey identifies workouts, that are considered the same
+func duplicateKey(workout *entity.Workout) string {
+	return fmt.Sprintf("%s|%s|%d", workout.Date.Format(time.DateOnly), strings.ToLower(workout.Kind), workout.Duration)
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"api/internal/app/handler/catalog"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"api/pkg/units"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestImportWorkoutsCSV(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	first := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	third := first.AddDate(0, 0, 2)
+
+	dryRunBody, dryRunType := multipartBody(t, "workouts.csv",
+		"Date,Duration,Kind,Distance_km,avg_heart_rate,max_heart_rate\n"+
+			"01-05-2024,30,Running,\"5,5\",150,160\n"+
+			"02-05-2024,0,Running,,,\n"+
+			"\n"+
+			"31-02-2024,45,Cycling,,,\n"+
+			"03-05-2024,40,Running,far,150,140\n",
+		map[string]string{"dry_run": "true"})
+
+	mappedBody, mappedType := multipartBody(t, "workouts.csv",
+		"Day,Minutes,Sport\n"+
+			"01-05-2024,30,Running\n"+
+			"01-05-2024,30,running\n"+
+			"02-05-2024,69,Calisthenics\n"+
+			"03-05-2024,45,Yoga\n",
+		map[string]string{"mapping": `{"date":"Day","duration":"Minutes","kind":"Sport"}`})
+
+	missingBody, missingType := multipartBody(t, "workouts.csv", "date,kind\n01-05-2024,Running\n", nil)
+	unknownColumnBody, unknownColumnType := multipartBody(t, "workouts.csv", "date,duration,kind\n", map[string]string{"mapping": `{"calories":"Energy"}`})
+	badMappingBody, badMappingType := multipartBody(t, "workouts.csv", "date,duration,kind\n", map[string]string{"mapping": `{"pace":"Pace"}`})
+	dryRunFlagBody, dryRunFlagType := multipartBody(t, "workouts.csv", "date,duration,kind\n", map[string]string{"dry_run": "maybe"})
+	bigBody, bigType := multipartBody(t, "workouts.csv", strings.Repeat(" ", maxCSVSize+maxCSVFormOverhead), nil)
+
+	tests := []test.Case{
+		{
+			Name: "dry run with invalid rows",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, "Running").
+					WillReturnRows(catalogRows("RUNNING_ID", catalog.Activity, "Running"))
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC").
+					WithArgs("USER_ID", first, first).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  dryRunType,
+				},
+				Body: dryRunBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.CSVImport{
+					DryRun:  true,
+					Total:   4,
+					Valid:   1,
+					Invalid: 3,
+					Errors: []responsebody.CSVImportError{
+						{Row: 3, Column: "duration", Message: "is required"},
+						{Row: 5, Column: "date", Message: "should be in DD-MM-YYYY format"},
+						{Row: 6, Column: "distance_km", Message: "should be a number"},
+						{Row: 6, Column: "max_heart_rate", Message: "can't be lower than average heart rate"},
+					},
+				},
+			},
+		},
+		{
+			Name: "mapped columns with duplicates",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, "Running").
+					WillReturnRows(catalogRows("RUNNING_ID", catalog.Activity, "Running"))
+				expectCatalogName(mock, catalog.Activity, "Calisthenics").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Calisthenics"))
+				expectCatalogName(mock, catalog.Activity, "Yoga").
+					WillReturnRows(sqlmock.NewRows(catalogColumns))
+				expectCatalogName(mock, catalog.Activity, catalog.Fallback).
+					WillReturnRows(catalogRows("OTHER_ID", catalog.Activity, catalog.Fallback))
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC").
+					WithArgs("USER_ID", first, third).
+					WillReturnRows(workoutRows("USER_ID", second))
+
+				columns := []string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs("USER_ID", "RUNNING_ID", first, 30, "Running", nil, nil, nil, nil, nil, nil, nil, "").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("RUN_ID", "USER_ID", "RUNNING_ID", first, 30, "Running", time.Now()))
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs("USER_ID", "OTHER_ID", third, 45, "Yoga", nil, nil, nil, nil, nil, nil, nil, "").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("YOGA_ID", "USER_ID", "OTHER_ID", third, 45, "Yoga", time.Now()))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  mappedType,
+				},
+				Body: mappedBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.CSVImport{
+					Total:      4,
+					Valid:      2,
+					Imported:   2,
+					Duplicates: 2,
+					Errors: []responsebody.CSVImportError{
+						{Row: 3, Message: "duplicates row 2"},
+						{Row: 4, Message: "duplicates an existing workout"},
+					},
+				},
+			},
+		},
+		{
+			Name: "missing column",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  missingType,
+				},
+				Body: missingBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: `column "duration" not found`,
+				},
+			},
+		},
+		{
+			Name: "unknown mapped column",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  unknownColumnType,
+				},
+				Body: unknownColumnBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: `column "Energy" not found`,
+				},
+			},
+		},
+		{
+			Name: "invalid mapping",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  badMappingType,
+				},
+				Body: badMappingBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid column mapping",
+				},
+			},
+		},
+		{
+			Name: "invalid dry run flag",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  dryRunFlagType,
+				},
+				Body: dryRunFlagBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "dry_run should be a boolean",
+				},
+			},
+		},
+		{
+			Name: "too big file",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  bigType,
+				},
+				Body: bigBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusRequestEntityTooLarge,
+				Body: responsebody.Message{
+					Message: "file should be smaller than 10Mb",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/workout/import/csv", "/api/workout/import/csv", handler.UserIdentity, handler.ImportWorkoutsCSV)
+	}
+}
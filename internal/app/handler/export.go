@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"api/internal/app/handler/response"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	"api/pkg/ical"
+	"api/pkg/requestid"
+	"api/pkg/track"
+	"api/pkg/units"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// exportBatchSize is a number of workouts, that are written at once
+	exportBatchSize = 100
+
+	exportProductID = "-//yodreik//API//EN"
+)
+
+// workoutEncoder writes workouts of an export one by one
+type workoutEncoder interface {
+	Begin() error
+	Encode(workout *entity.Workout) error
+	Flush() error
+	End() error
+}
+
+type exportFormat struct {
+	contentType string
+
+	// withTracks and withDetails tell, whether raw tracks or exercises and
+	// laps of workouts are needed
+	withTracks  bool
+	withDetails bool
+
+	encoder func(w io.Writer, system string) workoutEncoder
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		encoder:     newCSVEncoder,
+	},
+	"json": {
+		contentType: "application/json; charset=utf-8",
+		withDetails: true,
+		encoder:     newJSONEncoder,
+	},
+	"ics": {
+		contentType: "text/calendar; charset=utf-8",
+		encoder:     newICSEncoder,
+	},
+	"gpx": {
+		contentType: "application/gpx+xml",
+		withTracks:  true,
+		encoder:     newGPXEncoder,
+	},
+}
+
+// @Summary      Export workouts
+// @Description  streams all workouts of current user in a date range as a file. CSV has a row per workout, JSON is an array of workouts with their exercises and laps, iCalendar has an event per workout, that lasts all day, unless the workout has a start time, and GPX has a track per workout with points of its imported file, if there is one. Distance and elevation are in user's units
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      text/csv,json,text/calendar,application/gpx+xml
+// @Param        format query     string false "File format: csv, json, ics or gpx, json by default"
+// @Param        begin  query     string false "Begin date"
+// @Param        end    query     string false "End date"
+// @Success      200 {file}       file
+// @Failure      400 {object}     responsebody.Message
+// @Failure      401 {object}     responsebody.Message
+// @Router       /activity/export [get]
+func (h *Handler) ExportWorkouts(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.ExportWorkouts"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	name := c.DefaultQuery("format", "json")
+	format, ok := exportFormats[name]
+	if !ok {
+		log.Debug("unknown export format", slog.String("format", name))
+		response.WithMessage(c, http.StatusBadRequest, "format should be one of csv, json, ics or gpx")
+		return
+	}
+
+	system, location, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	begin, end, ok := parseDateRange(c, log, time.Now().In(location))
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="workouts.%s"`, name))
+
+	// batches only save queries for exercises and laps. Raw tracks can be up
+	// to 10Mb each, so workouts with them are written one by one
+	batchSize := exportBatchSize
+	if format.withTracks {
+		batchSize = 1
+	}
+
+	enc := format.encoder(c.Writer, system)
+	batch := make([]*entity.Workout, 0, batchSize)
+	started := false
+
+	// flush writes collected workouts, so only a batch of them is kept in
+	// memory at once
+	flush := func() error {
+		if !started {
+			if err := enc.Begin(); err != nil {
+				return err
+			}
+			started = true
+		}
+
+		if format.withDetails && len(batch) > 0 {
+			if err := h.attachExercises(c, batch...); err != nil {
+				return err
+			}
+			if err := h.attachLaps(c, batch...); err != nil {
+				return err
+			}
+		}
+
+		for _, workout := range batch {
+			if err := enc.Encode(workout); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+
+		return nil
+	}
+
+	userID := c.GetString("UserID")
+	err := h.repository.Workout.Export(c, userID, begin, end, format.withTracks, func(workout *entity.Workout) error {
+		batch = append(batch, workout)
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		err = enc.End()
+	}
+
+	if err != nil {
+		log.Error("can't export workouts", sl.Err(err))
+
+		// once a part of the file is sent, the status can't be changed
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			response.InternalServerError(c)
+		}
+		return
+	}
+
+	log.Info("exported workouts", slog.String("format", name))
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	system string
+}
+
+func newCSVEncoder(w io.Writer, system string) workoutEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), system: system}
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.w.Write([]string{
+		"id", "date", "kind", "activity_id", "duration",
+		"distance_" + units.DistanceUnit(e.system), "elevation_gain_" + units.ElevationUnit(e.system),
+		"pace", "avg_heart_rate", "max_heart_rate", "cadence", "calories",
+	})
+}
+
+func (e *csvEncoder) Encode(workout *entity.Workout) error {
+	metrics := metricsResponse(workout, e.system)
+
+	return e.w.Write([]string{
+		workout.ID,
+		workout.Date.Format(workoutDateLayout),
+		workout.Kind,
+		workout.ActivityID,
+		strconv.Itoa(workout.Duration),
+		formatFloat(metrics.Distance),
+		formatFloat(metrics.ElevationGain),
+		formatInt(metrics.Pace),
+		formatInt(metrics.AvgHeartRate),
+		formatInt(metrics.MaxHeartRate),
+		formatInt(metrics.Cadence),
+		formatInt(metrics.Calories),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) End() error {
+	return e.Flush()
+}
+
+// jsonEncoder writes an array of workouts
+type jsonEncoder struct {
+	w      *bufio.Writer
+	system string
+	count  int
+}
+
+func newJSONEncoder(w io.Writer, system string) workoutEncoder {
+	return &jsonEncoder{w: bufio.NewWriter(w), system: system}
+}
+
+func (e *jsonEncoder) Begin() error {
+	return e.w.WriteByte('[')
+}
+
+func (e *jsonEncoder) Encode(workout *entity.Workout) error {
+	data, err := json.Marshal(workoutResponse(workout, e.system))
+	if err != nil {
+		return err
+	}
+
+	if e.count > 0 {
+		if err := e.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *jsonEncoder) End() error {
+	if err := e.w.WriteByte(']'); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+type icsEncoder struct {
+	out    io.Writer
+	w      *ical.Writer
+	system string
+}
+
+func newICSEncoder(w io.Writer, system string) workoutEncoder {
+	return &icsEncoder{out: w, system: system}
+}
+
+func (e *icsEncoder) Begin() error {
+	e.w = ical.NewWriter(e.out, exportProductID)
+	return nil
+}
+
+// Encode writes a timed event for workouts with a start time and an all-day
+// event for the rest
+func (e *icsEncoder) Encode(workout *entity.Workout) error {
+	event := ical.Event{
+		UID:         workout.ID + "@yodreik",
+		Stamp:       workout.CreatedAt,
+		Start:       workout.Date,
+		End:         workout.Date.AddDate(0, 0, 1),
+		AllDay:      true,
+		Summary:     fmt.Sprintf("%s, %d min", workout.Kind, workout.Duration),
+		Description: describeWorkout(workout, e.system),
+		Categories:  []string{workout.Kind},
+	}
+
+	if workout.StartedAt != nil {
+		event.Start = *workout.StartedAt
+		event.End = workout.StartedAt.Add(time.Duration(workout.Duration) * time.Minute)
+		event.AllDay = false
+	}
+
+	return e.w.WriteEvent(event)
+}
+
+func (e *icsEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *icsEncoder) End() error {
+	return e.w.Close()
+}
+
+type gpxEncoder struct {
+	out    io.Writer
+	w      *track.GPXWriter
+	system string
+}
+
+func newGPXEncoder(w io.Writer, system string) workoutEncoder {
+	return &gpxEncoder{out: w, system: system}
+}
+
+func (e *gpxEncoder) Begin() error {
+	w, err := track.NewGPXWriter(e.out, "yodreik")
+	if err != nil {
+		return err
+	}
+
+	e.w = w
+	return nil
+}
+
+// Encode writes a track of a workout. Workouts, that weren't imported from
+// a file, have a track without points
+func (e *gpxEncoder) Encode(workout *entity.Workout) error {
+	var points []track.Point
+	if workout.Track != nil {
+		format, ok := trackFormats[workout.Track.Format]
+		if !ok {
+			return fmt.Errorf("unknown track format %q", workout.Track.Format)
+		}
+
+		activity, err := format.parse(bytes.NewReader(workout.Track.Data))
+		if err != nil {
+			return fmt.Errorf("can't parse track of workout %s: %w", workout.ID, err)
+		}
+		points = activity.Points
+	}
+
+	name := fmt.Sprintf("%s, %s", workout.Kind, workout.Date.Format(workoutDateLayout))
+
+	return e.w.WriteTrack(name, describeWorkout(workout, e.system), workout.Kind, points)
+}
+
+func (e *gpxEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *gpxEncoder) End() error {
+	return e.w.Close()
+}
+
+// describeWorkout lists metrics of a workout in user's units, one per line
+func describeWorkout(workout *entity.Workout, system string) string {
+	metrics := metricsResponse(workout, system)
+
+	lines := []string{fmt.Sprintf("Duration: %d min", workout.Duration)}
+	if metrics.Distance != nil {
+		lines = append(lines, fmt.Sprintf("Distance: %s %s", formatFloat(metrics.Distance), units.DistanceUnit(system)))
+	}
+	if metrics.Pace != nil {
+		lines = append(lines, fmt.Sprintf("Pace: %d:%02d /%s", *metrics.Pace/60, *metrics.Pace%60, units.DistanceUnit(system)))
+	}
+	if metrics.ElevationGain != nil {
+		lines = append(lines, fmt.Sprintf("Elevation gain: %s %s", formatFloat(metrics.ElevationGain), units.ElevationUnit(system)))
+	}
+	if metrics.AvgHeartRate != nil {
+		lines = append(lines, fmt.Sprintf("Average heart rate: %d bpm", *metrics.AvgHeartRate))
+	}
+	if metrics.MaxHeartRate != nil {
+		lines = append(lines, fmt.Sprintf("Max heart rate: %d bpm", *metrics.MaxHeartRate))
+	}
+	if metrics.Cadence != nil {
+		lines = append(lines, fmt.Sprintf("Cadence: %d", *metrics.Cadence))
+	}
+	if metrics.Calories != nil {
+		lines = append(lines, fmt.Sprintf("Calories: %d kcal", *metrics.Calories))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func formatInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*value)
+}
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"api/pkg/units"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	exportQuery       = "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC, created_at ASC"
+	exportTracksQuery = "SELECT workouts.*, workout_tracks.format AS track_format, workout_tracks.data AS track_data FROM workouts LEFT JOIN workout_tracks ON workout_tracks.workout_id = workouts.id WHERE workouts.user_id = $1 AND workouts.date BETWEEN $2 AND $3 ORDER BY workouts.date ASC, workouts.created_at ASC"
+)
+
+func TestExportWorkouts(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	begin := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.May, 31, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+
+	columns := []string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "distance", "avg_heart_rate"}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow("WORKOUT_ID", "USER_ID", "ACTIVITY_ID", begin, 69, "Calisthenics", createdAt, nil, nil).
+			AddRow("RUN_ID", "USER_ID", "RUNNING_ID", begin.AddDate(0, 0, 1), 30, "Running", createdAt, 5000.0, 150)
+	}
+
+	reps := 10
+	rest := 90
+
+	tests := []struct {
+		format string
+		tc     test.Case
+	}{
+		{
+			format: "csv",
+			tc: test.Case{
+				Name: "csv",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(exportQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(rows())
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status:  http.StatusOK,
+					RawBody: true,
+					Body: "id,date,kind,activity_id,duration,distance_km,elevation_gain_m,pace,avg_heart_rate,max_heart_rate,cadence,calories\n" +
+						"WORKOUT_ID,01-05-2024,Calisthenics,ACTIVITY_ID,69,,,,,,,\n" +
+						"RUN_ID,02-05-2024,Running,RUNNING_ID,30,5,,360,150,,,\n",
+				},
+			},
+		},
+		{
+			format: "json",
+			tc: test.Case{
+				Name: "json with exercises",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(exportQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(columns).
+							AddRow("WORKOUT_ID", "USER_ID", "ACTIVITY_ID", begin, 69, "Calisthenics", createdAt, nil, nil))
+
+					expectExercises(mock, "WORKOUT_ID")
+
+					mock.ExpectQuery("SELECT * FROM workout_laps WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+						WithArgs(pq.Array([]string{"WORKOUT_ID"})).
+						WillReturnRows(sqlmock.NewRows(lapColumns))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status:  http.StatusOK,
+					RawBody: true,
+					Body: []responsebody.Workout{
+						{
+							ID:         "WORKOUT_ID",
+							ActivityID: "ACTIVITY_ID",
+							Date:       "01-05-2024",
+							Duration:   69,
+							Kind:       "Calisthenics",
+							Exercises: []responsebody.Exercise{
+								{
+									Name: "Pull-up",
+									Sets: []responsebody.ExerciseSet{
+										{Reps: &reps},
+										{Reps: &reps, RestSeconds: &rest},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			format: "ics",
+			tc: test.Case{
+				Name: "ics",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(exportQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(columns).
+							AddRow("RUN_ID", "USER_ID", "RUNNING_ID", begin, 30, "Running", createdAt, 5000.0, 150))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status:  http.StatusOK,
+					RawBody: true,
+					Body: "BEGIN:VCALENDAR\r\n" +
+						"VERSION:2.0\r\n" +
+						"PRODID:-//yodreik//API//EN\r\n" +
+						"CALSCALE:GREGORIAN\r\n" +
+						"BEGIN:VEVENT\r\n" +
+						"UID:RUN_ID@yodreik\r\n" +
+						"DTSTAMP:20240501T103000Z\r\n" +
+						"DTSTART;VALUE=DATE:20240501\r\n" +
+						"DTEND;VALUE=DATE:20240502\r\n" +
+						"SUMMARY:Running\\, 30 min\r\n" +
+						"DESCRIPTION:Duration: 30 min\\nDistance: 5 km\\nPace: 6:00 /km\\nAverage heart\r\n" +
+						"  rate: 150 bpm\r\n" +
+						"CATEGORIES:Running\r\n" +
+						"END:VEVENT\r\n" +
+						"END:VCALENDAR\r\n",
+				},
+			},
+		},
+		{
+			format: "gpx",
+			tc: test.Case{
+				Name: "gpx with tracks",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(exportTracksQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(append(columns, "track_format", "track_data")).
+							AddRow("WORKOUT_ID", "USER_ID", "ACTIVITY_ID", begin, 69, "Calisthenics", createdAt, nil, nil, nil, nil).
+							AddRow("RUN_ID", "USER_ID", "RUNNING_ID", begin, 30, "Running", createdAt, 5000.0, 150, "gpx", []byte(importGPX)))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status:  http.StatusOK,
+					RawBody: true,
+					Body: `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="yodreik" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <trk>
+    <name>Calisthenics, 01-05-2024</name>
+    <desc>Duration: 69 min</desc>
+    <type>Calisthenics</type>
+  </trk>
+  <trk>
+    <name>Running, 01-05-2024</name>
+    <desc>Duration: 30 min&#xA;Distance: 5 km&#xA;Pace: 6:00 /km&#xA;Average heart rate: 150 bpm</desc>
+    <type>Running</type>
+    <trkseg>
+      <trkpt lat="0" lon="0">
+        <ele>10</ele>
+        <time>2024-05-01T06:00:00Z</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:hr>140</gpxtpx:hr>
+          </gpxtpx:TrackPointExtension>
+        </extensions>
+      </trkpt>
+      <trkpt lat="0" lon="0.05">
+        <ele>20</ele>
+        <time>2024-05-01T06:30:00Z</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:hr>160</gpxtpx:hr>
+          </gpxtpx:TrackPointExtension>
+        </extensions>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>`,
+				},
+			},
+		},
+		{
+			format: "xlsx",
+			tc: test.Case{
+				Name: "unknown format",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "format should be one of csv, json, ics or gpx",
+					},
+				},
+			},
+		},
+		{
+			format: "csv",
+			tc: test.Case{
+				Name: "repository error",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(exportQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnError(errors.New("repo: Some repository error"))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.ResponseInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		path := fmt.Sprintf("/api/activity/export?format=%s&begin=01-05-2024&end=31-05-2024", tt.format)
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/activity/export", path, handler.UserIdentity, handler.ExportWorkouts)
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	"api/pkg/cursor"
+	"api/pkg/requestid"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// feedCursor is a position of the last workout of a feed page
+type feedCursor struct {
+	Date      string    `json:"d"`
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// @Summary      Get activity feed
+// @Description  returns a page of workouts of followed users, the newest first. Workouts of a private user are shown once a follow request is approved. Workouts visible only to their owners are skipped. Pass `next_cursor` of a page as `cursor` to get the next one, the last page has no `next_cursor`. Metrics are in units of current user
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      json
+// @Param        limit  query int    false "Number of workouts to return, 20 by default, 100 at most"
+// @Param        cursor query string false "Cursor of the next page"
+// @Success      200 {object}  responsebody.Feed
+// @Failure      400 {object}  responsebody.Message
+// @Failure      401 {object}  responsebody.Message
+// @Failure      403 {object}  responsebody.Message
+// @Router       /feed         [get]
+func (h *Handler) GetFeed(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetFeed"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	limit := defaultFeedLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxFeedLimit {
+			log.Debug("invalid limit", slog.String("limit", value))
+			response.WithMessage(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	var after *entity.WorkoutPosition
+	if value := c.Query("cursor"); value != "" {
+		var position feedCursor
+		err := cursor.Decode(value, &position)
+
+		var date time.Time
+		if err == nil {
+			date, err = time.Parse(time.DateOnly, position.Date)
+		}
+
+		if err != nil || uuid.Validate(position.ID) != nil {
+			log.Debug("invalid cursor", slog.String("cursor", value))
+			response.WithMessage(c, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+
+		after = &entity.WorkoutPosition{
+			Date:      date,
+			CreatedAt: position.CreatedAt,
+			ID:        position.ID,
+		}
+	}
+
+	system, _, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	// one more workout tells, whether there is a next page
+	workouts, err := h.repository.Workout.Feed(c, c.GetString("UserID"), after, limit+1)
+	if err != nil {
+		log.Error("can't get feed", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	var next string
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+
+		last := workouts[limit-1]
+		next, err = cursor.Encode(feedCursor{
+			Date:      last.Date.Format(time.DateOnly),
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		})
+		if err != nil {
+			log.Error("can't encode cursor", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+	}
+
+	refs := make([]*entity.Workout, 0, len(workouts))
+	for i := range workouts {
+		refs = append(refs, &workouts[i].Workout)
+	}
+
+	if err := h.attachExercises(c, refs...); err != nil {
+		log.Error("can't get exercises", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if err := h.attachCounts(c, refs...); err != nil {
+		log.Error("can't get counts", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	res := responsebody.Feed{
+		Count:      len(workouts),
+		Items:      make([]responsebody.FeedItem, 0, len(workouts)),
+		NextCursor: next,
+	}
+
+	for _, workout := range workouts {
+		res.Items = append(res.Items, responsebody.FeedItem{
+			Author:  authorResponse(workout.UserID, workout.Author),
+			Workout: workoutResponse(&workout.Workout, system),
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
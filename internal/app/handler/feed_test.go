@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/cursor"
+	"api/pkg/password"
+	"api/pkg/units"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const feedQuery = "SELECT workouts.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM follows JOIN users ON users.id = follows.followee_id CROSS JOIN LATERAL (SELECT * FROM workouts WHERE workouts.user_id = follows.followee_id AND workouts.visibility <> 'only_me' AND ($2::date IS NULL OR (workouts.date, workouts.created_at, workouts.id) < ($2, $3::timestamp, $4::uuid)) ORDER BY workouts.date DESC, workouts.created_at DESC, workouts.id DESC LIMIT $5) AS workouts WHERE follows.follower_id = $1 AND follows.is_accepted = true AND users.suspended_at IS NULL AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $1 AND mutes.muted_id = follows.followee_id) ORDER BY workouts.date DESC, workouts.created_at DESC, workouts.id DESC LIMIT $5"
+
+func TestGetFeed(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	date := time.Date(2024, time.May, 3, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, time.May, 3, 10, 30, 0, 0, time.UTC)
+
+	columns := []string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "distance", "author_username", "author_display_name", "author_avatar_url"}
+
+	// cursors carry workout IDs, which are checked to be UUIDs
+	secondID := "9b2e6f0a-4c1d-4e8b-a3f5-7d6c2b1a0e9f"
+
+	badIDCursor, err := cursor.Encode(feedCursor{Date: "2024-05-02", CreatedAt: createdAt, ID: "SECOND_ID"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	nextCursor, err := cursor.Encode(feedCursor{Date: "2024-05-02", CreatedAt: createdAt, ID: secondID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	distance := 3.11
+	pace := 483
+	speed := 7.46
+
+	tests := []struct {
+		query string
+		tc    test.Case
+	}{
+		{
+			query: "?limit=2",
+			tc: test.Case{
+				Name: "page with next cursor",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Imperial)
+
+					mock.ExpectQuery(feedQuery).
+						WithArgs("USER_ID", nil, nil, nil, 3).
+						WillReturnRows(sqlmock.NewRows(columns).
+							AddRow("FIRST_ID", "OTHER_ID", "RUNNING_ID", date, 25, "Running", createdAt, 5000.0, "janedoe", "Jane Doe", "https://cdn.domain.com/jane.jpeg").
+							AddRow(secondID, "THIRD_USER_ID", "ACTIVITY_ID", date.AddDate(0, 0, -1), 69, "Calisthenics", createdAt, nil, "jimdoe", "", "").
+							AddRow("THIRD_ID", "OTHER_ID", "ACTIVITY_ID", date.AddDate(0, 0, -2), 45, "Yoga", createdAt, nil, "janedoe", "Jane Doe", "https://cdn.domain.com/jane.jpeg"))
+
+					mock.ExpectQuery("SELECT * FROM workout_exercises WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+						WithArgs(pq.Array([]string{"FIRST_ID", secondID})).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "workout_id", "position", "name"}))
+
+					mock.ExpectQuery(countsQuery).
+						WithArgs(pq.Array([]string{"FIRST_ID", secondID}), "USER_ID").
+						WillReturnRows(countsRows().AddRow("FIRST_ID", 4, 1).AddRow(secondID, 0, 0))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.Feed{
+						Count: 2,
+						Items: []responsebody.FeedItem{
+							{
+								Author: responsebody.Author{ID: "OTHER_ID", Username: "janedoe", DisplayName: "Jane Doe", AvatarURL: "https://cdn.domain.com/jane.jpeg"},
+								Workout: responsebody.Workout{
+									ID:            "FIRST_ID",
+									ActivityID:    "RUNNING_ID",
+									Date:          "03-05-2024",
+									Duration:      25,
+									Kind:          "Running",
+									KudosCount:    4,
+									CommentsCount: 1,
+									Metrics: responsebody.Metrics{
+										Distance: &distance,
+										Pace:     &pace,
+										Speed:    &speed,
+									},
+								},
+							},
+							{
+								Author: responsebody.Author{ID: "THIRD_USER_ID", Username: "jimdoe"},
+								Workout: responsebody.Workout{
+									ID:         secondID,
+									ActivityID: "ACTIVITY_ID",
+									Date:       "02-05-2024",
+									Duration:   69,
+									Kind:       "Calisthenics",
+								},
+							},
+						},
+						NextCursor: nextCursor,
+					},
+				},
+			},
+		},
+		{
+			query: "?limit=2&cursor=" + nextCursor,
+			tc: test.Case{
+				Name: "last page",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(feedQuery).
+						WithArgs("USER_ID", date.AddDate(0, 0, -1), createdAt, secondID, 3).
+						WillReturnRows(sqlmock.NewRows(columns))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.Feed{
+						Items: []responsebody.FeedItem{},
+					},
+				},
+			},
+		},
+		{
+			query: "?limit=500",
+			tc: test.Case{
+				Name: "invalid limit",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid limit",
+					},
+				},
+			},
+		},
+		{
+			query: "?cursor=not-a-cursor",
+			tc: test.Case{
+				Name: "malformed cursor",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid cursor",
+					},
+				},
+			},
+		},
+		{
+			query: "?cursor=" + badIDCursor,
+			tc: test.Case{
+				Name: "cursor with malformed id",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid cursor",
+					},
+				},
+			},
+		},
+		{
+			tc: test.Case{
+				Name: "repository error",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(feedQuery).
+						WithArgs("USER_ID", nil, nil, nil, 21).
+						WillReturnError(errors.New("repo: Some repository error"))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.ResponseInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/feed", "/api/feed"+tt.query, handler.UserIdentity, handler.GetFeed)
+	}
+}
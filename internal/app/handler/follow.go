@@ -0,0 +1,416 @@
+package handler
+
+import (
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	followAccepted = "accepted"
+	followPending  = "pending"
+)
+
+// @Summary      Follow a user
+// @Description  follows a user. Following a private user creates a request, that the user has to approve. Users, that block each other, can't follow each other
+// @Security     AccessToken
+// @Tags         user
+// @Produce      json
+// @Param        username path             string true "Username"
+// @Success      201 {object}              responsebody.Follow
+// @Failure      400 {object}              responsebody.Message
+// @Failure      401 {object}              responsebody.Message
+// @Failure      403 {object}              responsebody.Message
+// @Failure      404 {object}              responsebody.Message
+// @Failure      409 {object}              responsebody.Message
+// @Router       /user/{username}/follow   [post]
+func (h *Handler) FollowUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.FollowUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+	if user.ID == userID {
+		log.Debug("user tried to follow themselves")
+		response.WithMessage(c, http.StatusBadRequest, "can't follow yourself")
+		return
+	}
+
+	if !h.unblocked(c, log, user.ID, "user not found") {
+		return
+	}
+
+	follow, err := h.repository.Follow.Create(c, userID, user.ID, !user.IsPrivate)
+	if errors.Is(err, repoerr.ErrFollowExists) {
+		log.Debug("user is already followed", slog.String("followee_id", user.ID))
+		response.WithMessage(c, http.StatusConflict, "user is already followed")
+		return
+	}
+	if err != nil {
+		log.Error("can't follow user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("user followed", slog.String("follower_id", userID), slog.String("followee_id", user.ID), slog.Bool("is_accepted", follow.IsAccepted))
+
+	c.JSON(http.StatusCreated, responsebody.Follow{
+		Status: followStatus(follow),
+	})
+}
+
+// @Summary      Unfollow a user
+// @Description  unfollows a user or cancels a follow request
+// @Security     AccessToken
+// @Tags         user
+// @Produce      json
+// @Param        username path             string true "Username"
+// @Success      200
+// @Failure      401 {object}              responsebody.Message
+// @Failure      403 {object}              responsebody.Message
+// @Failure      404 {object}              responsebody.Message
+// @Router       /user/{username}/follow   [delete]
+func (h *Handler) UnfollowUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UnfollowUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.Follow.Delete(c, c.GetString("UserID"), user.ID)
+	if errors.Is(err, repoerr.ErrFollowNotFound) {
+		log.Debug("user is not followed", slog.String("followee_id", user.ID))
+		response.WithMessage(c, http.StatusNotFound, "user is not followed")
+		return
+	}
+	if err != nil {
+		log.Error("can't unfollow user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Get followers of a user
+// @Description  returns users following given one. Followers of a private user are visible to the user and the followers only. Users, that block each other with the viewer, are left out
+// @Tags         user
+// @Produce      json
+// @Param        username path               string true "Username"
+// @Success      200 {object}                responsebody.FollowList
+// @Failure      401 {object}                responsebody.Message
+// @Failure      403 {object}                responsebody.Message
+// @Failure      404 {object}                responsebody.Message
+// @Router       /user/{username}/followers  [get]
+func (h *Handler) GetFollowers(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetFollowers"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.visibleUser(c, log)
+	if !ok {
+		return
+	}
+
+	users, err := h.repository.Follow.Followers(c, user.ID, true, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get followers", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, followListResponse(users))
+}
+
+// @Summary      Get users followed by a user
+// @Description  returns users followed by given one. They are visible to everyone for a public user and to the user and the followers only for a private one. Users, that block each other with the viewer, are left out
+// @Tags         user
+// @Produce      json
+// @Param        username path               string true "Username"
+// @Success      200 {object}                responsebody.FollowList
+// @Failure      401 {object}                responsebody.Message
+// @Failure      403 {object}                responsebody.Message
+// @Failure      404 {object}                responsebody.Message
+// @Router       /user/{username}/following  [get]
+func (h *Handler) GetFollowing(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetFollowing"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.visibleUser(c, log)
+	if !ok {
+		return
+	}
+
+	users, err := h.repository.Follow.Following(c, user.ID, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get followed users", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, followListResponse(users))
+}
+
+// @Summary      Get follow requests
+// @Description  returns pending requests to follow current user
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}             responsebody.FollowList
+// @Failure      401 {object}             responsebody.Message
+// @Failure      403 {object}             responsebody.Message
+// @Router       /account/follow-requests [get]
+func (h *Handler) GetFollowRequests(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetFollowRequests"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	userID := c.GetString("UserID")
+	users, err := h.repository.Follow.Followers(c, userID, false, userID)
+	if err != nil {
+		log.Error("can't get follow requests", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, followListResponse(users))
+}
+
+// @Summary      Approve a follow request
+// @Description  lets a user, that requested to follow current user, see the full profile
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Param        username path                        string true "Username of the requester"
+// @Success      200
+// @Failure      401 {object}                         responsebody.Message
+// @Failure      403 {object}                         responsebody.Message
+// @Failure      404 {object}                         responsebody.Message
+// @Router       /account/follow-requests/{username}  [post]
+func (h *Handler) ApproveFollowRequest(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.ApproveFollowRequest"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+
+	err := h.repository.Follow.Accept(c, user.ID, userID)
+	if errors.Is(err, repoerr.ErrFollowNotFound) {
+		log.Debug("follow request not found", slog.String("follower_id", user.ID))
+		response.WithMessage(c, http.StatusNotFound, "follow request not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't approve follow request", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("follow request approved", slog.String("follower_id", user.ID), slog.String("followee_id", userID))
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Reject a follow request
+// @Description  removes a pending request to follow current user
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Param        username path                        string true "Username of the requester"
+// @Success      200
+// @Failure      401 {object}                         responsebody.Message
+// @Failure      403 {object}                         responsebody.Message
+// @Failure      404 {object}                         responsebody.Message
+// @Router       /account/follow-requests/{username}  [delete]
+func (h *Handler) RejectFollowRequest(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.RejectFollowRequest"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.Follow.Reject(c, user.ID, c.GetString("UserID"))
+	if errors.Is(err, repoerr.ErrFollowNotFound) {
+		log.Debug("follow request not found", slog.String("follower_id", user.ID))
+		response.WithMessage(c, http.StatusNotFound, "follow request not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't reject follow request", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Remove a follower
+// @Description  makes a user stop following current user
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Param        username path                  string true "Username of the follower"
+// @Success      200
+// @Failure      401 {object}                   responsebody.Message
+// @Failure      403 {object}                   responsebody.Message
+// @Failure      404 {object}                   responsebody.Message
+// @Router       /account/followers/{username}  [delete]
+func (h *Handler) RemoveFollower(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.RemoveFollower"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+
+	err := h.repository.Follow.Delete(c, user.ID, userID)
+	if errors.Is(err, repoerr.ErrFollowNotFound) {
+		log.Debug("follower not found", slog.String("follower_id", user.ID))
+		response.WithMessage(c, http.StatusNotFound, "follower not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't remove follower", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("follower removed", slog.String("follower_id", user.ID), slog.String("followee_id", userID))
+
+	c.Status(http.StatusOK)
+}
+
+// userByUsername finds a user, whose username is in the path
+func (h *Handler) userByUsername(c *gin.Context, log *slog.Logger) (*entity.User, bool) {
+	username := c.Param("username")
+
+	user, err := h.repository.User.GetByUsername(c, username)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("username", username))
+		response.WithMessage(c, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("could not get user by username", sl.Err(err), slog.String("username", username))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// visibleUser finds a user, whose username is in the path, and makes sure,
+// that current user can see the full profile
+func (h *Handler) visibleUser(c *gin.Context, log *slog.Logger) (*entity.User, bool) {
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return nil, false
+	}
+
+	if !h.unblocked(c, log, user.ID, "user not found") {
+		return nil, false
+	}
+
+	status, ok := h.followStatus(c, log, user)
+	if !ok {
+		return nil, false
+	}
+
+	if !canView(c, user, status) {
+		log.Debug("profile is private", slog.String("user_id", user.ID))
+		response.WithMessage(c, http.StatusForbidden, "profile is private")
+		return nil, false
+	}
+
+	return user, true
+}
+
+// followStatus tells, whether current user follows given one. It's empty for
+// anonymous requests and for the user itself
+func (h *Handler) followStatus(c *gin.Context, log *slog.Logger, user *entity.User) (string, bool) {
+	userID := c.GetString("UserID")
+	if userID == "" || userID == user.ID {
+		return "", true
+	}
+
+	follow, err := h.repository.Follow.Get(c, userID, user.ID)
+	if errors.Is(err, repoerr.ErrFollowNotFound) {
+		return "", true
+	}
+	if err != nil {
+		log.Error("can't get follow", sl.Err(err))
+		response.InternalServerError(c)
+		return "", false
+	}
+
+	return followStatus(follow), true
+}
+
+// canView tells, whether current user can see the full profile of given one
+func canView(c *gin.Context, user *entity.User, status string) bool {
+	return !user.IsPrivate || user.ID == c.GetString("UserID") || status == followAccepted
+}
+
+func followStatus(follow *entity.Follow) string {
+	if follow.IsAccepted {
+		return followAccepted
+	}
+
+	return followPending
+}
+
+func followListResponse(users []entity.FollowUser) responsebody.FollowList {
+	list := make([]responsebody.FollowUser, 0, len(users))
+	for _, user := range users {
+		list = append(list, responsebody.FollowUser{
+			ID:          user.ID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarURL,
+			FollowedAt:  user.FollowedAt.Format(time.RFC3339),
+		})
+	}
+
+	return responsebody.FollowList{
+		Count: len(list),
+		Users: list,
+	}
+}
@@ -0,0 +1,813 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	followQuery         = "SELECT * FROM follows WHERE follower_id = $1 AND followee_id = $2"
+	followCountsQuery   = "SELECT (SELECT count(*) FROM follows WHERE followee_id = $1 AND is_accepted = true) AS followers, (SELECT count(*) FROM follows WHERE follower_id = $1 AND is_accepted = true) AS following"
+	followersQuery      = "SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at FROM follows JOIN users ON users.id = follows.follower_id WHERE follows.followee_id = $1 AND follows.is_accepted = $2 AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $3 AND blocks.blocked_id = users.id) OR (blocks.blocker_id = users.id AND blocks.blocked_id = $3)) ORDER BY follows.created_at DESC"
+	followingQuery      = "SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at FROM follows JOIN users ON users.id = follows.followee_id WHERE follows.follower_id = $1 AND follows.is_accepted = true AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = users.id) OR (blocks.blocker_id = users.id AND blocks.blocked_id = $2)) ORDER BY follows.created_at DESC"
+	userByUsernameQuery = "SELECT * FROM users WHERE username = $1"
+	deleteFollowQuery   = "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2"
+	acceptFollowQuery   = "UPDATE follows SET is_accepted = true WHERE follower_id = $1 AND followee_id = $2 AND is_accepted = false"
+	rejectFollowQuery   = "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2 AND is_accepted = false"
+	createFollowQuery   = "INSERT INTO follows (follower_id, followee_id, is_accepted) VALUES ($1, $2, $3) RETURNING *"
+)
+
+func TestFollowUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []struct {
+		username string
+		tc       test.Case
+	}{
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "public user",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+					mock.ExpectQuery(createFollowQuery).
+						WithArgs("USER_ID", "OTHER_ID", true).
+						WillReturnRows(followRows("USER_ID", "OTHER_ID", true))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusCreated,
+					Body: responsebody.Follow{
+						Status: "accepted",
+					},
+				},
+			},
+		},
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "private user",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", true)
+
+					expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+					mock.ExpectQuery(createFollowQuery).
+						WithArgs("USER_ID", "OTHER_ID", false).
+						WillReturnRows(followRows("USER_ID", "OTHER_ID", false))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusCreated,
+					Body: responsebody.Follow{
+						Status: "pending",
+					},
+				},
+			},
+		},
+		{
+			username: "johndoe",
+			tc: test.Case{
+				Name: "yourself",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "USER_ID", "johndoe", false)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "can't follow yourself",
+					},
+				},
+			},
+		},
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "blocked",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					expectBlocked(mock, "USER_ID", "OTHER_ID", true)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusNotFound,
+					Body: responsebody.Message{
+						Message: "user not found",
+					},
+				},
+			},
+		},
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "already followed",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+					mock.ExpectQuery(createFollowQuery).
+						WithArgs("USER_ID", "OTHER_ID", true).
+						WillReturnError(&pq.Error{Code: "23505"})
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusConflict,
+					Body: responsebody.Message{
+						Message: "user is already followed",
+					},
+				},
+			},
+		},
+		{
+			username: "nobody",
+			tc: test.Case{
+				Name: "user not found",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					mock.ExpectQuery(userByUsernameQuery).
+						WithArgs("nobody").
+						WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusNotFound,
+					Body: responsebody.Message{
+						Message: "user not found",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodPost, "/api/user/:username/follow", fmt.Sprintf("/api/user/%s/follow", tt.username), handler.UserIdentity, handler.FollowUser)
+	}
+}
+
+func TestUnfollowUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectExec(deleteFollowQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not followed",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectExec(deleteFollowQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "user is not followed",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/user/:username/follow", "/api/user/janedoe/follow", handler.UserIdentity, handler.UnfollowUser)
+	}
+}
+
+func TestGetFollowers(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	followedAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+
+	followers := responsebody.FollowList{
+		Count: 1,
+		Users: []responsebody.FollowUser{
+			{ID: "FOLLOWER_ID", Username: "jimdoe", DisplayName: "Jim Doe", FollowedAt: followedAt.Format(time.RFC3339)},
+		},
+	}
+
+	tests := []test.Case{
+		{
+			Name: "public user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectQuery(followersQuery).
+					WithArgs("OTHER_ID", true, nil).
+					WillReturnRows(followUserRows("FOLLOWER_ID", "jimdoe", "Jim Doe", followedAt))
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body:   followers,
+			},
+		},
+		{
+			Name: "private user: follower",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", true)
+
+				mock.ExpectQuery(followersQuery).
+					WithArgs("OTHER_ID", true, "USER_ID").
+					WillReturnRows(followUserRows("FOLLOWER_ID", "jimdoe", "Jim Doe", followedAt))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body:   followers,
+			},
+		},
+		{
+			Name: "public user: users blocking each other with viewer left out",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id", "followee_id", "is_accepted", "created_at"}))
+
+				// the viewer blocks the only follower
+				mock.ExpectQuery(followersQuery).
+					WithArgs("OTHER_ID", true, "USER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "display_name", "avatar_url", "followed_at"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.FollowList{
+					Users: []responsebody.FollowUser{},
+				},
+			},
+		},
+		{
+			Name: "private user: pending request",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", false)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "profile is private",
+				},
+			},
+		},
+		{
+			Name: "private user: anonymous",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", true)
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "profile is private",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectQuery(followersQuery).
+					WithArgs("OTHER_ID", true, nil).
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/user/:username/followers", "/api/user/janedoe/followers", handler.OptionalUserIdentity, handler.GetFollowers)
+	}
+}
+
+func TestGetFollowing(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("OTHER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	followedAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "private user: themselves",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", true)
+
+				mock.ExpectQuery(followingQuery).
+					WithArgs("OTHER_ID", "OTHER_ID").
+					WillReturnRows(followUserRows("USER_ID", "johndoe", "John Doe", followedAt))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.FollowList{
+					Count: 1,
+					Users: []responsebody.FollowUser{
+						{ID: "USER_ID", Username: "johndoe", DisplayName: "John Doe", FollowedAt: followedAt.Format(time.RFC3339)},
+					},
+				},
+			},
+		},
+		{
+			Name: "nobody followed",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectQuery(followingQuery).
+					WithArgs("OTHER_ID", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.FollowList{
+					Users: []responsebody.FollowUser{},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/user/:username/following", "/api/user/janedoe/following", handler.OptionalUserIdentity, handler.GetFollowing)
+	}
+}
+
+func TestGetFollowRequests(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	requestedAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery(followersQuery).
+					WithArgs("USER_ID", false, "USER_ID").
+					WillReturnRows(followUserRows("OTHER_ID", "janedoe", "Jane Doe", requestedAt))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.FollowList{
+					Count: 1,
+					Users: []responsebody.FollowUser{
+						{ID: "OTHER_ID", Username: "janedoe", DisplayName: "Jane Doe", FollowedAt: requestedAt.Format(time.RFC3339)},
+					},
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery(followersQuery).
+					WithArgs("USER_ID", false, "USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/account/follow-requests", "/api/account/follow-requests", handler.UserIdentity, handler.GetFollowRequests)
+	}
+}
+
+func TestManageFollowers(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	requests := "/api/account/follow-requests/:username"
+	followers := "/api/account/followers/:username"
+
+	tests := []struct {
+		method string
+		route  string
+		action gin.HandlerFunc
+		tc     test.Case
+	}{
+		{
+			method: http.MethodPost,
+			route:  requests,
+			action: handler.ApproveFollowRequest,
+			tc: test.Case{
+				Name: "approve",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(acceptFollowQuery).
+						WithArgs("OTHER_ID", "USER_ID").
+						WillReturnResult(driver.RowsAffected(1))
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+				},
+			},
+		},
+		{
+			method: http.MethodPost,
+			route:  requests,
+			action: handler.ApproveFollowRequest,
+			tc: test.Case{
+				Name: "approve: request not found",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(acceptFollowQuery).
+						WithArgs("OTHER_ID", "USER_ID").
+						WillReturnResult(driver.RowsAffected(0))
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusNotFound,
+					Body: responsebody.Message{
+						Message: "follow request not found",
+					},
+				},
+			},
+		},
+		{
+			method: http.MethodDelete,
+			route:  requests,
+			action: handler.RejectFollowRequest,
+			tc: test.Case{
+				Name: "reject",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(rejectFollowQuery).
+						WithArgs("OTHER_ID", "USER_ID").
+						WillReturnResult(driver.RowsAffected(1))
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+				},
+			},
+		},
+		{
+			method: http.MethodDelete,
+			route:  followers,
+			action: handler.RemoveFollower,
+			tc: test.Case{
+				Name: "remove follower",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(deleteFollowQuery).
+						WithArgs("OTHER_ID", "USER_ID").
+						WillReturnResult(driver.RowsAffected(1))
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+				},
+			},
+		},
+		{
+			method: http.MethodDelete,
+			route:  followers,
+			action: handler.RemoveFollower,
+			tc: test.Case{
+				Name: "remove follower: not a follower",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(deleteFollowQuery).
+						WithArgs("OTHER_ID", "USER_ID").
+						WillReturnResult(driver.RowsAffected(0))
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusNotFound,
+					Body: responsebody.Message{
+						Message: "follower not found",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.tc.Request.Headers = map[string]string{
+			"Authorization": headerAuthorization,
+		}
+
+		path := strings.Replace(tt.route, ":username", "janedoe", 1)
+		test.Endpoint(t, tt.tc, mock, tt.method, tt.route, path, handler.UserIdentity, tt.action)
+	}
+}
+
+func expectUserByUsername(mock sqlmock.Sqlmock, id string, username string, isPrivate bool) {
+	mock.ExpectQuery(userByUsernameQuery).
+		WithArgs(username).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "is_private"}).AddRow(id, username, isPrivate))
+}
+
+func expectFollow(mock sqlmock.Sqlmock, followerID string, followeeID string, isAccepted bool) {
+	mock.ExpectQuery(followQuery).
+		WithArgs(followerID, followeeID).
+		WillReturnRows(followRows(followerID, followeeID, isAccepted))
+}
+
+func expectFollowCounts(mock sqlmock.Sqlmock, userID string, followers int, following int) {
+	mock.ExpectQuery(followCountsQuery).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"followers", "following"}).AddRow(followers, following))
+}
+
+func followRows(followerID string, followeeID string, isAccepted bool) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"follower_id", "followee_id", "is_accepted", "created_at"}).
+		AddRow(followerID, followeeID, isAccepted, time.Now())
+}
+
+func followUserRows(id string, username string, displayName string, followedAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "username", "display_name", "avatar_url", "followed_at"}).
+		AddRow(id, username, displayName, "", followedAt)
+}
@@ -5,6 +5,8 @@ import (
 	"api/internal/mailer"
 	"api/internal/repository"
 	"api/internal/token"
+	"api/pkg/oauth"
+	"api/pkg/password"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,14 +17,31 @@ type Handler struct {
 	repository *repository.Repository
 	mailer     mailer.Mailer
 	token      token.Manager
+	hasher     password.Hasher
+	providers  map[string]*oauth.Provider
 }
 
-func New(c *config.Config, r *repository.Repository, m mailer.Mailer, t token.Manager) *Handler {
+func New(c *config.Config, r *repository.Repository, m mailer.Mailer, t token.Manager, p password.Hasher) *Handler {
+	providers := make(map[string]*oauth.Provider, len(c.OAuth.Providers))
+	for _, provider := range c.OAuth.Providers {
+		providers[provider.Name] = oauth.New(oauth.Config{
+			Name:         provider.Name,
+			Kind:         provider.Kind,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		})
+	}
+
 	return &Handler{
 		config:     c,
 		repository: r,
 		mailer:     m,
 		token:      t,
+		hasher:     p,
+		providers:  providers,
 	}
 }
 
@@ -36,3 +55,10 @@ func New(c *config.Config, r *repository.Repository, m mailer.Mailer, t token.Ma
 func (h *Handler) Healthcheck(c *gin.Context) {
 	c.String(http.StatusOK, "ok")
 }
+
+// GetJWKS returns public keys, that can be used to verify access tokens.
+// Served at /.well-known/jwks.json, outside of API base path
+func (h *Handler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.token.JWKS())
+}
@@ -5,6 +5,7 @@ import (
 	mockmailer "api/internal/mailer/mock"
 	"api/internal/repository"
 	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,7 +20,7 @@ func TestHealthcheck(t *testing.T) {
 	c := config.Config{}
 	repo := repository.Repository{}
 
-	h := New(&c, &repo, mockmailer.New(), mocktoken.New(c.Token))
+	h := New(&c, &repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
 	r.GET("/healthcheck", h.Healthcheck)
 
@@ -41,3 +42,37 @@ func TestHealthcheck(t *testing.T) {
 		t.Fatalf("handler returned unexpected body: got %v, want %v\n", w.Body.String(), expected)
 	}
 }
+
+func TestGetJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+
+	c := config.Config{}
+	repo := repository.Repository{}
+
+	h := New(&c, &repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	r.GET("/.well-known/jwks.json", h.GetJWKS)
+
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if status := w.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v, want %v\n", status, http.StatusOK)
+	}
+
+	expected := `{"keys":[]}`
+	if w.Body.String() != expected {
+		t.Fatalf("handler returned unexpected body: got %v, want %v\n", w.Body.String(), expected)
+	}
+
+	if cache := w.Header().Get("Cache-Control"); cache == "" {
+		t.Fatal("handler returned no Cache-Control header")
+	}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Give kudos to a workout
+// @Description  gives kudos to a workout of another user, that current user can see
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      json
+// @Param        id path              string true "Workout ID"
+// @Success      201
+// @Failure      400 {object}         responsebody.Message
+// @Failure      401 {object}         responsebody.Message
+// @Failure      403 {object}         responsebody.Message
+// @Failure      404 {object}         responsebody.Message
+// @Failure      409 {object}         responsebody.Message
+// @Router       /workout/{id}/kudos  [post]
+func (h *Handler) GiveKudos(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GiveKudos"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+	if workout.UserID == userID {
+		log.Debug("user tried to give kudos to their own workout")
+		response.WithMessage(c, http.StatusBadRequest, "can't give kudos to your own workout")
+		return
+	}
+
+	err := h.repository.Kudos.Create(c, workout.ID, userID)
+	if errors.Is(err, repoerr.ErrKudosExists) {
+		log.Debug("kudos already given", slog.String("workout_id", workout.ID))
+		response.WithMessage(c, http.StatusConflict, "kudos already given")
+		return
+	}
+	if err != nil {
+		log.Error("can't give kudos", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// @Summary      Remove kudos from a workout
+// @Description  removes kudos, that current user gave to a workout
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      json
+// @Param        id path              string true "Workout ID"
+// @Success      200
+// @Failure      401 {object}         responsebody.Message
+// @Failure      403 {object}         responsebody.Message
+// @Failure      404 {object}         responsebody.Message
+// @Router       /workout/{id}/kudos  [delete]
+func (h *Handler) RemoveKudos(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.RemoveKudos"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.Kudos.Delete(c, workout.ID, c.GetString("UserID"))
+	if errors.Is(err, repoerr.ErrKudosNotFound) {
+		log.Debug("kudos not given", slog.String("workout_id", workout.ID))
+		response.WithMessage(c, http.StatusNotFound, "kudos not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't remove kudos", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Get kudos of a workout
+// @Description  returns users, that gave kudos to a workout, the latest first. Kudos of a private user's workout are visible to the user and the followers only. Kudos of blocked and muted users are hidden
+// @Tags         activity
+// @Produce      json
+// @Param        id path              string true "Workout ID"
+// @Success      200 {object}         responsebody.KudosList
+// @Failure      401 {object}         responsebody.Message
+// @Failure      403 {object}         responsebody.Message
+// @Failure      404 {object}         responsebody.Message
+// @Router       /workout/{id}/kudos  [get]
+func (h *Handler) GetKudos(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetKudos"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.visibleWorkout(c, log)
+	if !ok {
+		return
+	}
+
+	kudos, err := h.repository.Kudos.GetByWorkout(c, workout.ID, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get kudos", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	res := responsebody.KudosList{
+		Count: len(kudos),
+		Kudos: make([]responsebody.Kudos, 0, len(kudos)),
+	}
+
+	for _, k := range kudos {
+		res.Kudos = append(res.Kudos, responsebody.Kudos{
+			Author:    authorResponse(k.UserID, k.Author),
+			CreatedAt: k.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
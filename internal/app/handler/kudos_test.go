@@ -0,0 +1,518 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/app/handler/visibility"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	createKudosQuery = "INSERT INTO kudos (workout_id, user_id) VALUES ($1, $2)"
+	deleteKudosQuery = "DELETE FROM kudos WHERE workout_id = $1 AND user_id = $2"
+	kudosQuery       = "SELECT kudos.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM kudos JOIN users ON users.id = kudos.user_id WHERE kudos.workout_id = $1 AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = kudos.user_id) OR (blocks.blocker_id = kudos.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = kudos.user_id) ORDER BY kudos.created_at DESC"
+)
+
+func TestGiveKudos(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+
+				mock.ExpectExec(createKudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+			},
+		},
+		{
+			Name: "private workout of a followed user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", true)
+
+				mock.ExpectExec(createKudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+			},
+		},
+		{
+			Name: "private workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", false)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "workout is private",
+				},
+			},
+		},
+		{
+			Name: "followers only workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectWorkoutWithVisibility(mock, "OTHER_ID", false, visibility.Followers)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "workout is private",
+				},
+			},
+		},
+		{
+			Name: "followers only workout of a followed user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectWorkoutWithVisibility(mock, "OTHER_ID", false, visibility.Followers)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", true)
+
+				mock.ExpectExec(createKudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+			},
+		},
+		{
+			Name: "only me workout of a followed user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectWorkoutWithVisibility(mock, "OTHER_ID", false, visibility.OnlyMe)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", true)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "workout is private",
+				},
+			},
+		},
+		{
+			Name: "own workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", false)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "can't give kudos to your own workout",
+				},
+			},
+		},
+		{
+			Name: "blocked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", true)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "workout not found",
+				},
+			},
+		},
+		{
+			Name: "already given",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				mock.ExpectQuery(followQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"follower_id"}))
+
+				mock.ExpectExec(createKudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "kudos already given",
+				},
+			},
+		},
+		{
+			Name: "workout not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "workout not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/workout/:id/kudos", "/api/workout/WORKOUT_ID/kudos", handler.UserIdentity, handler.GiveKudos)
+	}
+}
+
+func TestRemoveKudos(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", true)
+
+				mock.ExpectExec(deleteKudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not given",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+
+				expectBlocked(mock, "USER_ID", "OTHER_ID", false)
+
+				expectFollow(mock, "USER_ID", "OTHER_ID", true)
+
+				mock.ExpectExec(deleteKudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "kudos not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/workout/:id/kudos", "/api/workout/WORKOUT_ID/kudos", handler.UserIdentity, handler.RemoveKudos)
+	}
+}
+
+func TestGetKudos(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	givenAt := time.Date(2024, time.May, 3, 10, 30, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "anonymous",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectVisibleWorkout(mock, "OTHER_ID", false)
+
+				mock.ExpectQuery(kudosQuery).
+					WithArgs("WORKOUT_ID", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"workout_id", "user_id", "created_at", "author_username", "author_display_name", "author_avatar_url"}).
+						AddRow("WORKOUT_ID", "THIRD_USER_ID", givenAt, "jimdoe", "Jim Doe", ""))
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.KudosList{
+					Count: 1,
+					Kudos: []responsebody.Kudos{
+						{
+							Author:    responsebody.Author{ID: "THIRD_USER_ID", Username: "jimdoe", DisplayName: "Jim Doe"},
+							CreatedAt: "2024-05-03T10:30:00Z",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "own private workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectVisibleWorkout(mock, "USER_ID", true)
+
+				mock.ExpectQuery(kudosQuery).
+					WithArgs("WORKOUT_ID", "USER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"workout_id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.KudosList{
+					Kudos: []responsebody.Kudos{},
+				},
+			},
+		},
+		{
+			Name: "anonymous, private workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expectVisibleWorkout(mock, "OTHER_ID", true)
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "workout is private",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/workout/:id/kudos", "/api/workout/WORKOUT_ID/kudos", handler.OptionalUserIdentity, handler.GetKudos)
+	}
+}
+
+// expectVisibleWorkout expects a public workout of given user to be looked
+// up together with its owner, unless the owner is current user
+func expectVisibleWorkout(mock sqlmock.Sqlmock, ownerID string, isPrivate bool) {
+	expectWorkoutWithVisibility(mock, ownerID, isPrivate, visibility.Public)
+}
+
+func expectWorkoutWithVisibility(mock sqlmock.Sqlmock, ownerID string, isPrivate bool, workoutVisibility string) {
+	mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+		WithArgs("WORKOUT_ID").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "visibility"}).
+			AddRow("WORKOUT_ID", ownerID, "ACTIVITY_ID", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC), 69, "Calisthenics", workoutVisibility))
+
+	if ownerID == "USER_ID" {
+		return
+	}
+
+	mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+		WithArgs(ownerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "is_private"}).AddRow(ownerID, "janedoe", isPrivate))
+}
@@ -2,12 +2,18 @@ package handler
 
 import (
 	"api/internal/app/handler/response"
+	"api/internal/app/handler/role"
+	"api/internal/app/handler/scope"
 	"api/internal/lib/logger/sl"
-
+	repoerr "api/internal/repository/errors"
+	tokenpkg "api/internal/token"
 	"api/pkg/requestid"
+	"api/pkg/sha256"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -34,13 +40,137 @@ func (h *Handler) UserIdentity(c *gin.Context) {
 
 	token := parts[1]
 
-	userID, err := h.token.ParseJWT(token)
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		h.apiKeyIdentity(c, log, token)
+		return
+	}
+
+	claims, err := h.token.ParseJWT(token)
+	if errors.Is(err, tokenpkg.ErrTokenExpired) {
+		log.Debug("access token expired")
+		response.WithMessage(c, http.StatusUnauthorized, "authorization token expired")
+		return
+	}
 	if err != nil {
 		log.Error("can't parse access token", slog.String("token", token), sl.Err(err))
 		response.WithMessage(c, http.StatusUnauthorized, "invalid authorization token")
 		return
 	}
 
-	c.Set("UserID", userID)
+	suspended, err := h.repository.Session.Touch(c, claims.SessionID, c.Request.UserAgent(), c.ClientIP())
+	if errors.Is(err, repoerr.ErrSessionNotFound) {
+		log.Debug("session revoked", slog.String("session_id", claims.SessionID))
+		response.WithMessage(c, http.StatusUnauthorized, "session revoked")
+		return
+	}
+	if err != nil {
+		log.Error("can't check session", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if suspended {
+		log.Debug("user is suspended", slog.String("user_id", claims.UserID))
+		response.WithMessage(c, http.StatusForbidden, "account suspended")
+		return
+	}
+
+	c.Set("UserID", claims.UserID)
+	c.Set("SessionID", claims.SessionID)
+	c.Set("Role", claims.Role)
+	c.Set("Scopes", scope.Session)
 	c.Next()
 }
+
+// OptionalUserIdentity identifies a user like UserIdentity, but lets requests
+// without authorization header through anonymously
+func (h *Handler) OptionalUserIdentity(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		c.Next()
+		return
+	}
+
+	h.UserIdentity(c)
+}
+
+// apiKeyIdentity authenticates a request made with a personal API key
+func (h *Handler) apiKeyIdentity(c *gin.Context, log *slog.Logger, key string) {
+	apiKey, err := h.repository.APIKey.GetByHash(c, sha256.String(key))
+	if errors.Is(err, repoerr.ErrAPIKeyNotFound) {
+		log.Debug("API key not found")
+		response.WithMessage(c, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+	if err != nil {
+		log.Error("can't check API key", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		log.Debug("API key expired", slog.String("api_key_id", apiKey.ID))
+		response.WithMessage(c, http.StatusUnauthorized, "API key expired")
+		return
+	}
+
+	suspended, err := h.repository.APIKey.Touch(c, apiKey.ID)
+	if errors.Is(err, repoerr.ErrAPIKeyNotFound) {
+		log.Debug("API key revoked", slog.String("api_key_id", apiKey.ID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+	if err != nil {
+		log.Error("can't update API key last usage", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if suspended {
+		log.Debug("user is suspended", slog.String("user_id", apiKey.UserID))
+		response.WithMessage(c, http.StatusForbidden, "account suspended")
+		return
+	}
+
+	// API keys never carry a role, so they can't be used for moderation
+	c.Set("UserID", apiKey.UserID)
+	c.Set("APIKeyID", apiKey.ID)
+	c.Set("Scopes", []string(apiKey.Scopes))
+	c.Next()
+}
+
+// RequireScope aborts requests, which credentials were not granted given scope.
+// Must be used after UserIdentity
+func (h *Handler) RequireScope(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes := c.GetStringSlice("Scopes")
+		if !scope.Has(scopes, required) {
+			slog.Debug("insufficient scope",
+				slog.String("op", "handler.RequireScope"),
+				slog.String("request_id", requestid.Get(c)),
+				slog.String("scope", required),
+			)
+			response.WithMessage(c, http.StatusForbidden, "insufficient scope")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole aborts requests of users, whose role is lower than given one.
+// Must be used after UserIdentity
+func (h *Handler) RequireRole(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !role.AtLeast(c.GetString("Role"), required) {
+			slog.Debug("insufficient role",
+				slog.String("op", "handler.RequireRole"),
+				slog.String("request_id", requestid.Get(c)),
+				slog.String("role", required),
+			)
+			response.WithMessage(c, http.StatusForbidden, "insufficient role")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -2,21 +2,75 @@ package handler
 
 import (
 	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/scope"
 	"api/internal/app/handler/test"
 	"api/internal/config"
 	mockmailer "api/internal/mailer/mock"
 	"api/internal/repository"
+	"api/internal/token"
 	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"api/pkg/sha256"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jmoiron/sqlx"
 )
 
 func TestUserIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
 	c := config.Config{}
-	repo := repository.Repository{}
-	handler := New(&c, &repo, mockmailer.New(), mocktoken.New(c.Token))
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, token.Claims{
+		UserID:    "USER_ID",
+		SessionID: "SESSION_ID",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte(c.Token.Secret))
+	if err != nil {
+		t.Fatal("unexpected error while generating expired token")
+	}
+
+	apiKey := "ydk_0123456789abcdefghijklmnopqrstuvwxyzABCD"
 
 	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
 		{
 			Name: "empty header",
 
@@ -59,9 +113,378 @@ func TestUserIdentity(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "expired token",
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", expiredToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "authorization token expired",
+				},
+			},
+		},
+		{
+			Name: "session revoked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(test.TouchSessionQuery).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "SESSION_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"suspended"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "session revoked",
+				},
+			},
+		},
+		{
+			Name: "suspended",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(test.TouchSessionQuery).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "SESSION_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"suspended"}).AddRow(true))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "account suspended",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(test.TouchSessionQuery).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "SESSION_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+		{
+			Name: "API key: ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM api_keys WHERE key_hash = $1").
+					WithArgs(sha256.String(apiKey)).
+					WillReturnRows(apiKeyRows(nil))
+
+				expectAPIKeyTouch(mock, false)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "API key: not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM api_keys WHERE key_hash = $1").
+					WithArgs(sha256.String(apiKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "invalid API key",
+				},
+			},
+		},
+		{
+			Name: "API key: expired",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				expiresAt := time.Now().Add(-time.Hour)
+
+				mock.ExpectQuery("SELECT * FROM api_keys WHERE key_hash = $1").
+					WithArgs(sha256.String(apiKey)).
+					WillReturnRows(apiKeyRows(&expiresAt))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "API key expired",
+				},
+			},
+		},
+		{
+			Name: "API key: suspended",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM api_keys WHERE key_hash = $1").
+					WithArgs(sha256.String(apiKey)).
+					WillReturnRows(apiKeyRows(nil))
+
+				expectAPIKeyTouch(mock, true)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "account suspended",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/me", "/api/me", handler.UserIdentity, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+}
+
+func TestOptionalUserIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	c := config.Config{}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+				},
+			},
+
+			Expect: test.Expect{
+				Status:  http.StatusOK,
+				RawBody: true,
+				Body:    "USER_ID",
+			},
+		},
+		{
+			Name: "anonymous",
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body:   "",
+			},
+		},
+		{
+			Name: "invalid token",
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": "Bearer",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "empty authorization header",
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
-		test.Endpoint(t, tc, nil, http.MethodGet, "/api/me", "/api/me", handler.UserIdentity)
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/me", "/api/me", handler.OptionalUserIdentity, func(c *gin.Context) {
+			c.String(http.StatusOK, c.GetString("UserID"))
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
 	}
+
+	c := config.Config{}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	apiKey := "ydk_0123456789abcdefghijklmnopqrstuvwxyzABCD"
+
+	expectAPIKey := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("SELECT * FROM api_keys WHERE key_hash = $1").
+			WithArgs(sha256.String(apiKey)).
+			WillReturnRows(apiKeyRows(nil))
+
+		expectAPIKeyTouch(mock, false)
+	}
+
+	tests := []struct {
+		scope string
+		tc    test.Case
+	}{
+		{
+			scope: scope.Account,
+			tc: test.Case{
+				Name: "session: ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": fmt.Sprintf("Bearer %s", accessToken),
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+				},
+			},
+		},
+		{
+			scope: scope.WorkoutsRead,
+			tc: test.Case{
+				Name: "API key: ok",
+
+				Repo: expectAPIKey,
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+				},
+			},
+		},
+		{
+			scope: scope.WorkoutsWrite,
+			tc: test.Case{
+				Name: "API key: insufficient scope",
+
+				Repo: expectAPIKey,
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusForbidden,
+					Body: responsebody.Message{
+						Message: "insufficient scope",
+					},
+				},
+			},
+		},
+		{
+			scope: scope.Account,
+			tc: test.Case{
+				Name: "API key: account management",
+
+				Repo: expectAPIKey,
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": fmt.Sprintf("Bearer %s", apiKey),
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusForbidden,
+					Body: responsebody.Message{
+						Message: "insufficient scope",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/me", "/api/me", handler.UserIdentity, handler.RequireScope(tt.scope), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+}
+
+func apiKeyRows(expiresAt *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "created_at"}).
+		AddRow("API_KEY_ID", "USER_ID", "CI", "ydk_01234567", "KEY_HASH", "{workouts:read}", expiresAt, nil, time.Now())
+}
+
+func expectAPIKeyTouch(mock sqlmock.Sqlmock, suspended bool) {
+	mock.ExpectQuery("UPDATE api_keys SET last_used_at = now() FROM users WHERE api_keys.id = $1 AND users.id = api_keys.user_id RETURNING users.suspended_at IS NOT NULL").
+		WithArgs("API_KEY_ID").
+		WillReturnRows(sqlmock.NewRows([]string{"suspended"}).AddRow(suspended))
 }
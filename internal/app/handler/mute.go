@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"api/internal/app/handler/response"
+	"api/internal/lib/logger/sl"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Mute a user
+// @Description  mutes a user. Workouts, comments and kudos of muted users are hidden from current user, while follows stay untouched
+// @Security     AccessToken
+// @Tags         user
+// @Produce      json
+// @Param        username path           string true "Username"
+// @Success      201
+// @Failure      400 {object}            responsebody.Message
+// @Failure      401 {object}            responsebody.Message
+// @Failure      403 {object}            responsebody.Message
+// @Failure      404 {object}            responsebody.Message
+// @Failure      409 {object}            responsebody.Message
+// @Router       /user/{username}/mute   [post]
+func (h *Handler) MuteUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.MuteUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("UserID")
+	if user.ID == userID {
+		log.Debug("user tried to mute themselves")
+		response.WithMessage(c, http.StatusBadRequest, "can't mute yourself")
+		return
+	}
+
+	err := h.repository.Mute.Create(c, userID, user.ID)
+	if errors.Is(err, repoerr.ErrMuteExists) {
+		log.Debug("user is already muted", slog.String("muted_id", user.ID))
+		response.WithMessage(c, http.StatusConflict, "user is already muted")
+		return
+	}
+	if err != nil {
+		log.Error("can't mute user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// @Summary      Unmute a user
+// @Description  unmutes a user
+// @Security     AccessToken
+// @Tags         user
+// @Produce      json
+// @Param        username path           string true "Username"
+// @Success      200
+// @Failure      401 {object}            responsebody.Message
+// @Failure      403 {object}            responsebody.Message
+// @Failure      404 {object}            responsebody.Message
+// @Router       /user/{username}/mute   [delete]
+func (h *Handler) UnmuteUser(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UnmuteUser"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	err := h.repository.Mute.Delete(c, c.GetString("UserID"), user.ID)
+	if errors.Is(err, repoerr.ErrMuteNotFound) {
+		log.Debug("user is not muted", slog.String("muted_id", user.ID))
+		response.WithMessage(c, http.StatusNotFound, "user is not muted")
+		return
+	}
+	if err != nil {
+		log.Error("can't unmute user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary      Get muted users
+// @Description  returns users, that current user muted, the latest first
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}     responsebody.ListedUserList
+// @Failure      401 {object}     responsebody.Message
+// @Failure      403 {object}     responsebody.Message
+// @Router       /account/mutes   [get]
+func (h *Handler) GetMutedUsers(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetMutedUsers"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	users, err := h.repository.Mute.Muted(c, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get muted users", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, userListResponse(users))
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	createMuteQuery = "INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2)"
+	deleteMuteQuery = "DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2"
+	mutedUsersQuery = "SELECT users.id, users.username, users.display_name, users.avatar_url, mutes.created_at AS listed_at FROM mutes JOIN users ON users.id = mutes.muted_id WHERE mutes.muter_id = $1 ORDER BY mutes.created_at DESC"
+)
+
+func TestMuteUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []struct {
+		username string
+		tc       test.Case
+	}{
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(createMuteQuery).
+						WithArgs("USER_ID", "OTHER_ID").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusCreated,
+				},
+			},
+		},
+		{
+			username: "johndoe",
+			tc: test.Case{
+				Name: "yourself",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "USER_ID", "johndoe", false)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "can't mute yourself",
+					},
+				},
+			},
+		},
+		{
+			username: "janedoe",
+			tc: test.Case{
+				Name: "already muted",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+					mock.ExpectExec(createMuteQuery).
+						WithArgs("USER_ID", "OTHER_ID").
+						WillReturnError(&pq.Error{Code: "23505"})
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusConflict,
+					Body: responsebody.Message{
+						Message: "user is already muted",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodPost, "/api/user/:username/mute", fmt.Sprintf("/api/user/%s/mute", tt.username), handler.UserIdentity, handler.MuteUser)
+	}
+}
+
+func TestUnmuteUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectExec(deleteMuteQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not muted",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUserByUsername(mock, "OTHER_ID", "janedoe", false)
+
+				mock.ExpectExec(deleteMuteQuery).
+					WithArgs("USER_ID", "OTHER_ID").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "user is not muted",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/user/:username/mute", "/api/user/janedoe/mute", handler.UserIdentity, handler.UnmuteUser)
+	}
+}
+
+func TestGetMutedUsers(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	mutedAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery(mutedUsersQuery).
+					WithArgs("USER_ID").
+					WillReturnRows(listedUserRows("OTHER_ID", "janedoe", "Jane Doe", mutedAt))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.ListedUserList{
+					Count: 1,
+					Users: []responsebody.ListedUser{
+						{ID: "OTHER_ID", Username: "janedoe", DisplayName: "Jane Doe", ListedAt: mutedAt.Format(time.RFC3339)},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/account/mutes", "/api/account/mutes", handler.UserIdentity, handler.GetMutedUsers)
+	}
+}
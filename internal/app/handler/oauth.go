@@ -0,0 +1,436 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/oauth"
+	"api/pkg/random"
+	"api/pkg/requestid"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9_.]`)
+
+// @Summary      Get sign in providers
+// @Description  returns names of configured external sign in providers
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object}  responsebody.OAuthProviders
+// @Router       /auth/oauth   [get]
+func (h *Handler) GetOAuthProviders(c *gin.Context) {
+	providers := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	c.JSON(http.StatusOK, responsebody.OAuthProviders{
+		Providers: providers,
+	})
+}
+
+// @Summary      Start sign in with external provider
+// @Description  returns provider's authorization URL. The client opens it, and passes `code` and `state` it was redirected with to the callback
+// @Tags         auth
+// @Produce      json
+// @Param        provider path           string true "Provider name"
+// @Success      200 {object}            responsebody.OAuthAuthorization
+// @Failure      404 {object}            responsebody.Message
+// @Router       /auth/oauth/{provider}  [post]
+func (h *Handler) StartOAuth(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.StartOAuth"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	h.startOAuth(c, log, "")
+}
+
+// @Summary      Complete sign in with external provider
+// @Description  exchanges authorization code for access and refresh tokens. Creates a confirmed account on first sign in. If two-factor authentication is enabled, returns a challenge token instead
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        provider path                    string true "Provider name"
+// @Param        input body                       requestbody.CompleteOAuth true "Authorization response"
+// @Success      200 {object}                     responsebody.Token
+// @Success      202 {object}                     responsebody.TwoFactorChallenge
+// @Failure      400 {object}                     responsebody.Message
+// @Failure      401 {object}                     responsebody.Message
+// @Failure      403 {object}                     responsebody.Message
+// @Failure      404 {object}                     responsebody.Message
+// @Failure      409 {object}                     responsebody.Message
+// @Router       /auth/oauth/{provider}/callback  [post]
+func (h *Handler) CompleteOAuth(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.CompleteOAuth"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.CompleteOAuth
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	provider := c.Param("provider")
+	identity, ok := h.exchangeOAuth(c, log, provider, body, "")
+	if !ok {
+		return
+	}
+
+	var user *entity.User
+	linked, err := h.repository.Identity.GetByProviderSubject(c, provider, identity.Subject)
+	switch {
+	case err == nil:
+		user, err = h.repository.User.GetByID(c, linked.UserID)
+		if err != nil {
+			log.Error("can't find user", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+	case errors.Is(err, repoerr.ErrIdentityNotFound):
+		user, ok = h.createOAuthUser(c, log, provider, identity)
+		if !ok {
+			return
+		}
+	default:
+		log.Error("can't find identity", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	h.signIn(c, log, user, body.Device)
+}
+
+// @Summary      Get linked sign in providers
+// @Description  returns external providers linked to the account
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}         responsebody.IdentityList
+// @Failure      401 {object}         responsebody.Message
+// @Router       /account/identities  [get]
+func (h *Handler) GetIdentities(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetIdentities"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	identities, err := h.repository.Identity.GetByUserID(c, c.GetString("UserID"))
+	if err != nil {
+		log.Error("can't get identities", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	list := make([]responsebody.Identity, 0, len(identities))
+	for _, identity := range identities {
+		list = append(list, responsebody.Identity{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, responsebody.IdentityList{
+		Count:      len(list),
+		Identities: list,
+	})
+}
+
+// @Summary      Start linking external provider
+// @Description  returns provider's authorization URL, to link the provider to the current account
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Param        provider path                   string true "Provider name"
+// @Success      200 {object}                    responsebody.OAuthAuthorization
+// @Failure      401 {object}                    responsebody.Message
+// @Failure      404 {object}                    responsebody.Message
+// @Router       /account/identities/{provider}  [post]
+func (h *Handler) LinkIdentity(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.LinkIdentity"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	h.startOAuth(c, log, c.GetString("UserID"))
+}
+
+// @Summary      Complete linking external provider
+// @Description  links external provider to the current account
+// @Security     AccessToken
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        provider path                            string true "Provider name"
+// @Param        input body                               requestbody.CompleteOAuth true "Authorization response"
+// @Success      201 {object}                             responsebody.Identity
+// @Failure      400 {object}                             responsebody.Message
+// @Failure      401 {object}                             responsebody.Message
+// @Failure      404 {object}                             responsebody.Message
+// @Failure      409 {object}                             responsebody.Message
+// @Router       /account/identities/{provider}/callback  [post]
+func (h *Handler) CompleteLinkIdentity(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.CompleteLinkIdentity"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.CompleteOAuth
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	userID := c.GetString("UserID")
+	provider := c.Param("provider")
+
+	identity, ok := h.exchangeOAuth(c, log, provider, body, userID)
+	if !ok {
+		return
+	}
+
+	linked, err := h.repository.Identity.Create(c, userID, provider, identity.Subject, identity.Email)
+	if errors.Is(err, repoerr.ErrIdentityAlreadyExists) {
+		log.Debug("identity already linked", slog.String("provider", provider))
+		response.WithMessage(c, http.StatusConflict, "provider already linked")
+		return
+	}
+	if err != nil {
+		log.Error("can't link identity", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("identity linked", slog.String("user_id", userID), slog.String("provider", provider))
+
+	c.JSON(http.StatusCreated, responsebody.Identity{
+		Provider:  linked.Provider,
+		Email:     linked.Email,
+		CreatedAt: linked.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+// @Summary      Unlink external provider
+// @Description  unlinks external provider from the current account. The last provider of an account without a password can't be unlinked
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Param        provider path                   string true "Provider name"
+// @Success      200
+// @Failure      401 {object}                    responsebody.Message
+// @Failure      404 {object}                    responsebody.Message
+// @Failure      409 {object}                    responsebody.Message
+// @Router       /account/identities/{provider}  [delete]
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UnlinkIdentity"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	userID := c.GetString("UserID")
+	provider := c.Param("provider")
+
+	user, err := h.repository.User.GetByID(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid authorization token")
+		return
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if user.PasswordHash == "" {
+		identities, err := h.repository.Identity.GetByUserID(c, userID)
+		if err != nil {
+			log.Error("can't get identities", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+
+		if len(identities) <= 1 {
+			log.Debug("can't unlink the only sign in method")
+			response.WithMessage(c, http.StatusConflict, "can't unlink the only sign in method, set a password first")
+			return
+		}
+	}
+
+	err = h.repository.Identity.Delete(c, userID, provider)
+	if errors.Is(err, repoerr.ErrIdentityNotFound) {
+		log.Debug("identity not found", slog.String("provider", provider))
+		response.WithMessage(c, http.StatusNotFound, "identity not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't unlink identity", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("identity unlinked", slog.String("user_id", userID), slog.String("provider", provider))
+
+	c.Status(http.StatusOK)
+}
+
+// startOAuth saves a new authorization request and responds with provider's
+// authorization URL. userID is set when linking a provider to an account
+func (h *Handler) startOAuth(c *gin.Context, log *slog.Logger, userID string) {
+	name := c.Param("provider")
+	provider, ok := h.providers[name]
+	if !ok {
+		log.Debug("provider not found", slog.String("provider", name))
+		response.WithMessage(c, http.StatusNotFound, "provider not found")
+		return
+	}
+
+	state := oauth.NewState()
+	verifier := oauth.NewVerifier()
+	nonce := oauth.NewState()
+
+	authURL, err := provider.AuthCodeURL(c, state, verifier, nonce)
+	if err != nil {
+		log.Error("can't build authorization URL", sl.Err(err), slog.String("provider", name))
+		response.InternalServerError(c)
+		return
+	}
+
+	err = h.repository.Identity.CreateState(c, state, name, verifier, nonce, userID, time.Now().Add(oauthStateTTL))
+	if err != nil {
+		log.Error("can't save authorization state", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, responsebody.OAuthAuthorization{
+		AuthorizationURL: authURL,
+		State:            state,
+	})
+}
+
+// exchangeOAuth consumes authorization request state and redeems the code.
+// Responds with an error and returns false, if the identity can't be obtained
+func (h *Handler) exchangeOAuth(c *gin.Context, log *slog.Logger, name string, body requestbody.CompleteOAuth, userID string) (*oauth.Identity, bool) {
+	provider, ok := h.providers[name]
+	if !ok {
+		log.Debug("provider not found", slog.String("provider", name))
+		response.WithMessage(c, http.StatusNotFound, "provider not found")
+		return nil, false
+	}
+
+	state, err := h.repository.Identity.ConsumeState(c, body.State)
+	if errors.Is(err, repoerr.ErrOAuthStateNotFound) {
+		log.Debug("state not found")
+		response.WithMessage(c, http.StatusBadRequest, "invalid state")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("can't get authorization state", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	if state.Provider != name || state.UserID != userID || state.ExpiresAt.Before(time.Now()) {
+		log.Debug("state doesn't match the request", slog.String("provider", name))
+		response.WithMessage(c, http.StatusBadRequest, "invalid state")
+		return nil, false
+	}
+
+	identity, err := provider.Exchange(c, body.Code, state.CodeVerifier, state.Nonce)
+	if errors.Is(err, oauth.ErrExchangeFailed) || errors.Is(err, oauth.ErrInvalidIDToken) {
+		log.Debug("can't authenticate with provider", sl.Err(err), slog.String("provider", name))
+		response.WithMessage(c, http.StatusUnauthorized, "can't authenticate with provider")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("can't exchange authorization code", sl.Err(err), slog.String("provider", name))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	return identity, true
+}
+
+// createOAuthUser creates an account for an identity, signed in for the
+// first time. Accounts are never linked by email automatically, as it would
+// let a provider take over an existing account
+func (h *Handler) createOAuthUser(c *gin.Context, log *slog.Logger, provider string, identity *oauth.Identity) (*entity.User, bool) {
+	if identity.Email == "" || !identity.EmailVerified {
+		log.Debug("provider returned no verified email", slog.String("provider", provider))
+		response.WithMessage(c, http.StatusBadRequest, "provider returned no verified email")
+		return nil, false
+	}
+
+	_, err := h.repository.User.GetByEmail(c, identity.Email)
+	if err == nil {
+		log.Debug("user with the email already exists", slog.String("provider", provider))
+		response.WithMessage(c, http.StatusConflict, "user with this email already exists, sign in and link the provider")
+		return nil, false
+	}
+	if !errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	base := oauthUsername(identity)
+	username := base
+	for attempt := 0; attempt < 5; attempt++ {
+		user, err := h.repository.User.CreateWithIdentity(c, identity.Email, username, provider, identity.Subject)
+		if errors.Is(err, repoerr.ErrUserAlreadyExists) {
+			username = fmt.Sprintf("%s_%s", base, random.StringWith(4, random.Numbers))
+			continue
+		}
+		if err != nil {
+			log.Error("can't create user", sl.Err(err))
+			response.InternalServerError(c)
+			return nil, false
+		}
+
+		log.Info("user created with external provider", slog.String("user_id", user.ID), slog.String("provider", provider))
+		return user, true
+	}
+
+	log.Error("can't find a free username", slog.String("username", base))
+	response.InternalServerError(c)
+	return nil, false
+}
+
+// oauthUsername derives a username from provider's username or email
+func oauthUsername(identity *oauth.Identity) string {
+	username := identity.Username
+	if username == "" {
+		username, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	username = usernameUnsafeChars.ReplaceAllString(strings.ToLower(username), "")
+	if len(username) > 24 {
+		username = username[:24]
+	}
+	if len(username) < 5 {
+		username = fmt.Sprintf("user_%s%s", username, random.StringWith(6, random.Numbers))
+	}
+
+	return username
+}
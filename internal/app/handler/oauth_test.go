@@ -0,0 +1,683 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/oauth"
+	"api/pkg/oauth/oauthtest"
+	"api/pkg/password"
+	"api/pkg/sha256"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func oauthConfig(server *oauthtest.Server) config.Config {
+	return config.Config{
+		Token: config.Token{Secret: "some-supa-secret-characters"},
+		OAuth: config.OAuth{
+			Providers: []config.OAuthProvider{
+				{
+					Name:         "test",
+					Issuer:       server.URL,
+					ClientID:     oauthtest.ClientID,
+					ClientSecret: oauthtest.ClientSecret,
+					RedirectURL:  "app://callback",
+				},
+			},
+		},
+	}
+}
+
+func oauthStateRows(state string, provider string, verifier string, nonce string, userID string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"state", "provider", "code_verifier", "nonce", "user_id", "expires_at", "created_at"}).
+		AddRow(state, provider, verifier, nonce, userID, time.Now().Add(time.Minute), time.Now())
+}
+
+func identityRows(userID string, provider string, subject string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email", "created_at"}).
+		AddRow("IDENTITY_ID", userID, provider, subject, "john.doe@example.com", time.Now())
+}
+
+func TestStartOAuth(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	c := oauthConfig(server)
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO oauth_states (state, provider, code_verifier, nonce, user_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6)").
+					WithArgs(sqlmock.AnyArg(), "test", sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"authorization_url", "state"},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO oauth_states (state, provider, code_verifier, nonce, user_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6)").
+					WithArgs(sqlmock.AnyArg(), "test", sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/auth/oauth/:provider", "/api/auth/oauth/test", handler.StartOAuth)
+	}
+
+	test.Endpoint(t, test.Case{
+		Name: "provider not found",
+
+		Expect: test.Expect{
+			Status: http.StatusNotFound,
+			Body: responsebody.Message{
+				Message: "provider not found",
+			},
+		},
+	}, mock, http.MethodPost, "/api/auth/oauth/:provider", "/api/auth/oauth/unknown", handler.StartOAuth)
+}
+
+func TestCompleteOAuth(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	c := oauthConfig(server)
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	verifier := oauth.NewVerifier()
+	nonce := oauth.NewState()
+
+	user := oauthtest.User{
+		Subject:       "SUBJECT",
+		Email:         "john.doe@example.com",
+		EmailVerified: true,
+		Username:      "John.Doe",
+	}
+
+	unverified := user
+	unverified.EmailVerified = false
+
+	authorize := func(user oauthtest.User) string {
+		return server.Authorize(user, oauth.Challenge(verifier), nonce)
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok: linked identity",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, ""))
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE provider = $1 AND subject = $2").
+					WithArgs("test", user.Subject).
+					WillReturnRows(identityRows("USER_ID", "test", user.Subject))
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows("", false))
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs("USER_ID", sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "Phone", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State:  "STATE",
+					Code:   authorize(user),
+					Device: "Phone",
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "ok: new user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, ""))
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE provider = $1 AND subject = $2").
+					WithArgs("test", user.Subject).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO users (email, username, password_hash, is_confirmed) VALUES ($1, $2, '', true) RETURNING *").
+					WithArgs(user.Email, "john.doe").
+					WillReturnRows(twoFactorUserRows("", false))
+				mock.ExpectExec("INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)").
+					WithArgs("USER_ID", "test", user.Subject, user.Email).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+
+				mock.ExpectQuery("INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs("USER_ID", sqlmock.AnyArg(), sha256.String("REFRESH_TOKEN"), sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sessionRows())
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  authorize(user),
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"token", "refresh_token"},
+			},
+		},
+		{
+			Name: "email already taken",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, ""))
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE provider = $1 AND subject = $2").
+					WithArgs("test", user.Subject).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				mock.ExpectQuery("SELECT * FROM users WHERE email = $1").
+					WithArgs(user.Email).
+					WillReturnRows(twoFactorUserRows("", false))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  authorize(user),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "user with this email already exists, sign in and link the provider",
+				},
+			},
+		},
+		{
+			Name: "email not verified",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, ""))
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE provider = $1 AND subject = $2").
+					WithArgs("test", user.Subject).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  authorize(unverified),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "provider returned no verified email",
+				},
+			},
+		},
+		{
+			Name: "invalid state",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(sqlmock.NewRows([]string{"state"}))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  "CODE",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid state",
+				},
+			},
+		},
+		{
+			Name: "state issued for linking",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, "USER_ID"))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  "CODE",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid state",
+				},
+			},
+		},
+		{
+			Name: "invalid code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, ""))
+			},
+
+			Request: test.Request{
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  "CODE",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "can't authenticate with provider",
+				},
+			},
+		},
+		{
+			Name: "invalid request body",
+
+			Request: test.Request{
+				Body: map[string]string{
+					"invalid": "body",
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/auth/oauth/:provider/callback", "/api/auth/oauth/test/callback", handler.CompleteOAuth)
+	}
+}
+
+func TestCompleteLinkIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	c := oauthConfig(server)
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	verifier := oauth.NewVerifier()
+	nonce := oauth.NewState()
+
+	user := oauthtest.User{
+		Subject:       "SUBJECT",
+		Email:         "john.doe@example.com",
+		EmailVerified: true,
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, "USER_ID"))
+
+				mock.ExpectQuery("INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING *").
+					WithArgs("USER_ID", "test", user.Subject, user.Email).
+					WillReturnRows(identityRows("USER_ID", "test", user.Subject))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  server.Authorize(user, oauth.Challenge(verifier), nonce),
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusCreated,
+				BodyFields: []string{"provider", "email"},
+			},
+		},
+		{
+			Name: "already linked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, "USER_ID"))
+
+				mock.ExpectQuery("INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING *").
+					WithArgs("USER_ID", "test", user.Subject, user.Email).
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  server.Authorize(user, oauth.Challenge(verifier), nonce),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "provider already linked",
+				},
+			},
+		},
+		{
+			Name: "state issued for another user",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("DELETE FROM oauth_states WHERE state = $1 RETURNING *").
+					WithArgs("STATE").
+					WillReturnRows(oauthStateRows("STATE", "test", verifier, nonce, "ANOTHER_USER_ID"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CompleteOAuth{
+					State: "STATE",
+					Code:  server.Authorize(user, oauth.Challenge(verifier), nonce),
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid state",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/account/identities/:provider/callback", "/api/account/identities/test/callback", handler.UserIdentity, handler.CompleteLinkIdentity)
+	}
+}
+
+func TestGetIdentities(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	c := config.Config{Token: config.Token{Secret: "some-supa-secret-characters"}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	createdAt := time.Now()
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email", "created_at"}).
+					AddRow("IDENTITY_ID", "USER_ID", "google", "SUBJECT", "john.doe@example.com", createdAt)
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at ASC").
+					WithArgs("USER_ID").
+					WillReturnRows(rows)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.IdentityList{
+					Count: 1,
+					Identities: []responsebody.Identity{
+						{
+							Provider:  "google",
+							Email:     "john.doe@example.com",
+							CreatedAt: createdAt.Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at ASC").
+					WithArgs("USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/account/identities", "/api/account/identities", handler.UserIdentity, handler.GetIdentities)
+	}
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	c := config.Config{Token: config.Token{Secret: "some-supa-secret-characters"}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	userRows := func(passwordHash string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email", "username", "password_hash"}).
+			AddRow("USER_ID", "john.doe@example.com", "johndoe", passwordHash)
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(userRows(sha256.String("testword")))
+
+				mock.ExpectExec("DELETE FROM user_identities WHERE user_id = $1 AND provider = $2").
+					WithArgs("USER_ID", "google").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "the only sign in method",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(userRows(""))
+
+				mock.ExpectQuery("SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at ASC").
+					WithArgs("USER_ID").
+					WillReturnRows(identityRows("USER_ID", "google", "SUBJECT"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "can't unlink the only sign in method, set a password first",
+				},
+			},
+		},
+		{
+			Name: "identity not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(userRows(sha256.String("testword")))
+
+				mock.ExpectExec("DELETE FROM user_identities WHERE user_id = $1 AND provider = $2").
+					WithArgs("USER_ID", "google").
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "identity not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/account/identities/:provider", "/api/account/identities/google", handler.UserIdentity, handler.UnlinkIdentity)
+	}
+}
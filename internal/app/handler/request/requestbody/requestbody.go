@@ -1,5 +1,7 @@
 package requestbody
 
+import "time"
+
 type CreateAccount struct {
 	Email    string `json:"email" binding:"required,max=254"`
 	Username string `json:"username" binding:"required,min=5,max=32"`
@@ -9,20 +11,120 @@ type CreateAccount struct {
 type CreateSession struct {
 	Login    string `json:"login" binding:"required,max=254"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"omitempty,max=100"`
+}
+
+type CompleteTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type CompleteOAuth struct {
+	State  string `json:"state" binding:"required,max=64"`
+	Code   string `json:"code" binding:"required"`
+	Device string `json:"device" binding:"omitempty,max=100"`
 }
 
+type RefreshSession struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// UpdateAccount describes fields of an account to change. Default
+// visibility applies to new workouts, and to existing ones too, if
+// `apply_to_workouts` is set
 type UpdateAccount struct {
-	Email       *string `json:"email" binding:"omitempty,max=254"`
-	Username    *string `json:"username" binding:"omitempty,min=5,max=32"`
-	DisplayName *string `json:"display_name" binding:"omitempty,max=50"`
-	Password    *string `json:"password" binding:"omitempty,min=8,max=64"`
-	IsPrivate   *bool   `json:"is_private" binding:"omitempty"`
+	Email             *string `json:"email" binding:"omitempty,max=254"`
+	Username          *string `json:"username" binding:"omitempty,min=5,max=32"`
+	DisplayName       *string `json:"display_name" binding:"omitempty,max=50"`
+	Password          *string `json:"password" binding:"omitempty,min=8,max=64"`
+	IsPrivate         *bool   `json:"is_private" binding:"omitempty"`
+	Units             *string `json:"units" binding:"omitempty,oneof=metric imperial"`
+	TimeZone          *string `json:"time_zone" binding:"omitempty,max=64"`
+	DefaultVisibility *string `json:"default_visibility" binding:"omitempty,oneof=public followers only_me"`
+	ApplyToWorkouts   bool    `json:"apply_to_workouts" binding:"excluded_without=DefaultVisibility"`
+}
+
+type CreateAPIKey struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=workouts:read workouts:write profile:read social"`
+	ExpiresAt *time.Time `json:"expires_at" binding:"omitempty"`
 }
 
+// CreateWorkout describes a new workout. Its activity is taken from the
+// catalog by `activity_id` or by `kind`, which may be a name or an alias.
+// Visibility defaults to the user's default one
 type CreateWorkout struct {
-	Date     string `json:"date" binding:"required"`
-	Duration int    `json:"duration" binding:"required"`
-	Kind     string `json:"kind" binding:"required"`
+	Date       string     `json:"date" binding:"required_without=StartTime"`
+	StartTime  *time.Time `json:"start_time" binding:"omitempty"`
+	Duration   int        `json:"duration" binding:"required,min=1,max=1440"`
+	ActivityID string     `json:"activity_id" binding:"omitempty,uuid"`
+	Kind       string     `json:"kind" binding:"required_without=ActivityID,max=50"`
+	Visibility string     `json:"visibility" binding:"omitempty,oneof=public followers only_me"`
+	Exercises  []Exercise `json:"exercises" binding:"omitempty,max=50,dive"`
+	Metrics
+}
+
+type UpdateWorkout struct {
+	Date       *string    `json:"date" binding:"omitempty"`
+	StartTime  *time.Time `json:"start_time" binding:"omitempty"`
+	Duration   *int       `json:"duration" binding:"omitempty,min=1,max=1440"`
+	ActivityID *string    `json:"activity_id" binding:"omitempty,uuid"`
+	Kind       *string    `json:"kind" binding:"omitempty,max=50"`
+	Visibility *string    `json:"visibility" binding:"omitempty,oneof=public followers only_me"`
+	Exercises  []Exercise `json:"exercises" binding:"omitempty,max=50,dive"`
+	Metrics
+}
+
+// CSVMapping maps fields of a workout to names of CSV columns. Omitted
+// fields are read from columns, named as in CSV export
+type CSVMapping struct {
+	Date          string `json:"date"`
+	Duration      string `json:"duration"`
+	Kind          string `json:"kind"`
+	ActivityID    string `json:"activity_id"`
+	Distance      string `json:"distance"`
+	ElevationGain string `json:"elevation_gain"`
+	AvgHeartRate  string `json:"avg_heart_rate"`
+	MaxHeartRate  string `json:"max_heart_rate"`
+	Cadence       string `json:"cadence"`
+	Calories      string `json:"calories"`
+}
+
+// Metrics describes endurance metrics of a workout. Distance is in kilometers
+// or miles and elevation gain is in meters or feet, depending on user's units
+type Metrics struct {
+	Distance      *float64 `json:"distance" binding:"omitempty,gt=0,max=10000"`
+	ElevationGain *float64 `json:"elevation_gain" binding:"omitempty,min=0,max=50000"`
+	AvgHeartRate  *int     `json:"avg_heart_rate" binding:"omitempty,min=20,max=250"`
+	MaxHeartRate  *int     `json:"max_heart_rate" binding:"omitempty,min=20,max=250"`
+	Cadence       *int     `json:"cadence" binding:"omitempty,min=0,max=300"`
+	Calories      *int     `json:"calories" binding:"omitempty,min=0,max=20000"`
+}
+
+type Exercise struct {
+	ExerciseID string        `json:"exercise_id" binding:"omitempty,uuid"`
+	Name       string        `json:"name" binding:"required_without=ExerciseID,max=100"`
+	Sets       []ExerciseSet `json:"sets" binding:"max=100,dive"`
+}
+
+type CreateCatalogEntry struct {
+	Type         string   `json:"type" binding:"required,oneof=activity exercise"`
+	Name         string   `json:"name" binding:"required,max=50"`
+	Category     string   `json:"category" binding:"required"`
+	MuscleGroups []string `json:"muscle_groups" binding:"omitempty,max=12,dive,oneof=chest back shoulders biceps triceps forearms core glutes quadriceps hamstrings calves full_body"`
+	MET          float64  `json:"met" binding:"required,gt=0,max=25"`
+}
+
+type ExerciseSet struct {
+	Reps        *int     `json:"reps" binding:"omitempty,min=0,max=1000"`
+	Weight      *float64 `json:"weight" binding:"omitempty,min=0,max=10000"`
+	WeightUnit  string   `json:"weight_unit" binding:"required_with=Weight,omitempty,oneof=kg lb"`
+	RPE         *float64 `json:"rpe" binding:"omitempty,min=1,max=10"`
+	RestSeconds *int     `json:"rest_seconds" binding:"omitempty,min=0,max=3600"`
 }
 
 type ResetPassword struct {
@@ -37,3 +139,16 @@ type UpdatePassword struct {
 type ConfirmAccount struct {
 	Token string `json:"token" binding:"required"`
 }
+
+type UpdateRole struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+type CreateComment struct {
+	Text     string `json:"text" binding:"required,max=1000"`
+	ParentID string `json:"parent_id" binding:"omitempty,uuid"`
+}
+
+type UpdateComment struct {
+	Text string `json:"text" binding:"required,max=1000"`
+}
@@ -5,44 +5,350 @@ type Message struct {
 }
 
 type Account struct {
+	ID                string `json:"id"`
+	Email             string `json:"email"`
+	Username          string `json:"username"`
+	DisplayName       string `json:"display_name"`
+	AvatarURL         string `json:"avatar_url"`
+	IsPrivate         bool   `json:"is_private"`
+	IsConfirmed       bool   `json:"is_confirmed"`
+	TwoFactor         bool   `json:"two_factor_enabled"`
+	Role              string `json:"role"`
+	Units             string `json:"units"`
+	TimeZone          string `json:"time_zone"`
+	DefaultVisibility string `json:"default_visibility"`
+	CreatedAt         string `json:"created_at"`
+}
+
+type Profile struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	DisplayName    string    `json:"display_name"`
+	AvatarURL      string    `json:"avatar_url"`
+	IsPrivate      bool      `json:"is_private"`
+	FollowersCount int       `json:"followers_count"`
+	FollowingCount int       `json:"following_count"`
+	FollowStatus   string    `json:"follow_status,omitempty"`
+	WeekActivity   []Workout `json:"week_activity"`
+}
+
+// Follow tells, whether a follow is accepted or waits for an approval
+type Follow struct {
+	Status string `json:"status"`
+}
+
+type FollowUser struct {
 	ID          string `json:"id"`
-	Email       string `json:"email"`
 	Username    string `json:"username"`
 	DisplayName string `json:"display_name"`
 	AvatarURL   string `json:"avatar_url"`
-	IsPrivate   bool   `json:"is_private"`
-	IsConfirmed bool   `json:"is_confirmed"`
-	CreatedAt   string `json:"created_at"`
+	FollowedAt  string `json:"followed_at"`
 }
 
-type Profile struct {
-	ID           string    `json:"id"`
-	Username     string    `json:"username"`
-	DisplayName  string    `json:"display_name"`
-	AvatarURL    string    `json:"avatar_url"`
-	IsPrivate    bool      `json:"is_private"`
-	WeekActivity []Workout `json:"week_activity"`
+type FollowList struct {
+	Count int          `json:"count"`
+	Users []FollowUser `json:"users"`
+}
+
+type ListedUser struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+	ListedAt    string `json:"listed_at"`
+}
+
+type ListedUserList struct {
+	Count int          `json:"count"`
+	Users []ListedUser `json:"users"`
 }
 
 type Workout struct {
-	ID       string `json:"id"`
-	Date     string `json:"date"`
-	Duration int    `json:"duration"`
-	Kind     string `json:"kind"`
+	ID            string     `json:"id"`
+	ActivityID    string     `json:"activity_id,omitempty"`
+	Date          string     `json:"date"`
+	StartTime     string     `json:"start_time,omitempty"`
+	Duration      int        `json:"duration"`
+	Kind          string     `json:"kind"`
+	Visibility    string     `json:"visibility,omitempty"`
+	Exercises     []Exercise `json:"exercises,omitempty"`
+	Laps          []Lap      `json:"laps,omitempty"`
+	KudosCount    int        `json:"kudos_count,omitempty"`
+	CommentsCount int        `json:"comments_count,omitempty"`
+	Metrics
+}
+
+// Lap is a part of an imported workout. Start time is in RFC 3339, duration
+// is in seconds and distance is in user's units
+type Lap struct {
+	StartTime    string   `json:"start_time"`
+	Duration     int      `json:"duration"`
+	Distance     *float64 `json:"distance,omitempty"`
+	AvgHeartRate *int     `json:"avg_heart_rate,omitempty"`
+	MaxHeartRate *int     `json:"max_heart_rate,omitempty"`
+	Calories     *int     `json:"calories,omitempty"`
+}
+
+// Metrics describes endurance metrics of a workout in user's units: distance
+// in kilometers or miles, elevation gain in meters or feet, pace in seconds
+// per kilometer or mile and speed in kilometers or miles per hour
+type Metrics struct {
+	Distance      *float64 `json:"distance,omitempty"`
+	ElevationGain *float64 `json:"elevation_gain,omitempty"`
+	Pace          *int     `json:"pace,omitempty"`
+	Speed         *float64 `json:"speed,omitempty"`
+	AvgHeartRate  *int     `json:"avg_heart_rate,omitempty"`
+	MaxHeartRate  *int     `json:"max_heart_rate,omitempty"`
+	Cadence       *int     `json:"cadence,omitempty"`
+	Calories      *int     `json:"calories,omitempty"`
+}
+
+type Exercise struct {
+	ExerciseID *string       `json:"exercise_id,omitempty"`
+	Name       string        `json:"name"`
+	Sets       []ExerciseSet `json:"sets"`
+}
+
+type CatalogEntry struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Name         string   `json:"name"`
+	Category     string   `json:"category"`
+	MuscleGroups []string `json:"muscle_groups"`
+	MET          float64  `json:"met"`
+	IsCustom     bool     `json:"is_custom"`
+}
+
+type CatalogEntryList struct {
+	Count   int            `json:"count"`
+	Entries []CatalogEntry `json:"entries"`
+}
+
+type ExerciseSet struct {
+	Reps        *int     `json:"reps,omitempty"`
+	Weight      *float64 `json:"weight,omitempty"`
+	WeightUnit  string   `json:"weight_unit,omitempty"`
+	RPE         *float64 `json:"rpe,omitempty"`
+	RestSeconds *int     `json:"rest_seconds,omitempty"`
+}
+
+// CSVImport is a report of a CSV import. Rows are numbered as lines of the
+// file, with the header being the first one
+type CSVImport struct {
+	DryRun     bool             `json:"dry_run"`
+	Total      int              `json:"total"`
+	Valid      int              `json:"valid"`
+	Imported   int              `json:"imported"`
+	Invalid    int              `json:"invalid"`
+	Duplicates int              `json:"duplicates"`
+	Errors     []CSVImportError `json:"errors"`
+}
+
+type CSVImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
 }
 
 type ActivityHistory struct {
-	UserID   string    `json:"user_id"`
+	UserID     string    `json:"user_id"`
+	Count      int       `json:"count"`
+	Workouts   []Workout `json:"workouts"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type Feed struct {
+	Count      int        `json:"count"`
+	Items      []FeedItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type FeedItem struct {
+	Author  Author  `json:"author"`
+	Workout Workout `json:"workout"`
+}
+
+// Author is a summary of a user, that created a workout, gave kudos or left
+// a comment
+type Author struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+type KudosList struct {
+	Count int     `json:"count"`
+	Kudos []Kudos `json:"kudos"`
+}
+
+type Kudos struct {
+	Author    Author `json:"author"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CommentList is a tree of comments of a workout. Count includes replies
+type CommentList struct {
 	Count    int       `json:"count"`
-	Workouts []Workout `json:"workouts"`
+	Comments []Comment `json:"comments"`
+}
+
+// Comment is a comment of a workout with replies to it, the oldest first.
+// Edited at is empty, unless the comment was edited
+type Comment struct {
+	ID        string    `json:"id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Author    Author    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt string    `json:"created_at"`
+	EditedAt  string    `json:"edited_at,omitempty"`
+	Replies   []Comment `json:"replies,omitempty"`
 }
 
+// Statistics are aggregates of workouts in a date range. Periods and
+// kinds omit those without workouts, heatmap covers the last year of the
+// range day by day
 type Statistics struct {
-	UserID          string `json:"user_id"`
-	MinutesSpent    int    `json:"minutes_spent"`
-	LongestActivity int    `json:"longest_activity"`
+	UserID          string             `json:"user_id"`
+	Begin           string             `json:"begin"`
+	End             string             `json:"end"`
+	Count           int                `json:"count"`
+	ActiveDays      int                `json:"active_days"`
+	MinutesSpent    int                `json:"minutes_spent"`
+	LongestActivity int                `json:"longest_activity"`
+	TotalDistance   float64            `json:"total_distance"`
+	Units           string             `json:"units"`
+	Averages        Averages           `json:"averages"`
+	Streaks         Streaks            `json:"streaks"`
+	GroupBy         string             `json:"group_by"`
+	Periods         []PeriodStatistics `json:"periods"`
+	Kinds           []KindStatistics   `json:"kinds"`
+	Heatmap         []HeatmapDay       `json:"heatmap"`
+}
+
+// Averages are per workout, except of workouts per week. Distance is
+// averaged among workouts with distance only
+type Averages struct {
+	Duration        float64 `json:"duration"`
+	Distance        float64 `json:"distance"`
+	WorkoutsPerWeek float64 `json:"workouts_per_week"`
+}
+
+type Streaks struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+type PeriodStatistics struct {
+	Start         string  `json:"start"`
+	Count         int     `json:"count"`
+	MinutesSpent  int     `json:"minutes_spent"`
+	TotalDistance float64 `json:"total_distance"`
+}
+
+type KindStatistics struct {
+	Kind          string  `json:"kind"`
+	Count         int     `json:"count"`
+	MinutesSpent  int     `json:"minutes_spent"`
+	AvgDuration   float64 `json:"avg_duration"`
+	TotalDistance float64 `json:"total_distance"`
+	BestPace      *int    `json:"best_pace,omitempty"`
+}
+
+type HeatmapDay struct {
+	Date         string `json:"date"`
+	Count        int    `json:"count"`
+	MinutesSpent int    `json:"minutes_spent"`
+}
+
+type Session struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	LastSeenAt string `json:"last_seen_at"`
+	IsCurrent  bool   `json:"is_current"`
+}
+
+type SessionList struct {
+	Count    int       `json:"count"`
+	Sessions []Session `json:"sessions"`
 }
 
 type Token struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TwoFactorChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OAuthProviders struct {
+	Providers []string `json:"providers"`
+}
+
+type OAuthAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+type Identity struct {
+	Provider  string `json:"provider"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+type IdentityList struct {
+	Count      int        `json:"count"`
+	Identities []Identity `json:"identities"`
+}
+
+type APIKey struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatedAPIKey contains the key itself, that is shown only once
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyList struct {
+	Count   int      `json:"count"`
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+// User describes an account as seen by moderators
+type User struct {
+	ID          string `json:"id"`
+	Email       string `json:"email"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+	Role        string `json:"role"`
+	IsPrivate   bool   `json:"is_private"`
+	IsConfirmed bool   `json:"is_confirmed"`
+	SuspendedAt string `json:"suspended_at,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type UserList struct {
+	Count int    `json:"count"`
+	Users []User `json:"users"`
 }
@@ -0,0 +1,25 @@
+package role
+
+// Every role includes permissions of the roles below it
+const (
+	User      = "user"
+	Moderator = "moderator"
+	Admin     = "admin"
+)
+
+var rank = map[string]int{
+	User:      1,
+	Moderator: 2,
+	Admin:     3,
+}
+
+// AtLeast reports whether role grants permissions of the required one
+func AtLeast(role string, required string) bool {
+	return rank[role] > 0 && rank[role] >= rank[required]
+}
+
+// Outranks reports whether role is higher than the other one. Moderators
+// can only manage users with lower roles
+func Outranks(role string, other string) bool {
+	return rank[role] > rank[other]
+}
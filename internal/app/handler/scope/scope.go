@@ -0,0 +1,31 @@
+package scope
+
+const (
+	WorkoutsRead  = "workouts:read"
+	WorkoutsWrite = "workouts:write"
+	ProfileRead   = "profile:read"
+
+	// Social allows following users, managing followers and reading the feed
+	Social = "social"
+
+	// Account allows managing the account itself, e.g. sessions, password or
+	// API keys. It's granted to sessions only and can't be given to an API key
+	Account = "account"
+)
+
+// Grantable lists scopes, that can be given to an API key
+var Grantable = []string{WorkoutsRead, WorkoutsWrite, ProfileRead, Social}
+
+// Session lists scopes of a user signed in with a password or a provider
+var Session = []string{WorkoutsRead, WorkoutsWrite, ProfileRead, Social, Account}
+
+// Has reports whether scopes contain given scope
+func Has(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -30,6 +30,8 @@ type Expect struct {
 	Status     int
 	Body       any
 	BodyFields []string
+	// RawBody skips decoding of a non-JSON response body, e.g. a file download
+	RawBody bool
 }
 
 var ResponseInternalServerError = Expect{
@@ -42,6 +44,16 @@ var ResponseInvalidRequestBody = Expect{
 	Body:   `{"message":"invalid request body"}`,
 }
 
+// TouchSessionQuery is executed by handler.UserIdentity on every request made with an access token
+const TouchSessionQuery = "WITH session AS (SELECT sessions.id, sessions.last_seen_at, users.suspended_at FROM sessions JOIN users ON users.id = sessions.user_id WHERE sessions.family_id = $3 AND sessions.is_used = false AND sessions.expires_at > now()), touched AS (UPDATE sessions SET last_seen_at = now(), user_agent = $1, ip_address = $2 FROM session WHERE sessions.id = session.id AND session.last_seen_at < now() - interval '1 minute') SELECT suspended_at IS NOT NULL FROM session"
+
+// ExpectActiveSession registers the session lookup performed by handler.UserIdentity
+func ExpectActiveSession(mock sqlmock.Sqlmock, sessionID string) {
+	mock.ExpectQuery(TouchSessionQuery).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"suspended"}).AddRow(false))
+}
+
 func Endpoint(t *testing.T, tc Case, mock sqlmock.Sqlmock, method string, handlerPath string, requestPath string, handlers ...gin.HandlerFunc) {
 	t.Run(tc.Name, func(t *testing.T) {
 		if tc.Repo != nil {
@@ -103,7 +115,7 @@ func Endpoint(t *testing.T, tc Case, mock sqlmock.Sqlmock, method string, handle
 
 		if w.Body.String() == "" && len(tc.Expect.BodyFields) > 0 {
 			t.Fatal("expected some body fields, got empty body")
-		} else if len(w.Body.String()) != 0 {
+		} else if len(w.Body.String()) != 0 && !tc.Expect.RawBody {
 
 			var body map[string]any
 			err = json.Unmarshal(w.Body.Bytes(), &body)
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"api/internal/app/handler/catalog"
+	"api/internal/app/handler/response"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/requestid"
+	"api/pkg/track"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxTrackSize = 10 * 1024 * 1024 // 10Mb
+	// maxTrackFormOverhead leaves room for other form fields and boundaries
+	maxTrackFormOverhead = 1024 * 1024 // 1Mb
+	maxKindLength        = 50
+)
+
+// Device metrics are bounded the same way as requestbody.Metrics. Files may
+// contain sensor glitches, so values out of bounds are dropped instead of
+// rejecting a whole recording
+const (
+	minDeviceHeartRate = 20
+	maxDeviceHeartRate = 250
+	maxDeviceCadence   = 300
+	maxDeviceCalories  = 20000
+)
+
+// trackFormats lists supported track files by their extensions
+var trackFormats = map[string]struct {
+	contentType string
+	parse       func(io.Reader) (*track.Activity, error)
+}{
+	"gpx": {contentType: "application/gpx+xml", parse: track.ParseGPX},
+	"tcx": {contentType: "application/vnd.garmin.tcx+xml", parse: track.ParseTCX},
+	"fit": {contentType: "application/vnd.ant.fit", parse: track.ParseFIT},
+}
+
+// @Summary      Import a recorded workout
+// @Description  creates a workout from a GPX, TCX or FIT file, recorded by a watch or bike computer. Date, duration, distance, elevation gain, heart rate and cadence are taken from track points, activity is taken from `kind` or a sport of the file. Laps of FIT files are stored with the workout. Heart rate, cadence and calories out of the bounds accepted for manual workouts are dropped. The file is stored as is and can be downloaded later
+// @Security     AccessToken
+// @Tags         activity
+// @Accept       mpfd
+// @Produce      json
+// @Param        file formData         file true "GPX, TCX or FIT file, smaller than 10Mb"
+// @Param        kind formData         string false "Workout kind, overrides a sport of the file"
+// @Param        activity_id formData  string false "Catalog activity ID"
+// @Success      201 {object}          responsebody.Workout
+// @Failure      400 {object}          responsebody.Message
+// @Failure      401 {object}          responsebody.Message
+// @Failure      413 {object}          responsebody.Message
+// @Router       /workout/import       [post]
+func (h *Handler) ImportWorkout(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.ImportWorkout"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxTrackSize+maxTrackFormOverhead)
+
+	file, err := c.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		log.Debug("request body too big", sl.Err(err))
+		response.WithMessage(c, http.StatusRequestEntityTooLarge, "file should be smaller than 10Mb")
+		return
+	}
+	if err != nil {
+		log.Debug("can't get file form", sl.Err(err))
+		response.WithMessage(c, http.StatusBadRequest, "no track file provided")
+		return
+	}
+
+	if file.Size > maxTrackSize {
+		log.Debug("file too big", slog.Int64("size", file.Size))
+		response.WithMessage(c, http.StatusBadRequest, "file should be smaller than 10Mb")
+		return
+	}
+
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Filename), "."))
+	format, ok := trackFormats[extension]
+	if !ok {
+		log.Debug("invalid extension", slog.String("extension", extension))
+		response.WithMessage(c, http.StatusBadRequest, "only gpx, tcx and fit files are available")
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		log.Error("can't open uploaded file", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxTrackSize))
+	if err != nil {
+		log.Error("can't read uploaded file", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	activity, err := format.parse(bytes.NewReader(data))
+	if err != nil {
+		log.Debug("can't parse track", sl.Err(err))
+		response.WithMessage(c, http.StatusBadRequest, fmt.Sprintf("malformed %s file", extension))
+		return
+	}
+
+	summary, err := activity.Summary()
+	if errors.Is(err, track.ErrNoPoints) {
+		log.Debug("track has no points")
+		response.WithMessage(c, http.StatusBadRequest, "track has no recorded points")
+		return
+	}
+	if err != nil {
+		log.Error("can't summarize track", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	laps := make([]entity.Lap, 0, len(activity.Laps))
+	for _, lap := range activity.Laps {
+		laps = append(laps, entity.Lap{
+			StartTime:    lap.Start.UTC(),
+			Duration:     int(math.Round(lap.Duration.Seconds())),
+			Distance:     lap.Distance,
+			AvgHeartRate: plausible(lap.AvgHeartRate, minDeviceHeartRate, maxDeviceHeartRate),
+			MaxHeartRate: plausible(lap.MaxHeartRate, minDeviceHeartRate, maxDeviceHeartRate),
+			Calories:     plausible(lap.Calories, 0, maxDeviceCalories),
+		})
+	}
+
+	system, location, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	workout, ok := h.importWorkout(c, log, summary, activity.Sport, location, entity.Track{Format: extension, Data: data}, laps)
+	if !ok {
+		return
+	}
+
+	log.Info("imported a workout record", slog.String("id", workout.ID), slog.String("format", extension))
+
+	c.JSON(http.StatusCreated, workoutResponse(workout, system))
+}
+
+// @Summary      Download a track of a workout
+// @Description  returns a file, that the workout was imported from
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      application/gpx+xml,application/vnd.garmin.tcx+xml,application/vnd.ant.fit
+// @Param        id path              string true "Workout ID"
+// @Success      200 {file}           file
+// @Failure      401 {object}         responsebody.Message
+// @Failure      403 {object}         responsebody.Message
+// @Failure      404 {object}         responsebody.Message
+// @Router       /workout/{id}/track  [get]
+func (h *Handler) GetWorkoutTrack(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetWorkoutTrack"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.ownWorkout(c, log, "view")
+	if !ok {
+		return
+	}
+
+	t, err := h.repository.Workout.GetTrack(c, workout.ID)
+	if errors.Is(err, repoerr.ErrTrackNotFound) {
+		log.Debug("workout has no track", slog.String("id", workout.ID))
+		response.WithMessage(c, http.StatusNotFound, "track not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't get track", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, workout.ID, t.Format))
+	c.Data(http.StatusOK, trackFormats[t.Format].contentType, t.Data)
+}
+
+// importWorkout creates a workout from a summary of a recorded activity,
+// that is dated in user's time zone. Workout kind can be overridden with
+// `kind` and `activity_id` form fields
+func (h *Handler) importWorkout(c *gin.Context, log *slog.Logger, summary *track.Summary, sport string, location *time.Location, t entity.Track, laps []entity.Lap) (*entity.Workout, bool) {
+	duration := int(math.Round(summary.Duration.Minutes()))
+	if duration < 1 || duration > 24*60 {
+		log.Debug("invalid duration", slog.Duration("duration", summary.Duration))
+		response.WithMessage(c, http.StatusBadRequest, "workout should last from a minute to a day")
+		return nil, false
+	}
+
+	date, ok := parseWorkoutDate(c, log, summary.Start.In(location).Format(workoutDateLayout))
+	if !ok {
+		return nil, false
+	}
+	startedAt := summary.Start
+
+	activityID := c.PostForm("activity_id")
+	if activityID != "" && uuid.Validate(activityID) != nil {
+		log.Debug("invalid activity id", slog.String("activity_id", activityID))
+		response.WithMessage(c, http.StatusBadRequest, "unknown activity")
+		return nil, false
+	}
+
+	kind := c.PostForm("kind")
+	if utf8.RuneCountInString(kind) > maxKindLength {
+		log.Debug("kind is too long", slog.String("kind", kind))
+		response.WithMessage(c, http.StatusBadRequest, "workout kind can't be longer than 50 characters")
+		return nil, false
+	}
+
+	// sports of files are free text, so ones that don't fit are replaced
+	if kind == "" {
+		kind = sport
+		if kind == "" || utf8.RuneCountInString(kind) > maxKindLength {
+			kind = catalog.Fallback
+		}
+	}
+
+	activity, kind, ok := h.resolveActivity(c, log, activityID, kind)
+	if !ok {
+		return nil, false
+	}
+
+	metrics := entity.Metrics{
+		Distance:      summary.Distance,
+		ElevationGain: summary.ElevationGain,
+		AvgHeartRate:  plausible(summary.AvgHeartRate, minDeviceHeartRate, maxDeviceHeartRate),
+		MaxHeartRate:  plausible(summary.MaxHeartRate, minDeviceHeartRate, maxDeviceHeartRate),
+		Cadence:       plausible(summary.Cadence, 0, maxDeviceCadence),
+		Calories:      plausible(summary.Calories, 0, maxDeviceCalories),
+	}
+
+	userID := c.GetString("UserID")
+	workout, err := h.repository.Workout.Import(c, userID, activity.ID, date, &startedAt, duration, kind, metrics, t, laps)
+	if err != nil {
+		log.Error("can't import workout", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	return workout, true
+}
+
+// plausible returns nil for a device metric out of [min, max]
+func plausible(value *int, min, max int) *int {
+	if value == nil || *value < min || *value > max {
+		return nil
+	}
+	return value
+}
+
+// attachLaps loads laps of given imported workouts
+func (h *Handler) attachLaps(c *gin.Context, workouts ...*entity.Workout) error {
+	ids := make([]string, 0, len(workouts))
+	byID := make(map[string]*entity.Workout, len(workouts))
+	for _, workout := range workouts {
+		ids = append(ids, workout.ID)
+		byID[workout.ID] = workout
+	}
+
+	laps, err := h.repository.Workout.GetLaps(c, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, lap := range laps {
+		if workout, ok := byID[lap.WorkoutID]; ok {
+			workout.Laps = append(workout.Laps, lap)
+		}
+	}
+
+	return nil
+}
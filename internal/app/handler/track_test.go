@@ -0,0 +1,554 @@
+package handler
+
+import (
+	"api/internal/app/handler/catalog"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"api/pkg/units"
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const importGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <trk>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="0" lon="0"><ele>10</ele><time>2024-05-01T06:00:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>140</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="0" lon="0.05"><ele>20</ele><time>2024-05-01T06:30:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>160</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+var lapColumns = []string{"id", "workout_id", "position", "start_time", "duration", "distance", "avg_heart_rate", "max_heart_rate", "calories"}
+
+// multipartBody returns a body of a form with a single file and its content type
+func multipartBody(t *testing.T, filename string, content string, fields map[string]string) (string, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for key, value := range fields {
+		if err := w.WriteField(key, value); err != nil {
+			t.Fatalf("unexpected error: %v\n", err)
+		}
+	}
+
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	return buf.String(), w.FormDataContentType()
+}
+
+func TestImportWorkout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, time.May, 1, 6, 0, 0, 0, time.UTC)
+	distance := 5.56
+	elevationGain := 10.0
+	pace := 324
+	speed := 11.12
+	avgHeartRate := 150
+	maxHeartRate := 160
+
+	fitDistance := 6.05
+	fitElevationGain := 20.0
+	fitPace := 307
+	fitSpeed := 11.71
+	fitAvgHeartRate := 146
+	fitCalories := 420
+
+	type lap struct {
+		distance     float64
+		avgHeartRate int
+		maxHeartRate int
+		calories     int
+	}
+	firstLap := lap{distance: 3, avgHeartRate: 140, maxHeartRate: 145, calories: 200}
+	secondLap := lap{distance: 3.05, avgHeartRate: 155, maxHeartRate: 160, calories: 220}
+
+	fit, err := os.ReadFile("../../../pkg/fit/testdata/run.fit")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	gpxBody, gpxType := multipartBody(t, "Morning_Run.GPX", importGPX, nil)
+	fitBody, fitType := multipartBody(t, "run.fit", string(fit), nil)
+	kindBody, kindType := multipartBody(t, "run.gpx", importGPX, map[string]string{"kind": "Parkour"})
+	csvBody, csvType := multipartBody(t, "run.csv", "time,lat,lon\n", nil)
+	bigBody, bigType := multipartBody(t, "run.gpx", strings.Repeat(" ", maxTrackSize+maxTrackFormOverhead), nil)
+	malformedBody, malformedType := multipartBody(t, "run.tcx", importGPX, nil)
+	glitchBody, glitchType := multipartBody(t, "run.gpx", strings.NewReplacer(">140<", ">280<", ">160<", ">300<").Replace(importGPX), nil)
+	emptyBody, emptyType := multipartBody(t, "run.gpx", `<gpx><trk><trkseg><trkpt lat="0" lon="0"/></trkseg></trk></gpx>`, nil)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, "running").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Running"))
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "distance", "elevation_gain", "avg_heart_rate", "max_heart_rate"}).
+					AddRow("WORKOUT_ID", "USER_ID", "ACTIVITY_ID", date, 30, "Running", time.Now(), 5559.75, 10.0, 150, 160)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs("USER_ID", "ACTIVITY_ID", date, 30, "Running", sqlmock.AnyArg(), 10.0, avgHeartRate, maxHeartRate, nil, nil, start, "").
+					WillReturnRows(rows)
+				mock.ExpectExec("INSERT INTO workout_tracks (workout_id, format, data) VALUES ($1, $2, $3)").
+					WithArgs("WORKOUT_ID", "gpx", []byte(importGPX)).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  gpxType,
+				},
+				Body: gpxBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         "WORKOUT_ID",
+					ActivityID: "ACTIVITY_ID",
+					Date:       "01-05-2024",
+					Duration:   30,
+					Kind:       "Running",
+					Metrics: responsebody.Metrics{
+						Distance:      &distance,
+						ElevationGain: &elevationGain,
+						Pace:          &pace,
+						Speed:         &speed,
+						AvgHeartRate:  &avgHeartRate,
+						MaxHeartRate:  &maxHeartRate,
+					},
+				},
+			},
+		},
+		{
+			Name: "implausible heart rate is dropped",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, "running").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Running"))
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "distance", "elevation_gain"}).
+					AddRow("WORKOUT_ID", "USER_ID", "ACTIVITY_ID", date, 30, "Running", time.Now(), 5559.75, 10.0)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs("USER_ID", "ACTIVITY_ID", date, 30, "Running", sqlmock.AnyArg(), 10.0, nil, nil, nil, nil, start, "").
+					WillReturnRows(rows)
+				mock.ExpectExec("INSERT INTO workout_tracks (workout_id, format, data) VALUES ($1, $2, $3)").
+					WithArgs("WORKOUT_ID", "gpx", sqlmock.AnyArg()).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  glitchType,
+				},
+				Body: glitchBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         "WORKOUT_ID",
+					ActivityID: "ACTIVITY_ID",
+					Date:       "01-05-2024",
+					Duration:   30,
+					Kind:       "Running",
+					Metrics: responsebody.Metrics{
+						Distance:      &distance,
+						ElevationGain: &elevationGain,
+						Pace:          &pace,
+						Speed:         &speed,
+					},
+				},
+			},
+		},
+		{
+			Name: "fit with laps",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, "running").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Running"))
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs("USER_ID", "ACTIVITY_ID", date, 31, "Running", 6050.0, 20.0, 146, 160, nil, 420, start, "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "distance", "elevation_gain", "avg_heart_rate", "max_heart_rate", "calories"}).
+						AddRow("WORKOUT_ID", "USER_ID", "ACTIVITY_ID", date, 31, "Running", time.Now(), 6050.0, 20.0, 146, 160, 420))
+				mock.ExpectExec("INSERT INTO workout_tracks (workout_id, format, data) VALUES ($1, $2, $3)").
+					WithArgs("WORKOUT_ID", "fit", fit).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectQuery("INSERT INTO workout_laps (workout_id, position, start_time, duration, distance, avg_heart_rate, max_heart_rate, calories) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *").
+					WithArgs("WORKOUT_ID", 1, start, 900, 3000.0, 140, 145, 200).
+					WillReturnRows(sqlmock.NewRows(lapColumns).
+						AddRow("FIRST_LAP_ID", "WORKOUT_ID", 1, start, 900, 3000.0, 140, 145, 200))
+				mock.ExpectQuery("INSERT INTO workout_laps (workout_id, position, start_time, duration, distance, avg_heart_rate, max_heart_rate, calories) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *").
+					WithArgs("WORKOUT_ID", 2, start.Add(15*time.Minute), 930, 3050.0, 155, 160, 220).
+					WillReturnRows(sqlmock.NewRows(lapColumns).
+						AddRow("SECOND_LAP_ID", "WORKOUT_ID", 2, start.Add(15*time.Minute), 930, 3050.0, 155, 160, 220))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  fitType,
+				},
+				Body: fitBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         "WORKOUT_ID",
+					ActivityID: "ACTIVITY_ID",
+					Date:       "01-05-2024",
+					Duration:   31,
+					Kind:       "Running",
+					Laps: []responsebody.Lap{
+						{StartTime: "2024-05-01T06:00:00Z", Duration: 900, Distance: &firstLap.distance, AvgHeartRate: &firstLap.avgHeartRate, MaxHeartRate: &firstLap.maxHeartRate, Calories: &firstLap.calories},
+						{StartTime: "2024-05-01T06:15:00Z", Duration: 930, Distance: &secondLap.distance, AvgHeartRate: &secondLap.avgHeartRate, MaxHeartRate: &secondLap.maxHeartRate, Calories: &secondLap.calories},
+					},
+					Metrics: responsebody.Metrics{
+						Distance:      &fitDistance,
+						ElevationGain: &fitElevationGain,
+						Pace:          &fitPace,
+						Speed:         &fitSpeed,
+						AvgHeartRate:  &fitAvgHeartRate,
+						MaxHeartRate:  &maxHeartRate,
+						Calories:      &fitCalories,
+					},
+				},
+			},
+		},
+		{
+			Name: "kind overrides sport",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, "Parkour").
+					WillReturnRows(sqlmock.NewRows(catalogColumns))
+
+				expectCatalogName(mock, catalog.Activity, catalog.Fallback).
+					WillReturnRows(catalogRows("OTHER_ID", catalog.Activity, catalog.Fallback))
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs("USER_ID", "OTHER_ID", date, 30, "Parkour", sqlmock.AnyArg(), 10.0, avgHeartRate, maxHeartRate, nil, nil, start, "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}).
+						AddRow("WORKOUT_ID", "USER_ID", "OTHER_ID", date, 30, "Parkour", time.Now()))
+				mock.ExpectExec("INSERT INTO workout_tracks (workout_id, format, data) VALUES ($1, $2, $3)").
+					WithArgs("WORKOUT_ID", "gpx", []byte(importGPX)).
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  kindType,
+				},
+				Body: kindBody,
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusCreated,
+				BodyFields: []string{"id", "activity_id", "kind"},
+			},
+		},
+		{
+			Name: "unsupported format",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  csvType,
+				},
+				Body: csvBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "only gpx, tcx and fit files are available",
+				},
+			},
+		},
+		{
+			Name: "too big file",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  bigType,
+				},
+				Body: bigBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusRequestEntityTooLarge,
+				Body: responsebody.Message{
+					Message: "file should be smaller than 10Mb",
+				},
+			},
+		},
+		{
+			Name: "malformed file",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  malformedType,
+				},
+				Body: malformedBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "malformed tcx file",
+				},
+			},
+		},
+		{
+			Name: "no timestamped points",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+					"Content-Type":  emptyType,
+				},
+				Body: emptyBody,
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "track has no recorded points",
+				},
+			},
+		},
+		{
+			Name: "no file",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "no track file provided",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/workout/import", "/api/workout/import", handler.UserIdentity, handler.ImportWorkout)
+	}
+}
+
+func TestGetWorkoutTrack(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				mock.ExpectQuery("SELECT * FROM workout_tracks WHERE workout_id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"workout_id", "format", "data", "created_at"}).
+						AddRow("WORKOUT_ID", "gpx", []byte(importGPX), time.Now()))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status:  http.StatusOK,
+				RawBody: true,
+				Body:    importGPX,
+			},
+		},
+		{
+			Name: "created manually",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				mock.ExpectQuery("SELECT * FROM workout_tracks WHERE workout_id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"workout_id", "format", "data", "created_at"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "track not found",
+				},
+			},
+		},
+		{
+			Name: "another user's workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("ANOTHER_USER_ID", date))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "forbidden to view workout",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/workout/:id/track", "/api/workout/WORKOUT_ID/track", handler.UserIdentity, handler.GetWorkoutTrack)
+	}
+}
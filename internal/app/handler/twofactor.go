@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/random"
+	"api/pkg/requestid"
+	"api/pkg/sha256"
+	"api/pkg/totp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	totpIssuer         = "yodreik"
+	recoveryCodesCount = 10
+)
+
+// @Summary      Set up two-factor authentication
+// @Description  generates a new TOTP secret. Two-factor authentication is enabled after the first code is verified
+// @Security     AccessToken
+// @Tags         account
+// @Produce      json
+// @Success      200 {object}  responsebody.TwoFactorSetup
+// @Failure      401 {object}  responsebody.Message
+// @Failure      409 {object}  responsebody.Message
+// @Failure      429 {object}  responsebody.Message
+// @Router       /account/2fa  [post]
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.SetupTwoFactor"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	userID := c.GetString("UserID")
+	user, err := h.repository.User.GetByID(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid authorization token")
+		return
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if user.IsTOTPEnabled {
+		log.Debug("two-factor authentication already enabled")
+		response.WithMessage(c, http.StatusConflict, "two-factor authentication already enabled")
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("can't generate TOTP secret", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	err = h.repository.User.SetTOTPSecret(c, user.ID, secret)
+	if err != nil {
+		log.Error("can't save TOTP secret", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, responsebody.TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Username, secret),
+	})
+}
+
+// @Summary      Enable two-factor authentication
+// @Description  verifies the first code from authenticator app, enables two-factor authentication and returns single-use recovery codes
+// @Security     AccessToken
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        input body           requestbody.TwoFactorCode true "Code from authenticator app"
+// @Success      200 {object}         responsebody.RecoveryCodes
+// @Failure      400 {object}         responsebody.Message
+// @Failure      401 {object}         responsebody.Message
+// @Failure      409 {object}         responsebody.Message
+// @Failure      429 {object}         responsebody.Message
+// @Router       /account/2fa/verify  [post]
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.VerifyTwoFactor"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.TwoFactorCode
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	userID := c.GetString("UserID")
+	user, err := h.repository.User.GetByID(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid authorization token")
+		return
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if user.IsTOTPEnabled {
+		log.Debug("two-factor authentication already enabled")
+		response.WithMessage(c, http.StatusConflict, "two-factor authentication already enabled")
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		log.Debug("two-factor authentication setup not started")
+		response.WithMessage(c, http.StatusBadRequest, "two-factor authentication setup not started")
+		return
+	}
+
+	counter, ok := totp.Match(body.Code, user.TOTPSecret, time.Now())
+	if !ok {
+		log.Debug("invalid TOTP code")
+		response.WithMessage(c, http.StatusBadRequest, "invalid verification code")
+		return
+	}
+
+	// the code confirming the setup can't be used to sign in afterwards
+	err = h.repository.User.UseTOTPCounter(c, user.ID, int64(counter))
+	if errors.Is(err, repoerr.ErrTOTPCodeUsed) {
+		log.Debug("TOTP code already used")
+		response.WithMessage(c, http.StatusBadRequest, "invalid verification code")
+		return
+	}
+	if err != nil {
+		log.Error("can't use TOTP code", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code := random.StringWith(10, random.LatinLower|random.Numbers)
+		codes = append(codes, fmt.Sprintf("%s-%s", code[:5], code[5:]))
+		hashes = append(hashes, sha256.String(code))
+	}
+
+	err = h.repository.User.EnableTOTP(c, user.ID, hashes)
+	if err != nil {
+		log.Error("can't enable two-factor authentication", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("two-factor authentication enabled", slog.String("user_id", user.ID))
+
+	c.JSON(http.StatusOK, responsebody.RecoveryCodes{
+		RecoveryCodes: codes,
+	})
+}
+
+// @Summary      Disable two-factor authentication
+// @Description  disables two-factor authentication, requires a code from authenticator app or a recovery code
+// @Security     AccessToken
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        input body    requestbody.TwoFactorCode true "Code from authenticator app or recovery code"
+// @Success      200
+// @Failure      400 {object}  responsebody.Message
+// @Failure      401 {object}  responsebody.Message
+// @Failure      429 {object}  responsebody.Message
+// @Router       /account/2fa  [delete]
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.DisableTwoFactor"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.TwoFactorCode
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	userID := c.GetString("UserID")
+	user, err := h.repository.User.GetByID(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid authorization token")
+		return
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if !user.IsTOTPEnabled {
+		log.Debug("two-factor authentication is not enabled")
+		response.WithMessage(c, http.StatusBadRequest, "two-factor authentication is not enabled")
+		return
+	}
+
+	if h.isLocked(c, log, user) {
+		return
+	}
+
+	ok, err := h.verifySecondFactor(c, user, body.Code)
+	if err != nil {
+		log.Error("can't verify second factor", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+	if !ok {
+		log.Debug("invalid second factor code")
+		h.registerFailedLogin(c, log, user)
+		response.WithMessage(c, http.StatusBadRequest, "invalid verification code")
+		return
+	}
+
+	h.resetFailedLogins(c, log, user)
+
+	err = h.repository.User.DisableTOTP(c, user.ID)
+	if err != nil {
+		log.Error("can't disable two-factor authentication", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	log.Info("two-factor authentication disabled", slog.String("user_id", user.ID))
+
+	c.Status(http.StatusOK)
+}
+
+// verifySecondFactor checks given code as a TOTP code first, and then as a
+// recovery code. A TOTP code is accepted once and a matched recovery code is
+// burned
+func (h *Handler) verifySecondFactor(c *gin.Context, user *entity.User, code string) (bool, error) {
+	if counter, ok := totp.Match(code, user.TOTPSecret, time.Now()); ok {
+		err := h.repository.User.UseTOTPCounter(c, user.ID, int64(counter))
+		if errors.Is(err, repoerr.ErrTOTPCodeUsed) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	err := h.repository.User.UseRecoveryCode(c, user.ID, sha256.String(normalized))
+	if errors.Is(err, repoerr.ErrRecoveryCodeNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
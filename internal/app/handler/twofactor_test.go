@@ -0,0 +1,570 @@
+package handler
+
+import (
+	"api/internal/app/handler/request/requestbody"
+	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/test"
+	"api/internal/config"
+	mockmailer "api/internal/mailer/mock"
+	"api/internal/repository"
+	"api/internal/token"
+	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
+	"api/pkg/totp"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestSetupTwoFactor(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows("", false))
+
+				mock.ExpectExec("UPDATE users SET totp_secret = $1 WHERE id = $2 AND is_totp_enabled = false").
+					WithArgs(sqlmock.AnyArg(), "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"secret", "uri"},
+			},
+		},
+		{
+			Name: "already enabled",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows("JBSWY3DPEHPK3PXP", true))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusConflict,
+				Body: responsebody.Message{
+					Message: "two-factor authentication already enabled",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows("", false))
+
+				mock.ExpectExec("UPDATE users SET totp_secret = $1 WHERE id = $2 AND is_totp_enabled = false").
+					WithArgs(sqlmock.AnyArg(), "USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/account/2fa", "/api/account/2fa", handler.UserIdentity, handler.SetupTwoFactor)
+	}
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	secret := "JBSWY3DPEHPK3PXP"
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error while generating TOTP code: %v\n", err)
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, false))
+
+				expectTOTPCounter(mock, 1)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET is_totp_enabled = true WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectExec("DELETE FROM recovery_codes WHERE user_id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(0))
+				mock.ExpectExec("INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])").
+					WithArgs("USER_ID", sqlmock.AnyArg()).
+					WillReturnResult(driver.RowsAffected(10))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status:     http.StatusOK,
+				BodyFields: []string{"recovery_codes"},
+			},
+		},
+		{
+			Name: "invalid request body",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]string{
+					"invalid": "body",
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "setup not started",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows("", false))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "two-factor authentication setup not started",
+				},
+			},
+		},
+		{
+			Name: "invalid code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, false))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: "abcdef",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid verification code",
+				},
+			},
+		},
+		{
+			Name: "reused code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, false))
+
+				expectTOTPCounter(mock, 0)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid verification code",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, false))
+
+				expectTOTPCounter(mock, 1)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET is_totp_enabled = true WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+				mock.ExpectRollback()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: code,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/account/2fa/verify", "/api/account/2fa/verify", handler.UserIdentity, handler.VerifyTwoFactor)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	secret := "JBSWY3DPEHPK3PXP"
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error while generating TOTP code: %v\n", err)
+	}
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				expectTOTPCounter(mock, 1)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET totp_secret = '', is_totp_enabled = false WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+				mock.ExpectExec("DELETE FROM recovery_codes WHERE user_id = $1").
+					WithArgs("USER_ID").
+					WillReturnResult(driver.RowsAffected(10))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "not enabled",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows("", false))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: code,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "two-factor authentication is not enabled",
+				},
+			},
+		},
+		{
+			Name: "invalid code",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(twoFactorUserRows(secret, true))
+
+				mock.ExpectExec("UPDATE recovery_codes SET is_used = true WHERE user_id = $1 AND code_hash = $2 AND is_used = false").
+					WithArgs("USER_ID", sqlmock.AnyArg()).
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: "not-a-code",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid verification code",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/account/2fa", "/api/account/2fa", handler.UserIdentity, handler.DisableTwoFactor)
+	}
+}
+
+func TestDisableTwoFactorLockout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{
+		Token:   config.Token{Secret: tokenSecret},
+		Lockout: config.Lockout{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour},
+	}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	secret := "JBSWY3DPEHPK3PXP"
+
+	userRows := func(failedLoginAttempts int, lockedUntil *time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at", "totp_secret", "is_totp_enabled", "failed_login_attempts", "locked_until"}).
+			AddRow("USER_ID", "john.doe@example.com", "johndoe", "John Doe", "", "", false, true, "CONFIRMATION_TOKEN", time.Now(), secret, true, failedLoginAttempts, lockedUntil)
+	}
+
+	lockedUntil := time.Now().Add(time.Minute)
+
+	tests := []test.Case{
+		{
+			Name: "wrong code: account locked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(userRows(2, nil))
+
+				mock.ExpectExec("UPDATE recovery_codes SET is_used = true WHERE user_id = $1 AND code_hash = $2 AND is_used = false").
+					WithArgs("USER_ID", sqlmock.AnyArg()).
+					WillReturnResult(driver.RowsAffected(0))
+
+				mock.ExpectQuery("UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE id = $1 RETURNING failed_login_attempts").
+					WithArgs("USER_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts"}).AddRow(3))
+
+				mock.ExpectExec("UPDATE users SET locked_until = $1 WHERE id = $2").
+					WithArgs(sqlmock.AnyArg(), "USER_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: "000000",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid verification code",
+				},
+			},
+		},
+		{
+			Name: "locked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+					WithArgs("USER_ID").
+					WillReturnRows(userRows(3, &lockedUntil))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.TwoFactorCode{
+					Code: "000000",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusTooManyRequests,
+				Body: responsebody.Message{
+					Message: "account temporarily locked",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/account/2fa", "/api/account/2fa", handler.UserIdentity, handler.DisableTwoFactor)
+	}
+}
+
+func expectTOTPCounter(mock sqlmock.Sqlmock, rowsAffected int64) {
+	mock.ExpectExec("UPDATE users SET totp_last_counter = $1 WHERE id = $2 AND totp_last_counter < $1").
+		WithArgs(sqlmock.AnyArg(), "USER_ID").
+		WillReturnResult(driver.RowsAffected(rowsAffected))
+}
+
+func twoFactorUserRows(secret string, enabled bool) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at", "totp_secret", "is_totp_enabled"}).
+		AddRow("USER_ID", "john.doe@example.com", "johndoe", "John Doe", "", "", false, true, "CONFIRMATION_TOKEN", time.Now(), secret, enabled)
+}
@@ -4,23 +4,25 @@ import (
 	"api/internal/app/handler/response"
 	"api/internal/app/handler/response/responsebody"
 	"api/internal/lib/logger/sl"
-	repoerr "api/internal/repository/errors"
+	"api/internal/repository/entity"
 	"api/pkg/requestid"
-	"errors"
-	"fmt"
+	"api/pkg/units"
 	"log/slog"
+	"math"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary      Get public information about user by username
-// @Description  returns an user's information and week activity history
+// @Description  returns an user's information, follow counts and week activity history. A private user's information and activity are shown to the user and the followers only, others see the username and the counts. Week activity includes only workouts, whose visibility allows current user to see them. Users, that block each other, don't see each other's profiles
 // @Tags         user
 // @Produce      json
 // @Param        username          path string true "Username"
 // @Success      200 {object}      responsebody.Profile
+// @Failure      401 {object}      responsebody.Message
 // @Failure      404 {object}      responsebody.Message
 // @Router       /user/{username}  [get]
 func (h *Handler) GetUserByUsername(c *gin.Context) {
@@ -29,30 +31,43 @@ func (h *Handler) GetUserByUsername(c *gin.Context) {
 		slog.String("request_id", requestid.Get(c)),
 	)
 
-	username := c.Param("username")
+	user, ok := h.userByUsername(c, log)
+	if !ok {
+		return
+	}
+
+	if !h.unblocked(c, log, user.ID, "user not found") {
+		return
+	}
 
-	user, err := h.repository.User.GetByUsername(c, username)
-	if errors.Is(err, repoerr.ErrUserNotFound) {
-		log.Debug("user not found")
-		response.WithMessage(c, http.StatusNotFound, "user not found")
+	status, ok := h.followStatus(c, log, user)
+	if !ok {
 		return
 	}
+
+	counts, err := h.repository.Follow.Counts(c, user.ID)
 	if err != nil {
-		log.Error("could not get user by username", sl.Err(err), slog.String("username", username))
+		log.Error("could not get follow counts", sl.Err(err))
 		response.InternalServerError(c)
 		return
 	}
 
-	if user.IsPrivate {
+	if !canView(c, user, status) {
 		c.JSON(http.StatusOK, responsebody.Profile{
-			ID:        user.ID,
-			Username:  user.Username,
-			IsPrivate: user.IsPrivate,
+			ID:             user.ID,
+			Username:       user.Username,
+			IsPrivate:      user.IsPrivate,
+			FollowersCount: counts.Followers,
+			FollowingCount: counts.Following,
+			FollowStatus:   status,
 		})
 		return
 	}
 
-	workouts, err := h.repository.Workout.GetUserWorkouts(c, user.ID, time.Now().Add(-6*24*time.Hour).Truncate(time.Hour), time.Now().Add(time.Hour).Truncate(time.Hour))
+	// the week ends today in user's time zone
+	today := dateOf(time.Now().In(userLocation(log, user)))
+
+	workouts, err := h.repository.Workout.GetUserWorkouts(c, user.ID, today.AddDate(0, 0, -6), today)
 	if err != nil {
 		log.Error("could not get workouts", sl.Err(err))
 		response.InternalServerError(c)
@@ -61,10 +76,11 @@ func (h *Handler) GetUserByUsername(c *gin.Context) {
 
 	activity := make([]responsebody.Workout, 0)
 
-	fmt.Println("workouts ", workouts)
-	fmt.Println("activity ", activity)
-
 	for _, workout := range workouts {
+		if !canViewWorkout(c, user, status, &workout) {
+			continue
+		}
+
 		activity = append(activity, responsebody.Workout{
 			ID:       workout.ID,
 			Date:     workout.Date.Format("02-01-2006"),
@@ -74,21 +90,29 @@ func (h *Handler) GetUserByUsername(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, responsebody.Profile{
-		ID:           user.ID,
-		Username:     user.Username,
-		DisplayName:  user.DisplayName,
-		AvatarURL:    user.AvatarURL,
-		IsPrivate:    user.IsPrivate,
-		WeekActivity: activity,
+		ID:             user.ID,
+		Username:       user.Username,
+		DisplayName:    user.DisplayName,
+		AvatarURL:      user.AvatarURL,
+		IsPrivate:      user.IsPrivate,
+		FollowersCount: counts.Followers,
+		FollowingCount: counts.Following,
+		FollowStatus:   status,
+		WeekActivity:   activity,
 	})
 }
 
 // @Summary      Get user's statistics
-// @Description  returns user's all-time statistics
+// @Description  returns statistics of user's workouts in a date range: totals, averages, streaks, aggregates by periods and by workout kind and a heatmap of the last year of the range. Weeks start on Monday. Today, that is the default end of the range and the last day of the current streak, is taken in user's time zone or in `tz`. Distance is in kilometers or miles and pace is in seconds per kilometer or mile, depending on user's units
 // @Security     AccessToken
 // @Tags         activity
 // @Produce      json
+// @Param        begin    query string false "Begin date"
+// @Param        end      query string false "End date, today by default"
+// @Param        group_by query string false "Period of aggregates: day, week, month or year, month by default"
+// @Param        tz       query string false "IANA time zone, e.g. Europe/Berlin, user's one by default"
 // @Success      200 {object}  responsebody.Statistics
+// @Failure      400 {object}  responsebody.Message
 // @Failure      401 {object}  responsebody.Message
 // @Router       /statistics   [get]
 func (h *Handler) GetStatistics(c *gin.Context) {
@@ -97,26 +121,164 @@ func (h *Handler) GetStatistics(c *gin.Context) {
 		slog.String("request_id", requestid.Get(c)),
 	)
 
+	system, location, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	if name := c.Query("tz"); name != "" {
+		if !validTimeZone(name) {
+			log.Debug("unknown time zone", slog.String("tz", name))
+			response.WithMessage(c, http.StatusBadRequest, "unknown time zone")
+			return
+		}
+
+		location, _ = time.LoadLocation(name)
+	}
+
+	today := dateOf(time.Now().In(location))
+
+	begin, end, ok := parseDateRange(c, log, today)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", "month")
+	if !slices.Contains(statisticsPeriods, groupBy) {
+		log.Debug("unknown period", slog.String("group_by", groupBy))
+		response.WithMessage(c, http.StatusBadRequest, "group_by should be one of day, week, month or year")
+		return
+	}
+
 	userID := c.GetString("UserID")
-	workouts, err := h.repository.Workout.GetAllUserWorkouts(c, userID)
+
+	summary, err := h.repository.Statistics.Summary(c, userID, begin, end)
 	if err != nil {
-		log.Error("could not get workouts", sl.Err(err))
+		log.Error("can't get summary", sl.Err(err))
 		response.InternalServerError(c)
 		return
 	}
 
-	minutesSpent := 0
-	longestActivity := 0
-	for _, workout := range workouts {
-		minutesSpent += workout.Duration
-		if workout.Duration > longestActivity {
-			longestActivity = workout.Duration
-		}
+	kinds, err := h.repository.Statistics.Kinds(c, userID, begin, end)
+	if err != nil {
+		log.Error("can't get kinds", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	periods, err := h.repository.Statistics.Periods(c, userID, begin, end, groupBy)
+	if err != nil {
+		log.Error("can't get periods", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	heatmapBegin := end.AddDate(0, 0, -heatmapDays+1)
+	if heatmapBegin.Before(begin) {
+		heatmapBegin = begin
 	}
 
-	c.JSON(http.StatusOK, responsebody.Statistics{
+	days, err := h.repository.Statistics.Periods(c, userID, heatmapBegin, end, "day")
+	if err != nil {
+		log.Error("can't get days", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	streaks, err := h.repository.Statistics.Streaks(c, userID, today)
+	if err != nil {
+		log.Error("can't get streaks", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	// averages per week count from the first workout, unless the range
+	// begins later
+	averagesBegin := begin
+	if summary.FirstDate != nil && summary.FirstDate.After(begin) {
+		averagesBegin = *summary.FirstDate
+	}
+
+	res := responsebody.Statistics{
 		UserID:          userID,
-		MinutesSpent:    minutesSpent,
-		LongestActivity: longestActivity,
-	})
+		Begin:           begin.Format(workoutDateLayout),
+		End:             end.Format(workoutDateLayout),
+		Count:           summary.Count,
+		ActiveDays:      summary.ActiveDays,
+		MinutesSpent:    summary.Minutes,
+		LongestActivity: summary.Longest,
+		TotalDistance:   units.Round(units.DistanceFromMeters(summary.Distance, system), 2),
+		Units:           system,
+		Streaks: responsebody.Streaks{
+			Current: streaks.Current,
+			Longest: streaks.Longest,
+		},
+		GroupBy: groupBy,
+		Periods: make([]responsebody.PeriodStatistics, 0, len(periods)),
+		Kinds:   make([]responsebody.KindStatistics, 0, len(kinds)),
+		Heatmap: heatmap(days, heatmapBegin, end),
+	}
+
+	if summary.Count > 0 {
+		weeks := max(end.Sub(averagesBegin).Hours()/24+1, 7) / 7
+
+		res.Averages.Duration = units.Round(float64(summary.Minutes)/float64(summary.Count), 1)
+		res.Averages.WorkoutsPerWeek = units.Round(float64(summary.Count)/weeks, 2)
+	}
+	if summary.DistanceCount > 0 {
+		res.Averages.Distance = units.Round(units.DistanceFromMeters(summary.Distance/float64(summary.DistanceCount), system), 2)
+	}
+
+	for _, period := range periods {
+		res.Periods = append(res.Periods, responsebody.PeriodStatistics{
+			Start:         period.Start.Format(workoutDateLayout),
+			Count:         period.Count,
+			MinutesSpent:  period.Minutes,
+			TotalDistance: units.Round(units.DistanceFromMeters(period.Distance, system), 2),
+		})
+	}
+
+	for _, kind := range kinds {
+		s := responsebody.KindStatistics{
+			Kind:          kind.Kind,
+			Count:         kind.Count,
+			MinutesSpent:  kind.Minutes,
+			AvgDuration:   units.Round(float64(kind.Minutes)/float64(kind.Count), 1),
+			TotalDistance: units.Round(units.DistanceFromMeters(kind.Distance, system), 2),
+		}
+
+		if kind.BestPace != nil {
+			pace := int(math.Round(*kind.BestPace / units.DistanceFromMeters(1, system)))
+			s.BestPace = &pace
+		}
+
+		res.Kinds = append(res.Kinds, s)
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+const heatmapDays = 365
+
+var statisticsPeriods = []string{"day", "week", "month", "year"}
+
+// heatmap lists every day of a range, including days without workouts
+func heatmap(days []entity.PeriodSummary, begin time.Time, end time.Time) []responsebody.HeatmapDay {
+	byDate := make(map[string]entity.PeriodSummary, len(days))
+	for _, day := range days {
+		byDate[day.Start.Format(time.DateOnly)] = day
+	}
+
+	res := make([]responsebody.HeatmapDay, 0)
+	for date := begin; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day := byDate[date.Format(time.DateOnly)]
+
+		res = append(res, responsebody.HeatmapDay{
+			Date:         date.Format(workoutDateLayout),
+			Count:        day.Count,
+			MinutesSpent: day.Minutes,
+		})
+	}
+
+	return res
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"api/internal/app/handler/response/responsebody"
 	"api/internal/app/handler/test"
+	"api/internal/app/handler/visibility"
 	"api/internal/config"
 	mockmailer "api/internal/mailer/mock"
 	"api/internal/repository"
@@ -10,7 +11,9 @@ import (
 	repoerr "api/internal/repository/errors"
 	"api/internal/token"
 	mocktoken "api/internal/token/mock"
+	"api/pkg/password"
 	"api/pkg/sha256"
+	"api/pkg/units"
 	"errors"
 	"fmt"
 	"net/http"
@@ -21,6 +24,13 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+const (
+	summaryQuery = "SELECT count(*) AS count, COALESCE(sum(duration), 0) AS minutes, COALESCE(max(duration), 0) AS longest, COALESCE(sum(distance), 0) AS distance, count(distance) AS distance_count, count(DISTINCT date) AS active_days, min(date) AS first_date FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3"
+	kindsQuery   = "SELECT kind, count(*) AS count, sum(duration) AS minutes, COALESCE(sum(distance), 0) AS distance, min(duration * 60 / distance) FILTER (WHERE distance > 0) AS best_pace FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 GROUP BY kind ORDER BY kind"
+	periodsQuery = "SELECT date_trunc($4, date::timestamp)::date AS start, count(*) AS count, sum(duration) AS minutes, COALESCE(sum(distance), 0) AS distance FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 GROUP BY 1 ORDER BY 1"
+	streaksQuery = "WITH days AS (SELECT DISTINCT date FROM workouts WHERE user_id = $1 AND date <= $2), runs AS (SELECT max(date) AS last, count(*) AS length FROM (SELECT date, date - (row_number() OVER (ORDER BY date))::int AS run FROM days) AS numbered GROUP BY run) SELECT COALESCE(max(length) FILTER (WHERE last >= $2::date - 1), 0) AS current, COALESCE(max(length), 0) AS longest FROM runs"
+)
+
 func TestGetStatistics(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
@@ -31,117 +41,263 @@ func TestGetStatistics(t *testing.T) {
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
 	tokenManager := token.New(c.Token)
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
-	user := entity.User{
-		ID:                "USER_ID",
-		Email:             "john.doe@example.com",
-		Username:          "johndoe",
-		DisplayName:       "John Doe",
-		AvatarURL:         "https://cdn.domain.com/avatar.jpeg",
-		PasswordHash:      sha256.String("testword"),
-		IsPrivate:         false,
-		IsConfirmed:       true,
-		ConfirmationToken: "CONFIRMATION_TOKEN",
-		CreatedAt:         time.Now(),
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
 	}
 
-	workouts := []entity.Workout{
-		{
-			ID:        "WORKOUT_ID_1",
-			UserID:    "USER_ID",
-			Date:      time.Now(),
-			Duration:  69,
-			Kind:      "GYM",
-			CreatedAt: time.Now(),
-		},
-		{
-			ID:        "WORKOUT_ID_2",
-			UserID:    "USER_ID",
-			Date:      time.Now(),
-			Duration:  121,
-			Kind:      "Pool",
-			CreatedAt: time.Now(),
-		},
-		{
-			ID:        "WORKOUT_ID_3",
-			UserID:    "USER_ID",
-			Date:      time.Now(),
-			Duration:  21,
-			Kind:      "Calisthenics",
-			CreatedAt: time.Now(),
-		},
-	}
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
 
-	var minutesSpent int
-	var longestActivity int
-	for _, workout := range workouts {
-		minutesSpent += workout.Duration
+	begin := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.May, 7, 0, 0, 0, 0, time.UTC)
 
-		if workout.Duration > longestActivity {
-			longestActivity = workout.Duration
+	summaryColumns := []string{"count", "minutes", "longest", "distance", "distance_count", "active_days", "first_date"}
+	kindColumns := []string{"kind", "count", "minutes", "distance", "best_pace"}
+	periodColumns := []string{"start", "count", "minutes", "distance"}
+
+	// days lists every day of the range with given workouts on some of them
+	days := func(workouts map[int]responsebody.HeatmapDay) []responsebody.HeatmapDay {
+		res := make([]responsebody.HeatmapDay, 0)
+		for i := 0; i < 7; i++ {
+			day := workouts[i]
+			day.Date = begin.AddDate(0, 0, i).Format("02-01-2006")
+			res = append(res, day)
 		}
+		return res
 	}
 
-	accessToken, err := tokenManager.GenerateJWT(user.ID)
-	if err != nil {
-		t.Fatal("unexpected error while generating mock token")
-	}
+	// 3 miles in 24 minutes
+	bestPace := 480
 
-	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+	tests := []struct {
+		query string
+		tc    test.Case
+	}{
+		{
+			query: "&group_by=week&tz=Europe/Berlin",
+			tc: test.Case{
+				Name: "ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(summaryQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(summaryColumns).AddRow(3, 211, 121, 0.0, 0, 2, begin.AddDate(0, 0, 1)))
+
+					mock.ExpectQuery(kindsQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(kindColumns).
+							AddRow("Calisthenics", 1, 21, 0.0, nil).
+							AddRow("GYM", 1, 69, 0.0, nil).
+							AddRow("Pool", 1, 121, 0.0, nil))
+
+					mock.ExpectQuery(periodsQuery).
+						WithArgs("USER_ID", begin, end, "week").
+						WillReturnRows(sqlmock.NewRows(periodColumns).AddRow(begin.AddDate(0, 0, -2), 3, 211, 0.0))
+
+					mock.ExpectQuery(periodsQuery).
+						WithArgs("USER_ID", begin, end, "day").
+						WillReturnRows(sqlmock.NewRows(periodColumns).
+							AddRow(begin.AddDate(0, 0, 1), 2, 190, 0.0).
+							AddRow(begin.AddDate(0, 0, 3), 1, 21, 0.0))
+
+					mock.ExpectQuery(streaksQuery).
+						WithArgs("USER_ID", sqlmock.AnyArg()).
+						WillReturnRows(sqlmock.NewRows([]string{"current", "longest"}).AddRow(1, 3))
+				},
 
-	tests := []test.Case{
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.Statistics{
+						UserID:          "USER_ID",
+						Begin:           "01-05-2024",
+						End:             "07-05-2024",
+						Count:           3,
+						ActiveDays:      2,
+						MinutesSpent:    211,
+						LongestActivity: 121,
+						Units:           units.Metric,
+						Averages: responsebody.Averages{
+							Duration:        70.3,
+							WorkoutsPerWeek: 3,
+						},
+						Streaks: responsebody.Streaks{Current: 1, Longest: 3},
+						GroupBy: "week",
+						Periods: []responsebody.PeriodStatistics{
+							{Start: "29-04-2024", Count: 3, MinutesSpent: 211},
+						},
+						Kinds: []responsebody.KindStatistics{
+							{Kind: "Calisthenics", Count: 1, MinutesSpent: 21, AvgDuration: 21},
+							{Kind: "GYM", Count: 1, MinutesSpent: 69, AvgDuration: 69},
+							{Kind: "Pool", Count: 1, MinutesSpent: 121, AvgDuration: 121},
+						},
+						Heatmap: days(map[int]responsebody.HeatmapDay{
+							1: {Count: 2, MinutesSpent: 190},
+							3: {Count: 1, MinutesSpent: 21},
+						}),
+					},
+				},
+			},
+		},
 		{
-			Name: "ok",
+			tc: test.Case{
+				Name: "distance in miles",
 
-			Repo: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "user_id", "date", "duration", "kind", "created_at"})
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
 
-				for _, workout := range workouts {
-					rows.AddRow(workout.ID, workout.UserID, workout.Date, workout.Duration, workout.Kind, workout.CreatedAt)
-				}
+					expectUnits(mock, units.Imperial)
 
-				mock.ExpectQuery("SELECT * FROM workouts WHERE user_id = $1 ORDER BY date ASC").
-					WithArgs(user.ID).
-					WillReturnRows(rows)
-			},
+					mock.ExpectQuery(summaryQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(summaryColumns).AddRow(3, 94, 40, 12874.752, 2, 1, begin))
 
-			Request: test.Request{
-				Headers: map[string]string{
-					"Authorization": headerAuthorization,
+					mock.ExpectQuery(kindsQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnRows(sqlmock.NewRows(kindColumns).
+							AddRow("Running", 2, 64, 12874.752, 2400/8046.72).
+							AddRow("Yoga", 1, 30, 0.0, nil))
+
+					mock.ExpectQuery(periodsQuery).
+						WithArgs("USER_ID", begin, end, "month").
+						WillReturnRows(sqlmock.NewRows(periodColumns).AddRow(begin, 3, 94, 12874.752))
+
+					mock.ExpectQuery(periodsQuery).
+						WithArgs("USER_ID", begin, end, "day").
+						WillReturnRows(sqlmock.NewRows(periodColumns).AddRow(begin, 3, 94, 12874.752))
+
+					mock.ExpectQuery(streaksQuery).
+						WithArgs("USER_ID", sqlmock.AnyArg()).
+						WillReturnRows(sqlmock.NewRows([]string{"current", "longest"}).AddRow(0, 1))
 				},
-			},
 
-			Expect: test.Expect{
-				Status: http.StatusOK,
-				Body: responsebody.Statistics{
-					UserID:          user.ID,
-					MinutesSpent:    minutesSpent,
-					LongestActivity: longestActivity,
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.Statistics{
+						UserID:          "USER_ID",
+						Begin:           "01-05-2024",
+						End:             "07-05-2024",
+						Count:           3,
+						ActiveDays:      1,
+						MinutesSpent:    94,
+						LongestActivity: 40,
+						TotalDistance:   8,
+						Units:           units.Imperial,
+						Averages: responsebody.Averages{
+							Duration:        31.3,
+							Distance:        4,
+							WorkoutsPerWeek: 3,
+						},
+						Streaks: responsebody.Streaks{Current: 0, Longest: 1},
+						GroupBy: "month",
+						Periods: []responsebody.PeriodStatistics{
+							{Start: "01-05-2024", Count: 3, MinutesSpent: 94, TotalDistance: 8},
+						},
+						Kinds: []responsebody.KindStatistics{
+							{Kind: "Running", Count: 2, MinutesSpent: 64, AvgDuration: 32, TotalDistance: 8, BestPace: &bestPace},
+							{Kind: "Yoga", Count: 1, MinutesSpent: 30, AvgDuration: 30},
+						},
+						Heatmap: days(map[int]responsebody.HeatmapDay{
+							0: {Count: 3, MinutesSpent: 94},
+						}),
+					},
 				},
 			},
 		},
 		{
-			Name: "repository error",
+			query: "&tz=Mars/Olympus",
+			tc: test.Case{
+				Name: "unknown time zone",
 
-			Repo: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT * FROM workouts WHERE user_id = $1 ORDER BY date ASC").
-					WithArgs(user.ID).
-					WillReturnError(errors.New("repo: Some repository error"))
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "unknown time zone",
+					},
+				},
 			},
+		},
+		{
+			query: "&group_by=quarter",
+			tc: test.Case{
+				Name: "unknown period",
 
-			Request: test.Request{
-				Headers: map[string]string{
-					"Authorization": headerAuthorization,
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "group_by should be one of day, week, month or year",
+					},
 				},
 			},
+		},
+		{
+			tc: test.Case{
+				Name: "repository error",
 
-			Expect: test.ResponseInternalServerError,
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(summaryQuery).
+						WithArgs("USER_ID", begin, end).
+						WillReturnError(errors.New("repo: Some repository error"))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.ResponseInternalServerError,
+			},
 		},
 	}
 
-	for _, tc := range tests {
-		test.Endpoint(t, tc, mock, http.MethodGet, "/api/statistics", "/api/statistics", handler.UserIdentity, handler.GetStatistics)
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/statistics", "/api/statistics?begin=01-05-2024&end=07-05-2024"+tt.query, handler.UserIdentity, handler.GetStatistics)
 	}
 }
 
@@ -151,9 +307,18 @@ func TestGetByUsername(t *testing.T) {
 		t.Fatalf("err not expected: %v\n", err)
 	}
 
-	c := config.Empty()
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
-	handler := New(c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("VIEWER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
 
 	publicUser := entity.User{
 		ID:                "USER_ID",
@@ -171,25 +336,153 @@ func TestGetByUsername(t *testing.T) {
 	privateUser := publicUser
 	privateUser.IsPrivate = true
 
+	userRows := func(user entity.User) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
+			AddRow(user.ID, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.CreatedAt)
+	}
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	// a workout for every visibility, their ids are the visibilities
+	weekRows := func(userID string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "date", "duration", "kind", "visibility"})
+		for _, v := range []string{visibility.Public, visibility.Followers, visibility.OnlyMe} {
+			rows.AddRow(v, userID, date, 69, "Calisthenics", v)
+		}
+
+		return rows
+	}
+
 	tests := []test.Case{
+		{
+			Name: "public: ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").
+					WithArgs(publicUser.Username).
+					WillReturnRows(userRows(publicUser))
+
+				expectFollowCounts(mock, publicUser.ID, 2, 1)
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC").
+					WithArgs(publicUser.ID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(weekRows(publicUser.ID))
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Profile{
+					ID:             publicUser.ID,
+					Username:       publicUser.Username,
+					DisplayName:    publicUser.DisplayName,
+					AvatarURL:      publicUser.AvatarURL,
+					FollowersCount: 2,
+					FollowingCount: 1,
+					WeekActivity: []responsebody.Workout{
+						{ID: visibility.Public, Date: "01-05-2024", Duration: 69, Kind: "Calisthenics"},
+					},
+				},
+			},
+		},
 		{
 			Name: "private: ok",
 
 			Repo: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "email", "username", "display_name", "avatar_url", "password_hash", "is_private", "is_confirmed", "confirmation_token", "created_at"}).
-					AddRow(privateUser.ID, privateUser.Email, privateUser.Username, privateUser.DisplayName, privateUser.AvatarURL, privateUser.PasswordHash, privateUser.IsPrivate, privateUser.IsConfirmed, privateUser.ConfirmationToken, privateUser.CreatedAt)
+				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").
+					WithArgs(privateUser.Username).
+					WillReturnRows(userRows(privateUser))
+
+				expectFollowCounts(mock, privateUser.ID, 2, 1)
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Profile{
+					ID:             privateUser.ID,
+					Username:       privateUser.Username,
+					IsPrivate:      privateUser.IsPrivate,
+					FollowersCount: 2,
+					FollowingCount: 1,
+				},
+			},
+		},
+		{
+			Name: "private: requested",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
 
 				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").
 					WithArgs(privateUser.Username).
-					WillReturnRows(rows)
+					WillReturnRows(userRows(privateUser))
+
+				expectBlocked(mock, "VIEWER_ID", privateUser.ID, false)
+
+				expectFollow(mock, "VIEWER_ID", privateUser.ID, false)
+
+				expectFollowCounts(mock, privateUser.ID, 2, 1)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
 			},
 
 			Expect: test.Expect{
 				Status: http.StatusOK,
 				Body: responsebody.Profile{
-					ID:        privateUser.ID,
-					Username:  privateUser.Username,
-					IsPrivate: privateUser.IsPrivate,
+					ID:             privateUser.ID,
+					Username:       privateUser.Username,
+					IsPrivate:      privateUser.IsPrivate,
+					FollowersCount: 2,
+					FollowingCount: 1,
+					FollowStatus:   "pending",
+				},
+			},
+		},
+		{
+			Name: "private: follower",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").
+					WithArgs(privateUser.Username).
+					WillReturnRows(userRows(privateUser))
+
+				expectBlocked(mock, "VIEWER_ID", privateUser.ID, false)
+
+				expectFollow(mock, "VIEWER_ID", privateUser.ID, true)
+
+				expectFollowCounts(mock, privateUser.ID, 2, 1)
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC").
+					WithArgs(privateUser.ID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(weekRows(privateUser.ID))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Profile{
+					ID:             privateUser.ID,
+					Username:       privateUser.Username,
+					DisplayName:    privateUser.DisplayName,
+					AvatarURL:      privateUser.AvatarURL,
+					IsPrivate:      privateUser.IsPrivate,
+					FollowersCount: 2,
+					FollowingCount: 1,
+					FollowStatus:   "accepted",
+					WeekActivity: []responsebody.Workout{
+						{ID: visibility.Public, Date: "01-05-2024", Duration: 69, Kind: "Calisthenics"},
+						{ID: visibility.Followers, Date: "01-05-2024", Duration: 69, Kind: "Calisthenics"},
+					},
 				},
 			},
 		},
@@ -220,9 +513,35 @@ func TestGetByUsername(t *testing.T) {
 
 			Expect: test.ResponseInternalServerError,
 		},
+		{
+			Name: "blocked",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM users WHERE username = $1").
+					WithArgs(publicUser.Username).
+					WillReturnRows(userRows(publicUser))
+
+				expectBlocked(mock, "VIEWER_ID", publicUser.ID, true)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "user not found",
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
-		test.Endpoint(t, tc, mock, http.MethodGet, "/api/user/:username", fmt.Sprintf("/api/user/%s", publicUser.Username), handler.GetUserByUsername)
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/user/:username", fmt.Sprintf("/api/user/%s", publicUser.Username), handler.OptionalUserIdentity, handler.GetUserByUsername)
 	}
 }
@@ -0,0 +1,23 @@
+package visibility
+
+// A workout is visible to everyone, to approved followers of its owner or
+// only to the owner. A private profile hides public workouts from those,
+// who don't follow the owner, too
+const (
+	Public    = "public"
+	Followers = "followers"
+	OnlyMe    = "only_me"
+)
+
+// Allows reports whether a workout with given visibility is shown to a user
+// other than its owner
+func Allows(visibility string, isFollower bool) bool {
+	switch visibility {
+	case Public:
+		return true
+	case Followers:
+		return isFollower
+	default:
+		return false
+	}
+}
@@ -1,21 +1,46 @@
 package handler
 
 import (
+	"api/internal/app/handler/catalog"
 	"api/internal/app/handler/request/requestbody"
 	"api/internal/app/handler/response"
 	"api/internal/app/handler/response/responsebody"
+	"api/internal/app/handler/visibility"
 	"api/internal/lib/logger/sl"
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"api/pkg/cursor"
 	"api/pkg/requestid"
+	"api/pkg/units"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	workoutDateLayout = "02-01-2006"
+
+	// maxUTCOffset is an offset of the easternmost time zone, where a new
+	// day begins first
+	maxUTCOffset = 14 * time.Hour
+
+	// maxClockSkew is how much clocks of clients may be ahead
+	maxClockSkew = 5 * time.Minute
+
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
 )
 
 // @Summary      Create a record about past workout
-// @Description  creates a new record about workout session
+// @Description  creates a new record about workout session, optionally with exercises and their sets. Date is in DD-MM-YYYY or YYYY-MM-DD format and can't be in the future. Start time is in RFC 3339 format with an offset, date may be omitted with it, as it's taken from the start time. Duration is in minutes and can't exceed a day. Activity is taken from the catalog by `activity_id` or by `kind`, unknown kinds are recorded as "Other". Visibility is public, followers or only_me, the user's default one by default
 // @Security     AccessToken
 // @Tags         activity
 // @Accept       json
@@ -37,18 +62,33 @@ func (h *Handler) CreateWorkout(c *gin.Context) {
 		return
 	}
 
-	layout := "02-01-2006"
-	date, err := time.Parse(layout, body.Date)
-	if err != nil {
-		log.Debug("invalid date format", sl.Err(err))
-		response.WithMessage(c, http.StatusBadRequest, "invalid date format")
+	date, startedAt, ok := parseWorkoutTime(c, log, body.Date, body.StartTime)
+	if !ok {
+		return
+	}
+
+	activity, kind, ok := h.resolveActivity(c, log, body.ActivityID, body.Kind)
+	if !ok {
 		return
 	}
 
-	// TODO: Add check if workout is in future
+	system, _, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	metrics := applyMetrics(entity.Metrics{}, body.Metrics, system)
+	if !validHeartRate(c, log, metrics) {
+		return
+	}
+
+	exercises, ok := h.resolveExercises(c, log, body.Exercises)
+	if !ok {
+		return
+	}
 
 	userID := c.GetString("UserID")
-	workout, err := h.repository.Workout.Create(c, userID, date, body.Duration, body.Kind)
+	workout, err := h.repository.Workout.Create(c, userID, activity.ID, date, startedAt, body.Duration, kind, body.Visibility, metrics, exercises)
 	if err != nil {
 		log.Error("can't create workout", sl.Err(err))
 		response.InternalServerError(c)
@@ -57,12 +97,186 @@ func (h *Handler) CreateWorkout(c *gin.Context) {
 
 	log.Info("created a workout record", slog.String("id", workout.ID))
 
-	c.JSON(http.StatusCreated, responsebody.Workout{
-		ID:       workout.ID,
-		Date:     date.Format(layout),
-		Duration: workout.Duration,
-		Kind:     workout.Kind,
-	})
+	c.JSON(http.StatusCreated, workoutResponse(workout, system))
+}
+
+// @Summary      Get a workout record
+// @Description  returns a workout record of current user together with its exercises and laps
+// @Security     AccessToken
+// @Tags         activity
+// @Produce      json
+// @Param        id path        string true "Workout ID"
+// @Success      200 {object}   responsebody.Workout
+// @Failure      401 {object}   responsebody.Message
+// @Failure      403 {object}   responsebody.Message
+// @Failure      404 {object}   responsebody.Message
+// @Router       /workout/{id}  [get]
+func (h *Handler) GetWorkout(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.GetWorkout"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	workout, ok := h.ownWorkout(c, log, "view")
+	if !ok {
+		return
+	}
+
+	if err := h.attachExercises(c, workout); err != nil {
+		log.Error("can't get exercises", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if err := h.attachLaps(c, workout); err != nil {
+		log.Error("can't get laps", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if err := h.attachCounts(c, workout); err != nil {
+		log.Error("can't get counts", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	system, _, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, workoutResponse(workout, system))
+}
+
+// @Summary      Update a workout record
+// @Description  updates provided fields of a workout record, the rest stay the same. Provided exercises replace recorded ones. Moving a workout to another date without a start time clears the recorded one
+// @Security     AccessToken
+// @Tags         activity
+// @Accept       json
+// @Produce      json
+// @Param        id path        string true "Workout ID"
+// @Param        input body     requestbody.UpdateWorkout true "Fields to update"
+// @Success      200 {object}   responsebody.Workout
+// @Failure      400 {object}   responsebody.Message
+// @Failure      401 {object}   responsebody.Message
+// @Failure      403 {object}   responsebody.Message
+// @Failure      404 {object}   responsebody.Message
+// @Router       /workout/{id}  [patch]
+func (h *Handler) UpdateWorkout(c *gin.Context) {
+	log := slog.With(
+		slog.String("op", "handler.UpdateWorkout"),
+		slog.String("request_id", requestid.Get(c)),
+	)
+
+	var body requestbody.UpdateWorkout
+	if err := c.BindJSON(&body); err != nil {
+		log.Debug("can't decode request body", sl.Err(err))
+		response.InvalidRequestBody(c)
+		return
+	}
+
+	if body.Date == nil && body.StartTime == nil && body.Duration == nil && body.ActivityID == nil && body.Kind == nil && body.Visibility == nil && body.Exercises == nil && body.Metrics == (requestbody.Metrics{}) {
+		log.Debug("nothing to update")
+		response.WithMessage(c, http.StatusBadRequest, "nothing to update")
+		return
+	}
+
+	workout, ok := h.ownWorkout(c, log, "update")
+	if !ok {
+		return
+	}
+
+	system, _, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	switch {
+	case body.StartTime != nil:
+		var date string
+		if body.Date != nil {
+			date = *body.Date
+		}
+
+		workout.Date, workout.StartedAt, ok = parseWorkoutTime(c, log, date, body.StartTime)
+		if !ok {
+			return
+		}
+	case body.Date != nil:
+		date, ok := parseWorkoutDate(c, log, *body.Date)
+		if !ok {
+			return
+		}
+
+		// a start time on another day doesn't belong to the workout anymore
+		if !date.Equal(workout.Date) {
+			workout.StartedAt = nil
+		}
+		workout.Date = date
+	}
+
+	if body.Duration != nil {
+		workout.Duration = *body.Duration
+	}
+
+	if body.Visibility != nil {
+		workout.Visibility = *body.Visibility
+	}
+
+	if body.ActivityID != nil || body.Kind != nil {
+		var activityID, kind string
+		if body.ActivityID != nil {
+			activityID = *body.ActivityID
+		}
+		if body.Kind != nil {
+			kind = *body.Kind
+		}
+
+		var activity *entity.CatalogEntry
+		activity, workout.Kind, ok = h.resolveActivity(c, log, activityID, kind)
+		if !ok {
+			return
+		}
+		workout.ActivityID = activity.ID
+	}
+
+	metrics := applyMetrics(workout.Metrics, body.Metrics, system)
+	if !validHeartRate(c, log, metrics) {
+		return
+	}
+
+	// nil exercises keep recorded ones, while an empty list removes them
+	var exercises []entity.Exercise
+	if body.Exercises != nil {
+		exercises, ok = h.resolveExercises(c, log, body.Exercises)
+		if !ok {
+			return
+		}
+	}
+
+	updated, err := h.repository.Workout.Update(c, workout.ID, workout.ActivityID, workout.Date, workout.StartedAt, workout.Duration, workout.Kind, workout.Visibility, metrics, exercises)
+	if errors.Is(err, repoerr.ErrWorkoutNotFound) {
+		log.Debug("workout deleted concurrently", slog.String("id", workout.ID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return
+	}
+	if err != nil {
+		log.Error("can't update workout", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if exercises == nil {
+		if err := h.attachExercises(c, updated); err != nil {
+			log.Error("can't get exercises", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+	}
+
+	log.Info("updated a workout record", slog.String("id", updated.ID))
+
+	c.JSON(http.StatusOK, workoutResponse(updated, system))
 }
 
 // @Summary      Delete a workout record
@@ -71,8 +285,10 @@ func (h *Handler) CreateWorkout(c *gin.Context) {
 // @Tags         activity
 // @Accept       json
 // @Produce      json
-// @Param        id             path string true "Workout ID"
+// @Param        id path        string true "Workout ID"
 // @Success      200
+// @Failure      401 {object}   responsebody.Message
+// @Failure      403 {object}   responsebody.Message
 // @Failure      404 {object}   responsebody.Message
 // @Router       /workout/{id}  [delete]
 func (h *Handler) DeleteWorkout(c *gin.Context) {
@@ -81,39 +297,42 @@ func (h *Handler) DeleteWorkout(c *gin.Context) {
 		slog.String("request_id", requestid.Get(c)),
 	)
 
-	workoutID := c.Param("id")
-	fmt.Println(workoutID)
-	workout, err := h.repository.Workout.GetByID(c, workoutID)
-	if err != nil {
-		log.Error("workout not found", sl.Err(err))
-		response.WithMessage(c, http.StatusNotFound, "workout not found")
+	workout, ok := h.ownWorkout(c, log, "delete")
+	if !ok {
 		return
 	}
 
-	userID := c.GetString("UserID")
-	if workout.UserID != userID {
-		log.Error("user id doesn't match with workout's creator id", sl.Err(err))
-		response.WithMessage(c, http.StatusForbidden, "forbidden to delete workout")
+	err := h.repository.Workout.Delete(c, workout.ID)
+	if errors.Is(err, repoerr.ErrWorkoutNotFound) {
+		log.Debug("workout deleted concurrently", slog.String("id", workout.ID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return
 	}
-
-	err = h.repository.Workout.Delete(c, workoutID)
 	if err != nil {
 		log.Error("can't delete workout", sl.Err(err))
 		response.InternalServerError(c)
 		return
 	}
 
+	log.Info("deleted a workout record", slog.String("id", workout.ID))
+
 	c.Status(http.StatusOK)
 }
 
 // @Summary      Get user's activity history
-// @Description  returns user's workout history
+// @Description  returns a page of user's workout history, oldest first by default. Pass `next_cursor` of a page as `cursor` with the same filters to get the next one, the last page has no `next_cursor`
 // @Security     AccessToken
 // @Tags         activity
 // @Accept       json
 // @Produce      json
-// @Param        begin query   string false "Begin date"
-// @Param        end   query   string false "End date"
+// @Param        begin        query string false "Begin date"
+// @Param        end          query string false "End date"
+// @Param        kind         query string false "Workout kind, case insensitive"
+// @Param        min_duration query int    false "Minimal duration in minutes"
+// @Param        max_duration query int    false "Maximal duration in minutes"
+// @Param        order        query string false "Sort order by date: asc or desc, asc by default"
+// @Param        limit        query int    false "Number of workouts to return, 50 by default, 100 at most"
+// @Param        cursor       query string false "Cursor of the next page"
 // @Success      200 {object}  responsebody.ActivityHistory
 // @Failure      400 {object}  responsebody.Message
 // @Failure      401 {object}  responsebody.Message
@@ -124,59 +343,719 @@ func (h *Handler) GetActivityHistory(c *gin.Context) {
 		slog.String("request_id", requestid.Get(c)),
 	)
 
-	layout := "02-01-2006"
+	system, location, ok := h.preferences(c, log)
+	if !ok {
+		return
+	}
+
+	beginDate, endDate, ok := parseDateRange(c, log, time.Now().In(location))
+	if !ok {
+		return
+	}
+
+	filter, ok := parseHistoryFilter(c, log)
+	if !ok {
+		return
+	}
+	filter.Begin = beginDate
+	filter.End = endDate
+
+	limit := filter.Limit
+
+	// one more workout tells, whether there is a next page
+	filter.Limit++
+
+	userID := c.GetString("UserID")
+	workouts, err := h.repository.Workout.ListUserWorkouts(c, userID, filter)
+	if err != nil {
+		log.Error("can't get workouts", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	var next string
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+
+		last := workouts[limit-1]
+		next, err = cursor.Encode(historyCursor{
+			Date:      last.Date.Format(time.DateOnly),
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+			Order:     historyOrder(filter.Descending),
+		})
+		if err != nil {
+			log.Error("can't encode cursor", sl.Err(err))
+			response.InternalServerError(c)
+			return
+		}
+	}
+
+	refs := make([]*entity.Workout, 0, len(workouts))
+	for i := range workouts {
+		refs = append(refs, &workouts[i])
+	}
+
+	if err := h.attachExercises(c, refs...); err != nil {
+		log.Error("can't get exercises", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	if err := h.attachCounts(c, refs...); err != nil {
+		log.Error("can't get counts", sl.Err(err))
+		response.InternalServerError(c)
+		return
+	}
+
+	res := responsebody.ActivityHistory{
+		UserID:     userID,
+		Count:      len(workouts),
+		Workouts:   make([]responsebody.Workout, 0),
+		NextCursor: next,
+	}
+
+	for _, workout := range workouts {
+		res.Workouts = append(res.Workouts, workoutResponse(&workout, system))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// historyCursor is a position of the last workout of a page. It keeps the
+// sort order, as a position means nothing in the other one
+type historyCursor struct {
+	Date      string    `json:"d"`
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Order     string    `json:"o"`
+}
+
+func historyOrder(descending bool) string {
+	if descending {
+		return "desc"
+	}
+
+	return "asc"
+}
+
+// parseHistoryFilter parses filters, sort order and pagination of activity
+// history. Date range is parsed separately by parseDateRange
+func parseHistoryFilter(c *gin.Context, log *slog.Logger) (entity.WorkoutFilter, bool) {
+	filter := entity.WorkoutFilter{
+		Kind:  strings.TrimSpace(c.Query("kind")),
+		Limit: defaultHistoryLimit,
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		log.Debug("invalid order", slog.String("order", order))
+		response.WithMessage(c, http.StatusBadRequest, "order should be asc or desc")
+		return filter, false
+	}
+
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxHistoryLimit {
+			log.Debug("invalid limit", slog.String("limit", value))
+			response.WithMessage(c, http.StatusBadRequest, "invalid limit")
+			return filter, false
+		}
+		filter.Limit = n
+	}
+
+	durations := []struct {
+		param  string
+		target *int
+	}{
+		{"min_duration", &filter.MinDuration},
+		{"max_duration", &filter.MaxDuration},
+	}
+	for _, d := range durations {
+		value := c.Query(d.param)
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			log.Debug("invalid duration", slog.String(d.param, value))
+			response.WithMessage(c, http.StatusBadRequest, fmt.Sprintf("invalid %s", d.param))
+			return filter, false
+		}
+		*d.target = n
+	}
+
+	if filter.MaxDuration != 0 && filter.MaxDuration < filter.MinDuration {
+		log.Debug("invalid duration range", slog.Int("min", filter.MinDuration), slog.Int("max", filter.MaxDuration))
+		response.WithMessage(c, http.StatusBadRequest, "max_duration can't be less than min_duration")
+		return filter, false
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		var position historyCursor
+		err := cursor.Decode(value, &position)
+
+		var date time.Time
+		if err == nil {
+			date, err = time.Parse(time.DateOnly, position.Date)
+		}
+
+		if err != nil || uuid.Validate(position.ID) != nil || position.Order != historyOrder(filter.Descending) {
+			log.Debug("invalid cursor", slog.String("cursor", value))
+			response.WithMessage(c, http.StatusBadRequest, "invalid cursor")
+			return filter, false
+		}
+
+		filter.After = &entity.WorkoutPosition{
+			Date:      date,
+			CreatedAt: position.CreatedAt,
+			ID:        position.ID,
+		}
+	}
+
+	return filter, true
+}
+
+// ownWorkout finds a workout by `id` path parameter, that belongs to the
+// current user, and responds with an error otherwise
+func (h *Handler) ownWorkout(c *gin.Context, log *slog.Logger, action string) (*entity.Workout, bool) {
+	workoutID := c.Param("id")
+
+	workout, err := h.repository.Workout.GetByID(c, workoutID)
+	if errors.Is(err, repoerr.ErrWorkoutNotFound) {
+		log.Debug("workout not found", slog.String("id", workoutID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("can't find workout", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	if workout.UserID != c.GetString("UserID") {
+		log.Debug("user id doesn't match with workout's creator id", slog.String("id", workoutID))
+		response.WithMessage(c, http.StatusForbidden, fmt.Sprintf("forbidden to %s workout", action))
+		return nil, false
+	}
+
+	return workout, true
+}
+
+// visibleWorkout finds a workout by `id` path parameter and makes sure, that
+// current user can see it
+func (h *Handler) visibleWorkout(c *gin.Context, log *slog.Logger) (*entity.Workout, bool) {
+	workoutID := c.Param("id")
+
+	workout, err := h.repository.Workout.GetByID(c, workoutID)
+	if errors.Is(err, repoerr.ErrWorkoutNotFound) {
+		log.Debug("workout not found", slog.String("id", workoutID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("can't find workout", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	if workout.UserID == c.GetString("UserID") {
+		return workout, true
+	}
+
+	owner, err := h.repository.User.GetByID(c, workout.UserID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("workout owner not found", slog.String("user_id", workout.UserID))
+		response.WithMessage(c, http.StatusNotFound, "workout not found")
+		return nil, false
+	}
+	if err != nil {
+		log.Error("can't find user", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, false
+	}
+
+	if !h.unblocked(c, log, owner.ID, "workout not found") {
+		return nil, false
+	}
+
+	status, ok := h.followStatus(c, log, owner)
+	if !ok {
+		return nil, false
+	}
+
+	if !canViewWorkout(c, owner, status, workout) {
+		log.Debug("workout is private", slog.String("id", workoutID))
+		response.WithMessage(c, http.StatusForbidden, "workout is private")
+		return nil, false
+	}
+
+	return workout, true
+}
+
+// canViewWorkout tells, whether current user can see a workout of given user.
+// Besides the workout's visibility, a private profile hides it from those,
+// who don't follow the user
+func canViewWorkout(c *gin.Context, owner *entity.User, status string, workout *entity.Workout) bool {
+	if owner.ID == c.GetString("UserID") {
+		return true
+	}
+
+	return canView(c, owner, status) && visibility.Allows(workout.Visibility, status == followAccepted)
+}
+
+// parseDateRange parses `begin` and `end` query parameters, that default to
+// the Unix epoch and a date of `now`
+func parseDateRange(c *gin.Context, log *slog.Logger, now time.Time) (time.Time, time.Time, bool) {
 	params := c.Request.URL.Query()
 
-	var begin string
+	begin := time.Unix(0, 0).UTC()
 	if params.Has("begin") {
-		begin = params.Get("begin")
-	} else {
-		begin = "01-01-1970"
+		date, err := parseDate(params.Get("begin"))
+		if err != nil {
+			log.Debug("incorrect date format", slog.String("date", params.Get("begin")), sl.Err(err))
+			response.WithMessage(c, http.StatusBadRequest, "date not provided or invalid date format")
+			return time.Time{}, time.Time{}, false
+		}
+		begin = date
 	}
 
-	var end string
+	end := dateOf(now)
 	if params.Has("end") {
-		end = params.Get("end")
-	} else {
-		end = time.Now().Format(layout)
+		date, err := parseDate(params.Get("end"))
+		if err != nil {
+			log.Debug("incorrect date format", slog.String("date", params.Get("end")), sl.Err(err))
+			response.WithMessage(c, http.StatusBadRequest, "date not provided or invalid date format")
+			return time.Time{}, time.Time{}, false
+		}
+		end = date
 	}
 
-	beginDate, err := time.Parse(layout, begin)
+	return begin, end, true
+}
+
+// parseDate parses a date in DD-MM-YYYY or ISO 8601 YYYY-MM-DD format
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(workoutDateLayout, value)
+	if err == nil {
+		return date, nil
+	}
+
+	date, isoErr := time.Parse(time.DateOnly, value)
+	if isoErr == nil {
+		return date, nil
+	}
+
+	return time.Time{}, err
+}
+
+// dateOf returns a calendar date of a moment in its location, as dates of
+// workouts are stored as midnights in UTC
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseWorkoutTime parses a date and an optional start time of a workout.
+// A date of the start time is taken in its own offset, as the offset is
+// the one of the place, where the workout happened. The date may be omitted
+// then, otherwise it should match
+func parseWorkoutTime(c *gin.Context, log *slog.Logger, value string, startTime *time.Time) (time.Time, *time.Time, bool) {
+	if startTime == nil {
+		date, ok := parseWorkoutDate(c, log, value)
+		return date, nil, ok
+	}
+
+	if startTime.After(time.Now().Add(maxClockSkew)) {
+		log.Debug("workout starts in future", slog.Time("start_time", *startTime))
+		response.WithMessage(c, http.StatusBadRequest, "workout start time can't be in the future")
+		return time.Time{}, nil, false
+	}
+
+	date := dateOf(*startTime)
+	if value != "" {
+		parsed, ok := parseWorkoutDate(c, log, value)
+		if !ok {
+			return time.Time{}, nil, false
+		}
+
+		if !parsed.Equal(date) {
+			log.Debug("date doesn't match start time", slog.String("date", value), slog.Time("start_time", *startTime))
+			response.WithMessage(c, http.StatusBadRequest, "date doesn't match start time")
+			return time.Time{}, nil, false
+		}
+	}
+
+	return date, startTime, true
+}
+
+// parseWorkoutDate parses a date of a workout and makes sure, that it has
+// already come at least in one time zone
+func parseWorkoutDate(c *gin.Context, log *slog.Logger, value string) (time.Time, bool) {
+	date, err := validWorkoutDate(value)
+	if errors.Is(err, errFutureWorkout) {
+		log.Debug("workout is in future", slog.String("date", value))
+		response.WithMessage(c, http.StatusBadRequest, "workout date can't be in the future")
+		return time.Time{}, false
+	}
 	if err != nil {
-		log.Debug("incorrect date format", slog.String("date", begin), sl.Err(err))
-		response.WithMessage(c, http.StatusBadRequest, "date not provided or invalid date format")
-		return
+		log.Debug("invalid date format", sl.Err(err))
+		response.WithMessage(c, http.StatusBadRequest, "invalid date format")
+		return time.Time{}, false
 	}
 
-	endDate, err := time.Parse(layout, end)
+	return date, true
+}
+
+var errFutureWorkout = errors.New("workout date is in the future")
+
+// validWorkoutDate parses a date of a workout, that can't be in the future
+func validWorkoutDate(value string) (time.Time, error) {
+	date, err := parseDate(value)
 	if err != nil {
-		log.Debug("incorrect date format", slog.String("date", begin), sl.Err(err))
-		response.WithMessage(c, http.StatusBadRequest, "date not provided or invalid date format")
-		return
+		return time.Time{}, err
+	}
+
+	if date.After(time.Now().UTC().Add(maxUTCOffset)) {
+		return time.Time{}, errFutureWorkout
+	}
+
+	return date, nil
+}
+
+func parseWorkoutKind(c *gin.Context, log *slog.Logger, value string) (string, bool) {
+	kind := strings.TrimSpace(value)
+	if kind == "" {
+		log.Debug("empty workout kind")
+		response.WithMessage(c, http.StatusBadRequest, "workout kind can't be empty")
+		return "", false
+	}
+
+	return kind, true
+}
+
+// attachExercises loads exercises with their sets for given workouts
+func (h *Handler) attachExercises(c *gin.Context, workouts ...*entity.Workout) error {
+	ids := make([]string, 0, len(workouts))
+	byID := make(map[string]*entity.Workout, len(workouts))
+	for _, workout := range workouts {
+		ids = append(ids, workout.ID)
+		byID[workout.ID] = workout
+	}
+
+	exercises, err := h.repository.Workout.GetExercises(c, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, exercise := range exercises {
+		if workout, ok := byID[exercise.WorkoutID]; ok {
+			workout.Exercises = append(workout.Exercises, exercise)
+		}
+	}
+
+	return nil
+}
+
+// attachCounts loads numbers of kudos and comments for given workouts, that
+// current user can see in the lists of kudos and comments
+func (h *Handler) attachCounts(c *gin.Context, workouts ...*entity.Workout) error {
+	ids := make([]string, 0, len(workouts))
+	byID := make(map[string]*entity.Workout, len(workouts))
+	for _, workout := range workouts {
+		ids = append(ids, workout.ID)
+		byID[workout.ID] = workout
 	}
 
+	counts, err := h.repository.Workout.GetCounts(c, ids, c.GetString("UserID"))
+	if err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		if workout, ok := byID[count.WorkoutID]; ok {
+			workout.Counts = count
+		}
+	}
+
+	return nil
+}
+
+// preferences returns a measurement system and a time zone of current user
+func (h *Handler) preferences(c *gin.Context, log *slog.Logger) (string, *time.Location, bool) {
 	userID := c.GetString("UserID")
-	workouts, err := h.repository.Workout.GetUserWorkouts(c, userID, beginDate, endDate)
+
+	user, err := h.repository.User.GetByID(c, userID)
+	if errors.Is(err, repoerr.ErrUserNotFound) {
+		log.Debug("user not found", slog.String("id", userID))
+		response.WithMessage(c, http.StatusUnauthorized, "invalid authorization token")
+		return "", nil, false
+	}
 	if err != nil {
-		log.Error("can't get workouts", sl.Err(err))
+		log.Error("can't find user", sl.Err(err))
 		response.InternalServerError(c)
-		return
+		return "", nil, false
 	}
 
-	res := responsebody.ActivityHistory{
-		UserID:   userID,
-		Count:    len(workouts),
-		Workouts: make([]responsebody.Workout, 0),
+	return user.Units, userLocation(log, user), true
+}
+
+// userLocation loads a time zone of a user. Zones are validated, when they
+// are set, so UTC is used only if the time zone database lacks one
+func userLocation(log *slog.Logger, user *entity.User) *time.Location {
+	location, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		log.Warn("unknown time zone of user", slog.String("id", user.ID), slog.String("time_zone", user.TimeZone))
+		return time.UTC
 	}
 
-	for _, workout := range workouts {
-		res.Workouts = append(res.Workouts, responsebody.Workout{
-			ID:       workout.ID,
-			Date:     workout.Date.Format(layout),
-			Duration: workout.Duration,
-			Kind:     workout.Kind,
+	return location
+}
+
+// applyMetrics returns given metrics with provided fields of request body
+// converted from user's units and applied
+func applyMetrics(metrics entity.Metrics, body requestbody.Metrics, system string) entity.Metrics {
+	if body.Distance != nil {
+		distance := units.DistanceToMeters(*body.Distance, system)
+		metrics.Distance = &distance
+	}
+	if body.ElevationGain != nil {
+		elevationGain := units.ElevationToMeters(*body.ElevationGain, system)
+		metrics.ElevationGain = &elevationGain
+	}
+	if body.AvgHeartRate != nil {
+		metrics.AvgHeartRate = body.AvgHeartRate
+	}
+	if body.MaxHeartRate != nil {
+		metrics.MaxHeartRate = body.MaxHeartRate
+	}
+	if body.Cadence != nil {
+		metrics.Cadence = body.Cadence
+	}
+	if body.Calories != nil {
+		metrics.Calories = body.Calories
+	}
+
+	return metrics
+}
+
+func validHeartRate(c *gin.Context, log *slog.Logger, metrics entity.Metrics) bool {
+	if metrics.AvgHeartRate != nil && metrics.MaxHeartRate != nil && *metrics.MaxHeartRate < *metrics.AvgHeartRate {
+		log.Debug("max heart rate is lower than average", slog.Int("avg", *metrics.AvgHeartRate), slog.Int("max", *metrics.MaxHeartRate))
+		response.WithMessage(c, http.StatusBadRequest, "max heart rate can't be lower than average")
+		return false
+	}
+
+	return true
+}
+
+// resolveActivity finds a catalog activity by its id or by a kind, that may
+// be a name or an alias of one. Unknown kinds are attached to the fallback
+// activity and kept as is, otherwise the kind becomes activity's name
+func (h *Handler) resolveActivity(c *gin.Context, log *slog.Logger, activityID string, kind string) (*entity.CatalogEntry, string, bool) {
+	userID := c.GetString("UserID")
+
+	if activityID != "" {
+		activity, err := h.repository.Catalog.GetByID(c, userID, activityID)
+		if errors.Is(err, repoerr.ErrCatalogEntryNotFound) || err == nil && activity.Type != catalog.Activity {
+			log.Debug("unknown activity", slog.String("activity_id", activityID))
+			response.WithMessage(c, http.StatusBadRequest, "unknown activity")
+			return nil, "", false
+		}
+		if err != nil {
+			log.Error("can't find activity", sl.Err(err))
+			response.InternalServerError(c)
+			return nil, "", false
+		}
+
+		return activity, activity.Name, true
+	}
+
+	kind, ok := parseWorkoutKind(c, log, kind)
+	if !ok {
+		return nil, "", false
+	}
+
+	activity, kind, err := h.activityByKind(c, userID, kind)
+	if err != nil {
+		log.Error("can't find activity", sl.Err(err))
+		response.InternalServerError(c)
+		return nil, "", false
+	}
+
+	return activity, kind, true
+}
+
+// activityByKind finds a catalog activity by a name or an alias. Unknown
+// kinds are attached to the fallback activity and kept as is, otherwise the
+// kind becomes activity's name
+func (h *Handler) activityByKind(c *gin.Context, userID string, kind string) (*entity.CatalogEntry, string, error) {
+	activity, err := h.repository.Catalog.GetByName(c, userID, catalog.Activity, kind)
+	if errors.Is(err, repoerr.ErrCatalogEntryNotFound) {
+		activity, err = h.repository.Catalog.GetByName(c, userID, catalog.Activity, catalog.Fallback)
+		if err != nil {
+			return nil, "", fmt.Errorf("can't find fallback activity: %w", err)
+		}
+
+		return activity, kind, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return activity, activity.Name, nil
+}
+
+// resolveExercises converts exercises of request body and links them to
+// catalog entries by id or by name. Exercises with unknown names are kept
+// unlinked
+func (h *Handler) resolveExercises(c *gin.Context, log *slog.Logger, body []requestbody.Exercise) ([]entity.Exercise, bool) {
+	userID := c.GetString("UserID")
+
+	exercises := make([]entity.Exercise, 0, len(body))
+	for _, e := range body {
+		exercise := entity.Exercise{Name: strings.TrimSpace(e.Name)}
+
+		if e.ExerciseID != "" {
+			entry, err := h.repository.Catalog.GetByID(c, userID, e.ExerciseID)
+			if errors.Is(err, repoerr.ErrCatalogEntryNotFound) || err == nil && entry.Type != catalog.Exercise {
+				log.Debug("unknown exercise", slog.String("exercise_id", e.ExerciseID))
+				response.WithMessage(c, http.StatusBadRequest, "unknown exercise")
+				return nil, false
+			}
+			if err != nil {
+				log.Error("can't find exercise", sl.Err(err))
+				response.InternalServerError(c)
+				return nil, false
+			}
+
+			exercise.ExerciseID = &entry.ID
+			exercise.Name = entry.Name
+		} else {
+			if exercise.Name == "" {
+				log.Debug("empty exercise name")
+				response.WithMessage(c, http.StatusBadRequest, "exercise name can't be empty")
+				return nil, false
+			}
+
+			entry, err := h.repository.Catalog.GetByName(c, userID, catalog.Exercise, exercise.Name)
+			if err != nil && !errors.Is(err, repoerr.ErrCatalogEntryNotFound) {
+				log.Error("can't find exercise", sl.Err(err))
+				response.InternalServerError(c)
+				return nil, false
+			}
+			if err == nil {
+				exercise.ExerciseID = &entry.ID
+				exercise.Name = entry.Name
+			}
+		}
+
+		sets := make([]entity.ExerciseSet, 0, len(e.Sets))
+		for _, s := range e.Sets {
+			sets = append(sets, entity.ExerciseSet{
+				Reps:        s.Reps,
+				Weight:      s.Weight,
+				WeightUnit:  s.WeightUnit,
+				RPE:         s.RPE,
+				RestSeconds: s.RestSeconds,
+			})
+		}
+
+		exercise.Sets = sets
+		exercises = append(exercises, exercise)
+	}
+
+	return exercises, true
+}
+
+func workoutResponse(workout *entity.Workout, system string) responsebody.Workout {
+	res := responsebody.Workout{
+		ID:            workout.ID,
+		ActivityID:    workout.ActivityID,
+		Date:          workout.Date.Format(workoutDateLayout),
+		Duration:      workout.Duration,
+		Kind:          workout.Kind,
+		Visibility:    workout.Visibility,
+		KudosCount:    workout.Counts.Kudos,
+		CommentsCount: workout.Counts.Comments,
+		Metrics:       metricsResponse(workout, system),
+	}
+
+	if workout.StartedAt != nil {
+		res.StartTime = workout.StartedAt.Format(time.RFC3339)
+	}
+
+	for _, exercise := range workout.Exercises {
+		sets := make([]responsebody.ExerciseSet, 0, len(exercise.Sets))
+		for _, set := range exercise.Sets {
+			sets = append(sets, responsebody.ExerciseSet{
+				Reps:        set.Reps,
+				Weight:      set.Weight,
+				WeightUnit:  set.WeightUnit,
+				RPE:         set.RPE,
+				RestSeconds: set.RestSeconds,
+			})
+		}
+
+		res.Exercises = append(res.Exercises, responsebody.Exercise{
+			ExerciseID: exercise.ExerciseID,
+			Name:       exercise.Name,
+			Sets:       sets,
 		})
 	}
 
-	c.JSON(http.StatusOK, res)
+	for _, lap := range workout.Laps {
+		l := responsebody.Lap{
+			StartTime:    lap.StartTime.Format(time.RFC3339),
+			Duration:     lap.Duration,
+			AvgHeartRate: lap.AvgHeartRate,
+			MaxHeartRate: lap.MaxHeartRate,
+			Calories:     lap.Calories,
+		}
+
+		if lap.Distance != nil {
+			distance := units.Round(units.DistanceFromMeters(*lap.Distance, system), 2)
+			l.Distance = &distance
+		}
+
+		res.Laps = append(res.Laps, l)
+	}
+
+	return res
+}
+
+// metricsResponse converts metrics of a workout to user's units and derives
+// pace and speed from distance and duration
+func metricsResponse(workout *entity.Workout, system string) responsebody.Metrics {
+	res := responsebody.Metrics{
+		AvgHeartRate: workout.AvgHeartRate,
+		MaxHeartRate: workout.MaxHeartRate,
+		Cadence:      workout.Cadence,
+		Calories:     workout.Calories,
+	}
+
+	if workout.Distance != nil {
+		distance := units.Round(units.DistanceFromMeters(*workout.Distance, system), 2)
+		res.Distance = &distance
+
+		if *workout.Distance > 0 {
+			duration := time.Duration(workout.Duration) * time.Minute
+
+			pace := int(math.Round(units.Pace(*workout.Distance, duration, system)))
+			res.Pace = &pace
+
+			speed := units.Round(units.Speed(*workout.Distance, duration, system), 2)
+			res.Speed = &speed
+		}
+	}
+
+	if workout.ElevationGain != nil {
+		elevationGain := units.Round(units.ElevationFromMeters(*workout.ElevationGain, system), 1)
+		res.ElevationGain = &elevationGain
+	}
+
+	return res
 }
@@ -1,15 +1,21 @@
 package handler
 
 import (
+	"api/internal/app/handler/catalog"
 	"api/internal/app/handler/request/requestbody"
 	"api/internal/app/handler/response/responsebody"
 	"api/internal/app/handler/test"
+	"api/internal/app/handler/visibility"
 	"api/internal/config"
 	mockmailer "api/internal/mailer/mock"
 	"api/internal/repository"
 	"api/internal/repository/entity"
 	"api/internal/token"
 	mocktoken "api/internal/token/mock"
+	"api/pkg/cursor"
+	"api/pkg/password"
+	"api/pkg/units"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"net/http"
@@ -18,6 +24,13 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	insertWorkoutQuery = "INSERT INTO workouts (user_id, activity_id, date, duration, kind, distance, elevation_gain, avg_heart_rate, max_heart_rate, cadence, calories, started_at, visibility) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE(NULLIF($13, ''), (SELECT default_visibility FROM users WHERE id = $1))) RETURNING *"
+	countsQuery        = "SELECT workouts.id AS workout_id, (SELECT count(*) FROM kudos WHERE kudos.workout_id = workouts.id AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = kudos.user_id) OR (blocks.blocker_id = kudos.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = kudos.user_id)) AS kudos, (WITH RECURSIVE shown AS (SELECT comments.id FROM comments WHERE comments.workout_id = workouts.id AND comments.parent_id IS NULL AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = comments.user_id) OR (blocks.blocker_id = comments.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = comments.user_id) UNION ALL SELECT comments.id FROM comments JOIN shown ON comments.parent_id = shown.id WHERE NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = comments.user_id) OR (blocks.blocker_id = comments.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = comments.user_id)) SELECT count(*) FROM shown) AS comments FROM workouts WHERE workouts.id = ANY($1)"
+	updateWorkoutQuery = "UPDATE workouts SET activity_id = $1, date = $2, duration = $3, kind = $4, distance = $5, elevation_gain = $6, avg_heart_rate = $7, max_heart_rate = $8, cadence = $9, calories = $10, started_at = $11, visibility = $12, updated_at = now() WHERE id = $13 RETURNING *"
 )
 
 func TestCreateWorkout(t *testing.T) {
@@ -30,9 +43,9 @@ func TestCreateWorkout(t *testing.T) {
 	c := config.Config{Token: config.Token{Secret: tokenSecret}}
 	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
 	tokenManager := token.New(c.Token)
-	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token))
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
 
-	accessToken, err := tokenManager.GenerateJWT("USER_ID")
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
 	if err != nil {
 		t.Fatal("unexpected error while generating mock token")
 	}
@@ -51,17 +64,45 @@ func TestCreateWorkout(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
+	// just after midnight in Berlin, while it's still May 1st in UTC
+	startedAt := time.Date(2024, time.May, 2, 0, 30, 0, 0, time.FixedZone("", 2*60*60))
+	startDate := time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+
+	squatID := "SQUAT_ID"
+	reps := 5
+	weight := 102.5
+	rpe := 8.5
+	zero := 0.0
+
+	// 5 miles in 40 minutes
+	miles := 5.0
+	feet := 100.0
+	pace := 480
+	speed := 7.5
+	avgHeartRate := 150
+	maxHeartRate := 175
+
 	tests := []test.Case{
 		{
 			Name: "ok",
 
 			Repo: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "user_id", "date", "duration", "kind", "created_at"}).
-					AddRow(workout.ID, workout.UserID, workout.Date, workout.Duration, workout.Kind, workout.CreatedAt)
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}).
+					AddRow(workout.ID, workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, workout.CreatedAt)
+
+				expectCatalogName(mock, catalog.Activity, workout.Kind).
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, workout.Kind))
+
+				expectUnits(mock, units.Metric)
 
-				mock.ExpectQuery("INSERT INTO workouts (user_id, date, duration, kind) VALUES ($1, $2, $3, $4) RETURNING *").
-					WithArgs(workout.UserID, workout.Date, workout.Duration, workout.Kind).
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, nil, nil, nil, nil, nil, nil, nil, "").
 					WillReturnRows(rows)
+				mock.ExpectCommit()
 			},
 
 			Request: test.Request{
@@ -78,65 +119,437 @@ func TestCreateWorkout(t *testing.T) {
 			Expect: test.Expect{
 				Status: http.StatusCreated,
 				Body: responsebody.Workout{
-					ID:       workout.ID,
-					Date:     workout.Date.Format(layout),
+					ID:         workout.ID,
+					ActivityID: "ACTIVITY_ID",
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					Kind:       workout.Kind,
+				},
+			},
+		},
+		{
+			Name: "visibility",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "visibility", "created_at"}).
+					AddRow(workout.ID, workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, visibility.OnlyMe, workout.CreatedAt)
+
+				expectCatalogName(mock, catalog.Activity, workout.Kind).
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, workout.Kind))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, nil, nil, nil, nil, nil, nil, nil, visibility.OnlyMe).
+					WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					Kind:       workout.Kind,
+					Visibility: visibility.OnlyMe,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         workout.ID,
+					ActivityID: "ACTIVITY_ID",
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					Kind:       workout.Kind,
+					Visibility: visibility.OnlyMe,
+				},
+			},
+		},
+		{
+			Name: "start time",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "started_at", "created_at"}).
+					AddRow(workout.ID, workout.UserID, "ACTIVITY_ID", startDate, workout.Duration, workout.Kind, startedAt, workout.CreatedAt)
+
+				expectCatalogName(mock, catalog.Activity, workout.Kind).
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, workout.Kind))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", startDate, workout.Duration, workout.Kind, nil, nil, nil, nil, nil, nil, startedAt, "").
+					WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					StartTime: &startedAt,
+					Duration:  workout.Duration,
+					Kind:      workout.Kind,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         workout.ID,
+					ActivityID: "ACTIVITY_ID",
+					Date:       "02-05-2024",
+					StartTime:  "2024-05-02T00:30:00+02:00",
+					Duration:   workout.Duration,
+					Kind:       workout.Kind,
+				},
+			},
+		},
+		{
+			Name: "date in ISO format",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}).
+					AddRow(workout.ID, workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, workout.CreatedAt)
+
+				expectCatalogName(mock, catalog.Activity, workout.Kind).
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, workout.Kind))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, nil, nil, nil, nil, nil, nil, nil, "").
+					WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format("2006-01-02"),
 					Duration: workout.Duration,
 					Kind:     workout.Kind,
 				},
 			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         workout.ID,
+					ActivityID: "ACTIVITY_ID",
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					Kind:       workout.Kind,
+				},
+			},
 		},
 		{
-			Name: "invalid request body",
+			Name: "date doesn't match start time",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
 
 			Request: test.Request{
 				Headers: map[string]string{
 					"Authorization": headerAuthorization,
 				},
-				Body: map[string]string{
-					"invalid": "body",
+				Body: requestbody.CreateWorkout{
+					Date:      "01-05-2024",
+					StartTime: &startedAt,
+					Duration:  workout.Duration,
+					Kind:      workout.Kind,
 				},
 			},
 
-			Expect: test.ResponseInvalidRequestBody,
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "date doesn't match start time",
+				},
+			},
 		},
 		{
-			Name: "invalid date format",
+			Name: "start time in future",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
 
 			Request: test.Request{
 				Headers: map[string]string{
 					"Authorization": headerAuthorization,
 				},
 				Body: requestbody.CreateWorkout{
-					Date:     "69--01-2024",
+					StartTime: &future,
+					Duration:  workout.Duration,
+					Kind:      workout.Kind,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "workout start time can't be in the future",
+				},
+			},
+		},
+		{
+			Name: "by alias with exercises",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}).
+					AddRow(workout.ID, workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, "Strength training", workout.CreatedAt)
+
+				expectCatalogName(mock, catalog.Activity, "strength").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Strength training"))
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Exercise, "Squat").
+					WillReturnRows(catalogRows("SQUAT_ID", catalog.Exercise, "Squat"))
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, "Strength training", nil, nil, nil, nil, nil, nil, nil, "").
+					WillReturnRows(rows)
+				mock.ExpectQuery("INSERT INTO workout_exercises (workout_id, exercise_id, position, name) VALUES ($1, $2, $3, $4) RETURNING *").
+					WithArgs(workout.ID, "SQUAT_ID", 1, "Squat").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "workout_id", "exercise_id", "position", "name"}).AddRow("EXERCISE_ID", workout.ID, "SQUAT_ID", 1, "Squat"))
+				mock.ExpectQuery("INSERT INTO exercise_sets (exercise_id, position, reps, weight, weight_unit, rpe, rest_seconds) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *").
+					WithArgs("EXERCISE_ID", 1, reps, weight, "kg", rpe, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "exercise_id", "position", "reps", "weight", "weight_unit", "rpe", "rest_seconds"}).AddRow("SET_ID", "EXERCISE_ID", 1, reps, "102.50", "kg", "8.5", nil))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
 					Duration: workout.Duration,
-					Kind:     workout.Kind,
+					Kind:     "strength",
+					Exercises: []requestbody.Exercise{
+						{
+							Name: "Squat",
+							Sets: []requestbody.ExerciseSet{
+								{Reps: &reps, Weight: &weight, WeightUnit: "kg", RPE: &rpe},
+							},
+						},
+					},
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         workout.ID,
+					ActivityID: "ACTIVITY_ID",
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					Kind:       "Strength training",
+					Exercises: []responsebody.Exercise{
+						{
+							ExerciseID: &squatID,
+							Name:       "Squat",
+							Sets: []responsebody.ExerciseSet{
+								{Reps: &reps, Weight: &weight, WeightUnit: "kg", RPE: &rpe},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "with metrics in miles",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectCatalogName(mock, catalog.Activity, "Running").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Running"))
+
+				expectUnits(mock, units.Imperial)
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "distance", "elevation_gain", "avg_heart_rate", "max_heart_rate"}).
+					AddRow(workout.ID, workout.UserID, "ACTIVITY_ID", workout.Date, 40, "Running", workout.CreatedAt, 8046.72, 30.48, 150, 175)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", workout.Date, 40, "Running", 8046.72, sqlmock.AnyArg(), avgHeartRate, maxHeartRate, nil, nil, nil, "").
+					WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: 40,
+					Kind:     "Running",
+					Metrics: requestbody.Metrics{
+						Distance:      &miles,
+						ElevationGain: &feet,
+						AvgHeartRate:  &avgHeartRate,
+						MaxHeartRate:  &maxHeartRate,
+					},
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         workout.ID,
+					ActivityID: "ACTIVITY_ID",
+					Date:       workout.Date.Format(layout),
+					Duration:   40,
+					Kind:       "Running",
+					Metrics: responsebody.Metrics{
+						Distance:      &miles,
+						ElevationGain: &feet,
+						Pace:          &pace,
+						Speed:         &speed,
+						AvgHeartRate:  &avgHeartRate,
+						MaxHeartRate:  &maxHeartRate,
+					},
+				},
+			},
+		},
+		{
+			Name: "max heart rate lower than average",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectCatalogName(mock, catalog.Activity, "Running").
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, "Running"))
+
+				expectUnits(mock, units.Metric)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: 40,
+					Kind:     "Running",
+					Metrics: requestbody.Metrics{
+						AvgHeartRate: &maxHeartRate,
+						MaxHeartRate: &avgHeartRate,
+					},
 				},
 			},
 
 			Expect: test.Expect{
 				Status: http.StatusBadRequest,
 				Body: responsebody.Message{
-					Message: "invalid date format",
+					Message: "max heart rate can't be lower than average",
 				},
 			},
 		},
 		{
-			Name: "unauthorized",
+			Name: "unknown kind",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}).
+					AddRow(workout.ID, workout.UserID, "OTHER_ID", workout.Date, workout.Duration, "Parkour", workout.CreatedAt)
+
+				expectCatalogName(mock, catalog.Activity, "Parkour").
+					WillReturnRows(sqlmock.NewRows(catalogColumns))
+
+				expectCatalogName(mock, catalog.Activity, catalog.Fallback).
+					WillReturnRows(catalogRows("OTHER_ID", catalog.Activity, catalog.Fallback))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "OTHER_ID", workout.Date, workout.Duration, "Parkour", nil, nil, nil, nil, nil, nil, nil, "").
+					WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: workout.Duration,
+					Kind:     " Parkour ",
+				},
+			},
 
 			Expect: test.Expect{
-				Status: http.StatusUnauthorized,
+				Status: http.StatusCreated,
+				Body: responsebody.Workout{
+					ID:         workout.ID,
+					ActivityID: "OTHER_ID",
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					Kind:       "Parkour",
+				},
+			},
+		},
+		{
+			Name: "activity id of an exercise",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM catalog WHERE id = $1 AND (user_id IS NULL OR user_id = $2)").
+					WithArgs(catalogEntryID, "USER_ID").
+					WillReturnRows(catalogRows(catalogEntryID, catalog.Exercise, "Squat"))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:       workout.Date.Format(layout),
+					Duration:   workout.Duration,
+					ActivityID: catalogEntryID,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
 				Body: responsebody.Message{
-					Message: "empty authorization header",
+					Message: "unknown activity",
 				},
 			},
 		},
 		{
-			Name: "repository error",
+			Name: "weight without unit",
 
 			Repo: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("INSERT INTO workouts (user_id, date, duration, kind) VALUES ($1, $2, $3, $4) RETURNING *").
-					WithArgs(workout.UserID, workout.Date, workout.Duration, workout.Kind).
-					WillReturnError(errors.New("repo: Some repository error"))
+				test.ExpectActiveSession(mock, "SESSION_ID")
 			},
 
 			Request: test.Request{
@@ -146,15 +559,1056 @@ func TestCreateWorkout(t *testing.T) {
 				Body: requestbody.CreateWorkout{
 					Date:     workout.Date.Format(layout),
 					Duration: workout.Duration,
-					Kind:     workout.Kind,
+					Kind:     "Strength",
+					Exercises: []requestbody.Exercise{
+						{Name: "Squat", Sets: []requestbody.ExerciseSet{{Reps: &reps, Weight: &weight}}},
+					},
 				},
 			},
 
-			Expect: test.ResponseInternalServerError,
+			Expect: test.ResponseInvalidRequestBody,
 		},
-	}
+		{
+			Name: "invalid rpe",
 
-	for _, tc := range tests {
-		test.Endpoint(t, tc, mock, http.MethodPost, "/api/workout", "/api/workout", handler.UserIdentity, handler.CreateWorkout)
-	}
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: workout.Duration,
+					Kind:     "Strength",
+					Exercises: []requestbody.Exercise{
+						{Name: "Squat", Sets: []requestbody.ExerciseSet{{Reps: &reps, RPE: &zero}}},
+					},
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "invalid request body",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: map[string]string{
+					"invalid": "body",
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "invalid date format",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     "69--01-2024",
+					Duration: workout.Duration,
+					Kind:     workout.Kind,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "invalid date format",
+				},
+			},
+		},
+		{
+			Name: "date in the future",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     now.AddDate(0, 0, 2).Format(layout),
+					Duration: workout.Duration,
+					Kind:     workout.Kind,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "workout date can't be in the future",
+				},
+			},
+		},
+		{
+			Name: "duration too long",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: 1441,
+					Kind:     workout.Kind,
+				},
+			},
+
+			Expect: test.ResponseInvalidRequestBody,
+		},
+		{
+			Name: "blank kind",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: workout.Duration,
+					Kind:     "   ",
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "workout kind can't be empty",
+				},
+			},
+		},
+		{
+			Name: "unauthorized",
+
+			Expect: test.Expect{
+				Status: http.StatusUnauthorized,
+				Body: responsebody.Message{
+					Message: "empty authorization header",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				expectCatalogName(mock, catalog.Activity, workout.Kind).
+					WillReturnRows(catalogRows("ACTIVITY_ID", catalog.Activity, workout.Kind))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertWorkoutQuery).
+					WithArgs(workout.UserID, "ACTIVITY_ID", workout.Date, workout.Duration, workout.Kind, nil, nil, nil, nil, nil, nil, nil, "").
+					WillReturnError(errors.New("repo: Some repository error"))
+				mock.ExpectRollback()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.CreateWorkout{
+					Date:     workout.Date.Format(layout),
+					Duration: workout.Duration,
+					Kind:     workout.Kind,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPost, "/api/workout", "/api/workout", handler.UserIdentity, handler.CreateWorkout)
+	}
+}
+
+func TestGetWorkout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	reps := 10
+	rest := 90
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				expectExercises(mock, "WORKOUT_ID")
+
+				mock.ExpectQuery("SELECT * FROM workout_laps WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+					WithArgs(pq.Array([]string{"WORKOUT_ID"})).
+					WillReturnRows(sqlmock.NewRows(lapColumns))
+
+				mock.ExpectQuery(countsQuery).
+					WithArgs(pq.Array([]string{"WORKOUT_ID"}), "USER_ID").
+					WillReturnRows(countsRows().AddRow("WORKOUT_ID", 3, 2))
+
+				expectUnits(mock, units.Metric)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Workout{
+					ID:         "WORKOUT_ID",
+					ActivityID: "ACTIVITY_ID",
+					Date:       "01-05-2024",
+					Duration:   69,
+					Kind:       "Calisthenics",
+					Exercises: []responsebody.Exercise{
+						{
+							Name: "Pull-up",
+							Sets: []responsebody.ExerciseSet{
+								{Reps: &reps},
+								{Reps: &reps, RestSeconds: &rest},
+							},
+						},
+					},
+					KudosCount:    3,
+					CommentsCount: 2,
+				},
+			},
+		},
+		{
+			Name: "not found",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "workout not found",
+				},
+			},
+		},
+		{
+			Name: "another user's workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("ANOTHER_USER_ID", date))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "forbidden to view workout",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodGet, "/api/workout/:id", "/api/workout/WORKOUT_ID", handler.UserIdentity, handler.GetWorkout)
+	}
+}
+
+func TestUpdateWorkout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	duration := 90
+	kind := "Running"
+	futureDate := time.Now().AddDate(0, 0, 2).Format("02-01-2006")
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				expectUnits(mock, units.Metric)
+
+				expectCatalogName(mock, catalog.Activity, kind).
+					WillReturnRows(catalogRows("RUNNING_ID", catalog.Activity, kind))
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "updated_at"}).
+					AddRow("WORKOUT_ID", "USER_ID", "RUNNING_ID", date, duration, kind, time.Now(), time.Now())
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(updateWorkoutQuery).
+					WithArgs("RUNNING_ID", date, duration, kind, nil, nil, nil, nil, nil, nil, nil, "", "WORKOUT_ID").
+					WillReturnRows(rows)
+				mock.ExpectCommit()
+
+				mock.ExpectQuery("SELECT * FROM workout_exercises WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+					WithArgs(pq.Array([]string{"WORKOUT_ID"})).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "workout_id", "position", "name"}))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateWorkout{
+					Duration: &duration,
+					Kind:     &kind,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Workout{
+					ID:         "WORKOUT_ID",
+					ActivityID: "RUNNING_ID",
+					Date:       "01-05-2024",
+					Duration:   duration,
+					Kind:       kind,
+				},
+			},
+		},
+		{
+			Name: "remove exercises",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(updateWorkoutQuery).
+					WithArgs("ACTIVITY_ID", date, 69, "Calisthenics", nil, nil, nil, nil, nil, nil, nil, "", "WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+				mock.ExpectExec("DELETE FROM workout_exercises WHERE workout_id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnResult(driver.RowsAffected(2))
+				mock.ExpectCommit()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateWorkout{
+					Exercises: []requestbody.Exercise{},
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+				Body: responsebody.Workout{
+					ID:         "WORKOUT_ID",
+					ActivityID: "ACTIVITY_ID",
+					Date:       "01-05-2024",
+					Duration:   69,
+					Kind:       "Calisthenics",
+				},
+			},
+		},
+		{
+			Name: "nothing to update",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateWorkout{},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "nothing to update",
+				},
+			},
+		},
+		{
+			Name: "date in the future",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				expectUnits(mock, units.Metric)
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateWorkout{
+					Date: &futureDate,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusBadRequest,
+				Body: responsebody.Message{
+					Message: "workout date can't be in the future",
+				},
+			},
+		},
+		{
+			Name: "another user's workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("ANOTHER_USER_ID", date))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateWorkout{
+					Duration: &duration,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "forbidden to update workout",
+				},
+			},
+		},
+		{
+			Name: "repository error",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				expectUnits(mock, units.Metric)
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(updateWorkoutQuery).
+					WithArgs("ACTIVITY_ID", date, duration, "Calisthenics", nil, nil, nil, nil, nil, nil, nil, "", "WORKOUT_ID").
+					WillReturnError(errors.New("repo: Some repository error"))
+				mock.ExpectRollback()
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+				Body: requestbody.UpdateWorkout{
+					Duration: &duration,
+				},
+			},
+
+			Expect: test.ResponseInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodPatch, "/api/workout/:id", "/api/workout/WORKOUT_ID", handler.UserIdentity, handler.UpdateWorkout)
+	}
+}
+
+func TestDeleteWorkout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []test.Case{
+		{
+			Name: "ok",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				mock.ExpectExec("DELETE FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "another user's workout",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("ANOTHER_USER_ID", date))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusForbidden,
+				Body: responsebody.Message{
+					Message: "forbidden to delete workout",
+				},
+			},
+		},
+		{
+			Name: "deleted concurrently",
+
+			Repo: func(mock sqlmock.Sqlmock) {
+				test.ExpectActiveSession(mock, "SESSION_ID")
+
+				mock.ExpectQuery("SELECT * FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnRows(workoutRows("USER_ID", date))
+
+				mock.ExpectExec("DELETE FROM workouts WHERE id = $1").
+					WithArgs("WORKOUT_ID").
+					WillReturnResult(driver.RowsAffected(0))
+			},
+
+			Request: test.Request{
+				Headers: map[string]string{
+					"Authorization": headerAuthorization,
+				},
+			},
+
+			Expect: test.Expect{
+				Status: http.StatusNotFound,
+				Body: responsebody.Message{
+					Message: "workout not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Endpoint(t, tc, mock, http.MethodDelete, "/api/workout/:id", "/api/workout/WORKOUT_ID", handler.UserIdentity, handler.DeleteWorkout)
+	}
+}
+
+func workoutRows(userID string, date time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at", "updated_at"}).
+		AddRow("WORKOUT_ID", userID, "ACTIVITY_ID", date, 69, "Calisthenics", time.Now(), time.Now())
+}
+
+func countsRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"workout_id", "kudos", "comments"})
+}
+
+func expectExercises(mock sqlmock.Sqlmock, workoutID string) {
+	mock.ExpectQuery("SELECT * FROM workout_exercises WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+		WithArgs(pq.Array([]string{workoutID})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workout_id", "position", "name"}).
+			AddRow("EXERCISE_ID", workoutID, 1, "Pull-up"))
+
+	mock.ExpectQuery("SELECT exercise_sets.* FROM exercise_sets JOIN workout_exercises ON workout_exercises.id = exercise_sets.exercise_id WHERE workout_exercises.workout_id = ANY($1) ORDER BY exercise_sets.exercise_id, exercise_sets.position").
+		WithArgs(pq.Array([]string{workoutID})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exercise_id", "position", "reps", "weight", "weight_unit", "rpe", "rest_seconds"}).
+			AddRow("FIRST_SET_ID", "EXERCISE_ID", 1, 10, nil, "", nil, nil).
+			AddRow("SECOND_SET_ID", "EXERCISE_ID", 2, 10, nil, "", nil, 90))
+}
+
+const (
+	historyQuery     = "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND ($4 = '' OR lower(kind) = lower($4)) AND ($5 = 0 OR duration >= $5) AND ($6 = 0 OR duration <= $6) AND ($7::date IS NULL OR (date, created_at, id) > ($7, $8::timestamp, $9::uuid)) ORDER BY date ASC, created_at ASC, id ASC LIMIT $10"
+	historyDescQuery = "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND ($4 = '' OR lower(kind) = lower($4)) AND ($5 = 0 OR duration >= $5) AND ($6 = 0 OR duration <= $6) AND ($7::date IS NULL OR (date, created_at, id) < ($7, $8::timestamp, $9::uuid)) ORDER BY date DESC, created_at DESC, id DESC LIMIT $10"
+)
+
+func TestGetActivityHistory(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("err not expected: %v\n", err)
+	}
+
+	tokenSecret := "some-supa-secret-characters"
+	c := config.Config{Token: config.Token{Secret: tokenSecret}}
+	repo := repository.New(sqlx.NewDb(db, "sqlmock"))
+	tokenManager := token.New(c.Token)
+	handler := New(&c, repo, mockmailer.New(), mocktoken.New(c.Token), password.NewArgon2id())
+
+	accessToken, err := tokenManager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	if err != nil {
+		t.Fatal("unexpected error while generating mock token")
+	}
+
+	headerAuthorization := fmt.Sprintf("Bearer %s", accessToken)
+
+	begin := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.May, 31, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC)
+	reps := 10
+	rest := 90
+
+	columns := []string{"id", "user_id", "activity_id", "date", "duration", "kind", "created_at"}
+
+	// cursors carry workout IDs, which are checked to be UUIDs
+	secondID := "9b2e6f0a-4c1d-4e8b-a3f5-7d6c2b1a0e9f"
+
+	nextCursor, err := cursor.Encode(historyCursor{Date: "2024-05-03", CreatedAt: createdAt, ID: secondID, Order: "desc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+	badIDCursor, err := cursor.Encode(historyCursor{Date: "2024-05-03", CreatedAt: createdAt, ID: "SECOND_ID", Order: "desc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+	ascCursor, err := cursor.Encode(historyCursor{Date: "2024-05-03", CreatedAt: createdAt, ID: secondID, Order: "asc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	tests := []struct {
+		query string
+		tc    test.Case
+	}{
+		{
+			tc: test.Case{
+				Name: "ok",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(historyQuery).
+						WithArgs("USER_ID", begin, end, "", 0, 0, nil, nil, nil, 51).
+						WillReturnRows(workoutRows("USER_ID", begin))
+
+					expectExercises(mock, "WORKOUT_ID")
+
+					mock.ExpectQuery(countsQuery).
+						WithArgs(pq.Array([]string{"WORKOUT_ID"}), "USER_ID").
+						WillReturnRows(countsRows())
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.ActivityHistory{
+						UserID: "USER_ID",
+						Count:  1,
+						Workouts: []responsebody.Workout{
+							{
+								ID:         "WORKOUT_ID",
+								ActivityID: "ACTIVITY_ID",
+								Date:       "01-05-2024",
+								Duration:   69,
+								Kind:       "Calisthenics",
+								Exercises: []responsebody.Exercise{
+									{
+										Name: "Pull-up",
+										Sets: []responsebody.ExerciseSet{
+											{Reps: &reps},
+											{Reps: &reps, RestSeconds: &rest},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "&kind=running&min_duration=20&max_duration=60&order=desc&limit=2",
+			tc: test.Case{
+				Name: "filtered page with next cursor",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(historyDescQuery).
+						WithArgs("USER_ID", begin, end, "running", 20, 60, nil, nil, nil, 3).
+						WillReturnRows(sqlmock.NewRows(columns).
+							AddRow("FIRST_ID", "USER_ID", "RUNNING_ID", begin.AddDate(0, 0, 3), 30, "Running", createdAt).
+							AddRow(secondID, "USER_ID", "RUNNING_ID", begin.AddDate(0, 0, 2), 45, "Running", createdAt).
+							AddRow("THIRD_ID", "USER_ID", "RUNNING_ID", begin.AddDate(0, 0, 1), 40, "Running", createdAt))
+
+					mock.ExpectQuery("SELECT * FROM workout_exercises WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+						WithArgs(pq.Array([]string{"FIRST_ID", secondID})).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "workout_id", "position", "name"}))
+
+					mock.ExpectQuery(countsQuery).
+						WithArgs(pq.Array([]string{"FIRST_ID", secondID}), "USER_ID").
+						WillReturnRows(countsRows().AddRow("FIRST_ID", 1, 0).AddRow(secondID, 0, 0))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.ActivityHistory{
+						UserID: "USER_ID",
+						Count:  2,
+						Workouts: []responsebody.Workout{
+							{ID: "FIRST_ID", ActivityID: "RUNNING_ID", Date: "04-05-2024", Duration: 30, Kind: "Running", KudosCount: 1},
+							{ID: secondID, ActivityID: "RUNNING_ID", Date: "03-05-2024", Duration: 45, Kind: "Running"},
+						},
+						NextCursor: nextCursor,
+					},
+				},
+			},
+		},
+		{
+			query: "&order=desc&limit=2&cursor=" + nextCursor,
+			tc: test.Case{
+				Name: "next page",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(historyDescQuery).
+						WithArgs("USER_ID", begin, end, "", 0, 0, begin.AddDate(0, 0, 2), createdAt, secondID, 3).
+						WillReturnRows(sqlmock.NewRows(columns))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusOK,
+					Body: responsebody.ActivityHistory{
+						UserID:   "USER_ID",
+						Count:    0,
+						Workouts: []responsebody.Workout{},
+					},
+				},
+			},
+		},
+		{
+			query: "&order=desc&cursor=" + ascCursor,
+			tc: test.Case{
+				Name: "cursor of another order",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid cursor",
+					},
+				},
+			},
+		},
+		{
+			query: "&order=desc&cursor=" + badIDCursor,
+			tc: test.Case{
+				Name: "cursor with malformed id",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid cursor",
+					},
+				},
+			},
+		},
+		{
+			query: "&cursor=garbage",
+			tc: test.Case{
+				Name: "malformed cursor",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid cursor",
+					},
+				},
+			},
+		},
+		{
+			query: "&order=newest",
+			tc: test.Case{
+				Name: "invalid order",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "order should be asc or desc",
+					},
+				},
+			},
+		},
+		{
+			query: "&limit=500",
+			tc: test.Case{
+				Name: "invalid limit",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "invalid limit",
+					},
+				},
+			},
+		},
+		{
+			query: "&min_duration=60&max_duration=30",
+			tc: test.Case{
+				Name: "invalid duration range",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.Expect{
+					Status: http.StatusBadRequest,
+					Body: responsebody.Message{
+						Message: "max_duration can't be less than min_duration",
+					},
+				},
+			},
+		},
+		{
+			tc: test.Case{
+				Name: "repository error",
+
+				Repo: func(mock sqlmock.Sqlmock) {
+					test.ExpectActiveSession(mock, "SESSION_ID")
+
+					expectUnits(mock, units.Metric)
+
+					mock.ExpectQuery(historyQuery).
+						WithArgs("USER_ID", begin, end, "", 0, 0, nil, nil, nil, 51).
+						WillReturnRows(workoutRows("USER_ID", begin))
+
+					mock.ExpectQuery("SELECT * FROM workout_exercises WHERE workout_id = ANY($1) ORDER BY workout_id, position").
+						WithArgs(pq.Array([]string{"WORKOUT_ID"})).
+						WillReturnError(errors.New("repo: Some repository error"))
+				},
+
+				Request: test.Request{
+					Headers: map[string]string{
+						"Authorization": headerAuthorization,
+					},
+				},
+
+				Expect: test.ResponseInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		test.Endpoint(t, tt.tc, mock, http.MethodGet, "/api/activity", "/api/activity?begin=01-05-2024&end=31-05-2024"+tt.query, handler.UserIdentity, handler.GetActivityHistory)
+	}
+}
+
+func expectUnits(mock sqlmock.Sqlmock, system string) {
+	mock.ExpectQuery("SELECT * FROM users WHERE id = $1").
+		WithArgs("USER_ID").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "units"}).AddRow("USER_ID", system))
 }
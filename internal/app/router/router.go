@@ -2,28 +2,44 @@ package router
 
 import (
 	"api/internal/app/handler"
+	"api/internal/app/handler/role"
+	"api/internal/app/handler/scope"
 	"api/internal/config"
 	"api/internal/mailer"
 	"api/internal/repository"
 	"api/internal/token"
+	"api/pkg/password"
+	"api/pkg/ratelimit"
 	"api/pkg/requestid"
 	"api/pkg/requestlog"
+	"api/pkg/sha256"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	files "github.com/swaggo/files"
 	swaggin "github.com/swaggo/gin-swagger"
 )
 
+var (
+	defaultLoginLimit         = ratelimit.Rule{Limit: 20, Period: time.Minute}
+	defaultLoginAccountLimit  = ratelimit.Rule{Limit: 10, Period: 15 * time.Minute}
+	defaultPasswordResetLimit = ratelimit.Rule{Limit: 5, Period: time.Hour}
+)
+
 type Router struct {
 	config  *config.Config
 	handler *handler.Handler
+	token   token.Manager
+	limiter ratelimit.Limiter
 }
 
-func New(c *config.Config, r *repository.Repository, m mailer.Mailer, t token.Manager) *Router {
-	h := handler.New(c, r, m, t)
+func New(c *config.Config, r *repository.Repository, m mailer.Mailer, t token.Manager, p password.Hasher, l ratelimit.Limiter) *Router {
+	h := handler.New(c, r, m, t, p)
 	return &Router{
 		config:  c,
 		handler: h,
+		token:   t,
+		limiter: l,
 	}
 }
 
@@ -52,6 +68,8 @@ func (r *Router) InitRoutes() *gin.Engine {
 		})
 	}
 
+	router.GET("/.well-known/jwks.json", r.handler.GetJWKS)
+
 	api := router.Group("/api")
 	{
 		switch r.config.Env {
@@ -65,29 +83,150 @@ func (r *Router) InitRoutes() *gin.Engine {
 
 		api.GET("/healthcheck", r.handler.Healthcheck)
 
-		api.POST("/auth/session", r.handler.CreateSession)
+		login := api.Group("",
+			r.limit("login", r.config.RateLimit.Login, defaultLoginLimit, ratelimit.ByIP),
+			r.limit("login_account", r.config.RateLimit.LoginAccount, defaultLoginAccountLimit, ratelimit.ByJSONField("login")),
+		)
+		{
+			login.POST("/auth/session", r.handler.CreateSession)
+			login.POST("/auth/oauth/:provider/callback", r.handler.CompleteOAuth)
+		}
+
+		api.POST("/auth/session/2fa",
+			r.limit("login", r.config.RateLimit.Login, defaultLoginLimit, ratelimit.ByIP),
+			r.limit("two_factor", r.config.RateLimit.LoginAccount, defaultLoginAccountLimit, ratelimit.ByJSONFieldFunc("challenge_token", r.challengeUserID)),
+			r.handler.CompleteTwoFactor,
+		)
+
+		api.GET("/auth/oauth", r.handler.GetOAuthProviders)
+		api.POST("/auth/oauth/:provider", r.handler.StartOAuth)
+
+		api.DELETE("/auth/session", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.DeleteSession)
+		api.DELETE("/auth/sessions", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.DeleteAllSessions)
+		api.POST("/auth/refresh", r.handler.RefreshSession)
 		api.POST("/auth/account", r.handler.CreateAccount)
 
 		api.Static("/avatar", ".database/avatars")
-		api.PATCH("/account/avatar", r.handler.UserIdentity, r.handler.UploadAvatar)
-		api.DELETE("/account/avatar", r.handler.UserIdentity, r.handler.DeleteAvatar)
+		api.PATCH("/account/avatar", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.UploadAvatar)
+		api.DELETE("/account/avatar", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.DeleteAvatar)
 
-		api.GET("/account", r.handler.UserIdentity, r.handler.GetCurrentAccount)
-		api.PATCH("/account", r.handler.UserIdentity, r.handler.UpdateAccount)
+		api.GET("/account", r.handler.UserIdentity, r.handler.RequireScope(scope.ProfileRead), r.handler.GetCurrentAccount)
+		api.PATCH("/account", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.UpdateAccount)
+
+		api.GET("/account/follow-requests", r.handler.UserIdentity, r.handler.RequireScope(scope.ProfileRead), r.handler.GetFollowRequests)
+		api.POST("/account/follow-requests/:username", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.ApproveFollowRequest)
+		api.DELETE("/account/follow-requests/:username", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.RejectFollowRequest)
+		api.DELETE("/account/followers/:username", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.RemoveFollower)
+
+		api.GET("/account/blocks", r.handler.UserIdentity, r.handler.RequireScope(scope.ProfileRead), r.handler.GetBlockedUsers)
+		api.GET("/account/mutes", r.handler.UserIdentity, r.handler.RequireScope(scope.ProfileRead), r.handler.GetMutedUsers)
+
+		api.GET("/account/sessions", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.GetSessions)
+
+		twoFactor := r.limit("two_factor", r.config.RateLimit.LoginAccount, defaultLoginAccountLimit, userID)
+		api.POST("/account/2fa", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), twoFactor, r.handler.SetupTwoFactor)
+		api.POST("/account/2fa/verify", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), twoFactor, r.handler.VerifyTwoFactor)
+		api.DELETE("/account/2fa", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), twoFactor, r.handler.DisableTwoFactor)
+
+		api.GET("/account/identities", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.GetIdentities)
+		api.POST("/account/identities/:provider", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.LinkIdentity)
+		api.POST("/account/identities/:provider/callback", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.CompleteLinkIdentity)
+		api.DELETE("/account/identities/:provider", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.UnlinkIdentity)
+
+		api.POST("/account/api-keys", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.CreateAPIKey)
+		api.GET("/account/api-keys", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.GetAPIKeys)
+		api.DELETE("/account/api-keys/:id", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.DeleteAPIKey)
 
 		api.POST("/account/confirm", r.handler.ConfirmAccount)
 
-		api.POST("/account/reset-password/request", r.handler.ResetPassword)
-		api.PATCH("/account/reset-password", r.handler.UpdatePassword)
+		passwordReset := api.Group("",
+			r.limit("password_reset", r.config.RateLimit.PasswordReset, defaultPasswordResetLimit, ratelimit.ByIP),
+			r.limit("password_reset_email", r.config.RateLimit.PasswordReset, defaultPasswordResetLimit, ratelimit.ByJSONField("email")),
+		)
+		{
+			passwordReset.POST("/account/reset-password/request", r.handler.ResetPassword)
+		}
 
-		api.POST("/workout", r.handler.UserIdentity, r.handler.CreateWorkout)
-		api.DELETE("/workout/:id", r.handler.UserIdentity, r.handler.DeleteWorkout)
+		api.PATCH("/account/reset-password", r.handler.UpdatePassword)
 
-		api.GET("/activity", r.handler.UserIdentity, r.handler.GetActivityHistory)
-		api.GET("/statistics", r.handler.UserIdentity, r.handler.GetStatistics)
+		api.POST("/workout", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.CreateWorkout)
+		api.POST("/workout/import", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.ImportWorkout)
+		api.POST("/workout/import/csv", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.ImportWorkoutsCSV)
+		api.GET("/workout/:id", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsRead), r.handler.GetWorkout)
+		api.PATCH("/workout/:id", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.UpdateWorkout)
+		api.DELETE("/workout/:id", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.DeleteWorkout)
+		api.GET("/workout/:id/track", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsRead), r.handler.GetWorkoutTrack)
+		api.GET("/workout/:id/kudos", r.handler.OptionalUserIdentity, r.handler.GetKudos)
+		api.POST("/workout/:id/kudos", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.GiveKudos)
+		api.DELETE("/workout/:id/kudos", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.RemoveKudos)
+		api.GET("/workout/:id/comments", r.handler.OptionalUserIdentity, r.handler.GetComments)
+		api.POST("/workout/:id/comments", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.CreateComment)
+		api.PATCH("/workout/:id/comments/:comment_id", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.UpdateComment)
+		api.DELETE("/workout/:id/comments/:comment_id", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.DeleteComment)
+
+		api.GET("/activity", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsRead), r.handler.GetActivityHistory)
+		api.GET("/activity/export", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsRead), r.handler.ExportWorkouts)
+		api.GET("/statistics", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsRead), r.handler.GetStatistics)
+		api.GET("/feed", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.GetFeed)
+
+		api.GET("/catalog", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsRead), r.handler.GetCatalog)
+		api.POST("/catalog", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.CreateCatalogEntry)
+		api.DELETE("/catalog/:id", r.handler.UserIdentity, r.handler.RequireScope(scope.WorkoutsWrite), r.handler.DeleteCatalogEntry)
+
+		admin := api.Group("/admin", r.handler.UserIdentity, r.handler.RequireScope(scope.Account), r.handler.RequireRole(role.Moderator))
+		{
+			admin.GET("/users", r.handler.GetUsers)
+			admin.POST("/users/:id/confirmation", r.handler.RequireRole(role.Admin), r.handler.ConfirmUser)
+			admin.POST("/users/:id/suspension", r.handler.SuspendUser)
+			admin.DELETE("/users/:id/suspension", r.handler.UnsuspendUser)
+			admin.DELETE("/users/:id/avatar", r.handler.ResetUserAvatar)
+			admin.PATCH("/users/:id/role", r.handler.RequireRole(role.Admin), r.handler.UpdateUserRole)
+
+			admin.DELETE("/workouts/:id", r.handler.DeleteUserWorkout)
+		}
 
-		api.GET("/user/:username", r.handler.GetUserByUsername)
+		api.GET("/user/:username", r.handler.OptionalUserIdentity, r.handler.GetUserByUsername)
+		api.GET("/user/:username/followers", r.handler.OptionalUserIdentity, r.handler.GetFollowers)
+		api.GET("/user/:username/following", r.handler.OptionalUserIdentity, r.handler.GetFollowing)
+		api.POST("/user/:username/follow", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.FollowUser)
+		api.DELETE("/user/:username/follow", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.UnfollowUser)
+		api.POST("/user/:username/block", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.BlockUser)
+		api.DELETE("/user/:username/block", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.UnblockUser)
+		api.POST("/user/:username/mute", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.MuteUser)
+		api.DELETE("/user/:username/mute", r.handler.UserIdentity, r.handler.RequireScope(scope.Social), r.handler.UnmuteUser)
 	}
 
 	return router
 }
+
+// challengeUserID limits two-factor attempts by the user a challenge token was
+// issued for, so requesting new challenges doesn't reset the limit
+func (r *Router) challengeUserID(challenge string) string {
+	claims, err := r.token.ParseChallenge(challenge)
+	if err != nil {
+		return ""
+	}
+	return claims.UserID
+}
+
+// userID limits requests by the authenticated user. Keys are hashed the same
+// way as by ratelimit.ByJSONFieldFunc, so two-factor attempts of a user share
+// a bucket whether they come with a challenge or an access token
+func userID(c *gin.Context) string {
+	id := c.GetString("UserID")
+	if id == "" {
+		return ""
+	}
+	return sha256.String(id)
+}
+
+// limit returns a rate limiting middleware for given limit from config,
+// falling back to def when the limit is not configured
+func (r *Router) limit(name string, l config.Limit, def ratelimit.Rule, key ratelimit.KeyFunc) gin.HandlerFunc {
+	rule := ratelimit.Rule{Limit: l.Requests, Period: l.Period}
+	if l.Requests == 0 || l.Period <= 0 {
+		rule = def
+	}
+
+	return ratelimit.New(r.limiter, name, rule, key)
+}
@@ -17,12 +17,16 @@ const (
 )
 
 type Config struct {
-	Env      string   `yaml:"env" env-required:"true"`
-	BasePath string   `yaml:"basepath" env-required:"true"`
-	Server   Server   `yaml:"server" env-required:"true"`
-	Mail     Mail     `yaml:"mail" env-required:"true"`
-	Token    Token    `yaml:"token" env-required:"true"`
-	Postgres Postgres `yaml:"postgres" env-required:"true"`
+	Env       string    `yaml:"env" env-required:"true"`
+	BasePath  string    `yaml:"basepath" env-required:"true"`
+	Server    Server    `yaml:"server" env-required:"true"`
+	Mail      Mail      `yaml:"mail" env-required:"true"`
+	Token     Token     `yaml:"token" env-required:"true"`
+	Password  Password  `yaml:"password"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Lockout   Lockout   `yaml:"lockout"`
+	OAuth     OAuth     `yaml:"oauth"`
+	Postgres  Postgres  `yaml:"postgres" env-required:"true"`
 }
 
 type Server struct {
@@ -43,7 +47,69 @@ type SMTP struct {
 }
 
 type Token struct {
-	Secret string `yaml:"secret" env-required:"true"`
+	Algorithm  string        `yaml:"algorithm" env-default:"HS256"` // HS256, RS256 or EdDSA
+	Secret     string        `yaml:"secret"`                        // HS256 only
+	Keys       []TokenKey    `yaml:"keys"`                          // RS256 and EdDSA only
+	AccessTTL  time.Duration `yaml:"access_ttl" env-default:"15m"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env-default:"720h"`
+}
+
+// TokenKey describes a private key, that signs tokens starting from ActiveFrom.
+// Keys stay valid for verification until removed from configuration
+type TokenKey struct {
+	ID             string    `yaml:"id"`
+	PrivateKeyFile string    `yaml:"private_key_file"`
+	ActiveFrom     time.Time `yaml:"active_from"`
+}
+
+type Password struct {
+	Algorithm string   `yaml:"algorithm" env-default:"argon2id"` // argon2id or bcrypt
+	Argon2id  Argon2id `yaml:"argon2id"`
+	Bcrypt    Bcrypt   `yaml:"bcrypt"`
+}
+
+type Argon2id struct {
+	Memory      uint32 `yaml:"memory" env-default:"19456"`
+	Iterations  uint32 `yaml:"iterations" env-default:"2"`
+	Parallelism uint8  `yaml:"parallelism" env-default:"1"`
+}
+
+type Bcrypt struct {
+	Cost int `yaml:"cost" env-default:"12"`
+}
+
+type RateLimit struct {
+	Backend       string `yaml:"backend" env-default:"memory"` // memory or postgres
+	Login         Limit  `yaml:"login"`                        // per client IP
+	LoginAccount  Limit  `yaml:"login_account"`                // per login identifier
+	PasswordReset Limit  `yaml:"password_reset"`               // per client IP and per email
+}
+
+// Limit allows Requests per Period. Zero value falls back to a default,
+// negative Requests disables the limit
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+}
+
+type Lockout struct {
+	Threshold   int           `yaml:"threshold" env-default:"5"` // failed attempts before a lock, zero disables lockout
+	Duration    time.Duration `yaml:"duration" env-default:"1m"` // doubled on every subsequent lock
+	MaxDuration time.Duration `yaml:"max_duration" env-default:"24h"`
+}
+
+type OAuth struct {
+	Providers []OAuthProvider `yaml:"providers"`
+}
+
+type OAuthProvider struct {
+	Name         string   `yaml:"name"`
+	Kind         string   `yaml:"kind"`   // oidc (default) or github
+	Issuer       string   `yaml:"issuer"` // used for OIDC discovery
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
 }
 
 type Postgres struct {
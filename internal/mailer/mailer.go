@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"time"
 )
 
 type ConfirmationEmailData struct {
@@ -22,10 +23,15 @@ type EmailChanedEmailData struct {
 	UpdatedEmail string
 }
 
+type LockoutEmailData struct {
+	LockedUntil string
+}
+
 type Mailer interface {
 	SendRecoveryEmail(recepient string, token string) error
 	SendConfirmationEmail(recepient string, token string) error
 	SendSecurityEmail(recepient string, updatedEmail string) error
+	SendLockoutEmail(recepient string, lockedUntil time.Time) error
 	Send(recepient string, subject string, body string) error
 }
 
@@ -98,6 +104,25 @@ func (s *Sender) SendSecurityEmail(recepient string, updatedEmail string) error
 	return s.Send(recepient, "yodreik: Security alert", buf.String())
 }
 
+func (s *Sender) SendLockoutEmail(recepient string, lockedUntil time.Time) error {
+	tmpl, err := template.ParseFiles("templates/lockout_email.html")
+	if err != nil {
+		return fmt.Errorf("Error parsing template: %v", err)
+	}
+
+	data := LockoutEmailData{
+		LockedUntil: lockedUntil.UTC().Format("02 Jan 2006 15:04 MST"),
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return fmt.Errorf("Error executing template: %v", err)
+	}
+
+	return s.Send(recepient, "yodreik: Account temporarily locked", buf.String())
+}
+
 func (s *Sender) Send(recepient string, subject string, body string) error {
 	auth := smtp.PlainAuth("", s.config.Mail.Address, s.config.Mail.Password, s.config.Mail.SMTP.Address)
 
@@ -1,5 +1,7 @@
 package mock
 
+import "time"
+
 type MockMailer struct {
 	SentEmails []string
 }
@@ -25,6 +27,11 @@ func (mm *MockMailer) SendSecurityEmail(recepient string, token string) error {
 	return nil
 }
 
+func (mm *MockMailer) SendLockoutEmail(recepient string, lockedUntil time.Time) error {
+	mm.SentEmails = append(mm.SentEmails, recepient)
+	return nil
+}
+
 func (mm *MockMailer) Send(recepient string, subject string, body string) error {
 	mm.SentEmails = append(mm.SentEmails, recepient)
 	return nil
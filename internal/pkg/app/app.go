@@ -9,6 +9,8 @@ import (
 	"api/internal/repository"
 	"api/internal/repository/postgres"
 	"api/internal/token"
+	"api/pkg/password"
+	"api/pkg/ratelimit"
 	"context"
 	"errors"
 	"log/slog"
@@ -64,9 +66,49 @@ func (a *App) Run() {
 
 	repo := repository.New(db)
 	m := mailer.New(a.config)
-	tokenManager := token.New(a.config.Token)
 
-	r := router.New(a.config, repo, m, tokenManager)
+	var tokenManager token.Manager
+	switch a.config.Token.Algorithm {
+	case token.AlgorithmRS256, token.AlgorithmEdDSA:
+		manager, err := token.NewAsymmetric(a.config.Token)
+		if err != nil {
+			slog.Error("could not load token signing keys", sl.Err(err))
+			os.Exit(1)
+		}
+
+		tokenManager = manager
+	default:
+		if a.config.Token.Secret == "" {
+			slog.Error("token secret is required for HS256 signing")
+			os.Exit(1)
+		}
+
+		tokenManager = token.New(a.config.Token)
+	}
+
+	var hasher password.Hasher
+	switch a.config.Password.Algorithm {
+	case "bcrypt":
+		hasher = password.NewBcrypt(a.config.Password.Bcrypt.Cost)
+	default:
+		hasher = &password.Argon2id{
+			Memory:      a.config.Password.Argon2id.Memory,
+			Iterations:  a.config.Password.Argon2id.Iterations,
+			Parallelism: a.config.Password.Argon2id.Parallelism,
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+	}
+
+	var limiter ratelimit.Limiter
+	switch a.config.RateLimit.Backend {
+	case "postgres":
+		limiter = repo.RateLimit
+	default:
+		limiter = ratelimit.NewMemory()
+	}
+
+	r := router.New(a.config, repo, m, tokenManager, hasher, limiter)
 
 	server := &http.Server{
 		Addr:         a.config.Server.Address,
@@ -95,6 +137,29 @@ func (a *App) Run() {
 			} else if n > 0 {
 				slog.Debug("expired records deleted", slog.Int64("count", n))
 			}
+
+			n, err = repo.Session.RemoveExpiredRecords(ctx)
+			if err != nil {
+				slog.Error("failed to delete expired sessions", sl.Err(err))
+			} else if n > 0 {
+				slog.Debug("expired sessions deleted", slog.Int64("count", n))
+			}
+
+			n, err = repo.Identity.RemoveExpiredRecords(ctx)
+			if err != nil {
+				slog.Error("failed to delete expired sign in states", sl.Err(err))
+			} else if n > 0 {
+				slog.Debug("expired sign in states deleted", slog.Int64("count", n))
+			}
+
+			if a.config.RateLimit.Backend == "postgres" {
+				n, err = repo.RateLimit.RemoveExpiredRecords(ctx)
+				if err != nil {
+					slog.Error("failed to delete stale rate limits", sl.Err(err))
+				} else if n > 0 {
+					slog.Debug("stale rate limits deleted", slog.Int64("count", n))
+				}
+			}
 			time.Sleep(1 * time.Hour)
 		}
 	}()
@@ -1,29 +1,201 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type User struct {
-	ID                string    `db:"id"`
-	Email             string    `db:"email"`
-	Username          string    `db:"username"`
-	DisplayName       string    `db:"display_name"`
-	AvatarURL         string    `db:"avatar_url"`
-	PasswordHash      string    `db:"password_hash"`
-	IsPrivate         bool      `db:"is_private"`
-	IsConfirmed       bool      `db:"is_confirmed"`
-	ConfirmationToken string    `db:"confirmation_token"`
-	CreatedAt         time.Time `db:"created_at"`
+	ID                  string     `db:"id"`
+	Email               string     `db:"email"`
+	Username            string     `db:"username"`
+	DisplayName         string     `db:"display_name"`
+	AvatarURL           string     `db:"avatar_url"`
+	PasswordHash        string     `db:"password_hash"`
+	IsPrivate           bool       `db:"is_private"`
+	IsConfirmed         bool       `db:"is_confirmed"`
+	ConfirmationToken   string     `db:"confirmation_token"`
+	CreatedAt           time.Time  `db:"created_at"`
+	TOTPSecret          string     `db:"totp_secret"`
+	IsTOTPEnabled       bool       `db:"is_totp_enabled"`
+	TOTPLastCounter     int64      `db:"totp_last_counter"`
+	FailedLoginAttempts int        `db:"failed_login_attempts"`
+	LockedUntil         *time.Time `db:"locked_until"`
+	Role                string     `db:"role"`
+	SuspendedAt         *time.Time `db:"suspended_at"`
+	Units               string     `db:"units"`
+	TimeZone            string     `db:"time_zone"`
+	DefaultVisibility   string     `db:"default_visibility"`
 }
 
 type Workout struct {
-	ID        string    `db:"id"`
-	UserID    string    `db:"user_id"`
-	Date      time.Time `db:"date"`
-	Duration  int       `db:"duration"`
-	Kind      string    `db:"kind"`
+	ID         string     `db:"id"`
+	UserID     string     `db:"user_id"`
+	ActivityID string     `db:"activity_id"`
+	Date       time.Time  `db:"date"`
+	StartedAt  *time.Time `db:"started_at"`
+	Duration   int        `db:"duration"`
+	Kind       string     `db:"kind"`
+	Visibility string     `db:"visibility"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+	Exercises  []Exercise `db:"-"`
+	Laps       []Lap      `db:"-"`
+	Track      *Track     `db:"-"`
+	Counts     Counts     `db:"-"`
+	Metrics
+}
+
+// Counts are numbers of kudos and comments of a workout
+type Counts struct {
+	WorkoutID string `db:"workout_id"`
+	Kudos     int    `db:"kudos"`
+	Comments  int    `db:"comments"`
+}
+
+// AccountUpdate lists settings of an account, that are changed together with
+// the user. Nil fields are left as they are
+type AccountUpdate struct {
+	Units             *string
+	TimeZone          *string
+	DefaultVisibility *string
+
+	// ApplyToWorkouts changes visibility of existing workouts to the new
+	// default one
+	ApplyToWorkouts bool
+
+	// AcceptFollows accepts pending follow requests, as public users don't
+	// approve follows
+	AcceptFollows bool
+}
+
+// WorkoutFilter narrows and orders a page of user's workouts. Zero values
+// of optional fields disable them
+type WorkoutFilter struct {
+	Begin       time.Time
+	End         time.Time
+	Kind        string
+	MinDuration int
+	MaxDuration int
+	Descending  bool
+	After       *WorkoutPosition
+	Limit       int
+}
+
+// WorkoutPosition is a place of a workout in the order of pages, as a date
+// alone doesn't identify a workout
+type WorkoutPosition struct {
+	Date      time.Time
+	CreatedAt time.Time
+	ID        string
+}
+
+// FeedWorkout is a workout of a followed user together with a summary of
+// its author
+type FeedWorkout struct {
+	Workout
+	Author
+}
+
+// Author is a summary of a user, joined to a record the user created
+type Author struct {
+	Username    string `db:"author_username"`
+	DisplayName string `db:"author_display_name"`
+	AvatarURL   string `db:"author_avatar_url"`
+}
+
+// WorkoutSummary aggregates workouts of a date range. Distance is in meters
+type WorkoutSummary struct {
+	Count         int        `db:"count"`
+	Minutes       int        `db:"minutes"`
+	Longest       int        `db:"longest"`
+	Distance      float64    `db:"distance"`
+	DistanceCount int        `db:"distance_count"`
+	ActiveDays    int        `db:"active_days"`
+	FirstDate     *time.Time `db:"first_date"`
+}
+
+// KindSummary aggregates workouts of a single kind. Best pace is in seconds
+// per meter
+type KindSummary struct {
+	Kind     string   `db:"kind"`
+	Count    int      `db:"count"`
+	Minutes  int      `db:"minutes"`
+	Distance float64  `db:"distance"`
+	BestPace *float64 `db:"best_pace"`
+}
+
+// PeriodSummary aggregates workouts of a day, week, month or year, that
+// starts at Start
+type PeriodSummary struct {
+	Start    time.Time `db:"start"`
+	Count    int       `db:"count"`
+	Minutes  int       `db:"minutes"`
+	Distance float64   `db:"distance"`
+}
+
+// Streaks are lengths of runs of consecutive days with workouts. The current
+// one ends today or yesterday, as today may be not over yet
+type Streaks struct {
+	Current int `db:"current"`
+	Longest int `db:"longest"`
+}
+
+// Track is a raw file of a recorded activity, that a workout was imported
+// from
+type Track struct {
+	WorkoutID string    `db:"workout_id"`
+	Format    string    `db:"format"`
+	Data      []byte    `db:"data"`
 	CreatedAt time.Time `db:"created_at"`
 }
 
+// Lap is a part of an imported workout, as split by the device. Duration
+// is in seconds, distance is in meters
+type Lap struct {
+	ID           string    `db:"id"`
+	WorkoutID    string    `db:"workout_id"`
+	Position     int       `db:"position"`
+	StartTime    time.Time `db:"start_time"`
+	Duration     int       `db:"duration"`
+	Distance     *float64  `db:"distance"`
+	AvgHeartRate *int      `db:"avg_heart_rate"`
+	MaxHeartRate *int      `db:"max_heart_rate"`
+	Calories     *int      `db:"calories"`
+}
+
+// Metrics describes optional endurance metrics of a workout. Distance and
+// elevation gain are stored in meters
+type Metrics struct {
+	Distance      *float64 `db:"distance"`
+	ElevationGain *float64 `db:"elevation_gain"`
+	AvgHeartRate  *int     `db:"avg_heart_rate"`
+	MaxHeartRate  *int     `db:"max_heart_rate"`
+	Cadence       *int     `db:"cadence"`
+	Calories      *int     `db:"calories"`
+}
+
+type Exercise struct {
+	ID         string        `db:"id"`
+	WorkoutID  string        `db:"workout_id"`
+	ExerciseID *string       `db:"exercise_id"`
+	Position   int           `db:"position"`
+	Name       string        `db:"name"`
+	Sets       []ExerciseSet `db:"-"`
+}
+
+type ExerciseSet struct {
+	ID          string   `db:"id"`
+	ExerciseID  string   `db:"exercise_id"`
+	Position    int      `db:"position"`
+	Reps        *int     `db:"reps"`
+	Weight      *float64 `db:"weight"`
+	WeightUnit  string   `db:"weight_unit"`
+	RPE         *float64 `db:"rpe"`
+	RestSeconds *int     `db:"rest_seconds"`
+}
+
 type Request struct {
 	ID        string    `db:"id"`
 	Email     string    `db:"email"`
@@ -32,3 +204,114 @@ type Request struct {
 	ExpiresAt time.Time `db:"expires_at"`
 	CreatedAt time.Time `db:"created_at"`
 }
+
+type Session struct {
+	ID               string    `db:"id"`
+	FamilyID         string    `db:"family_id"`
+	UserID           string    `db:"user_id"`
+	RefreshTokenHash string    `db:"refresh_token_hash"`
+	IsUsed           bool      `db:"is_used"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	CreatedAt        time.Time `db:"created_at"`
+	Device           string    `db:"device"`
+	UserAgent        string    `db:"user_agent"`
+	IPAddress        string    `db:"ip_address"`
+	LastSeenAt       time.Time `db:"last_seen_at"`
+}
+
+type UserIdentity struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Provider  string    `db:"provider"`
+	Subject   string    `db:"subject"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type OAuthState struct {
+	State        string    `db:"state"`
+	Provider     string    `db:"provider"`
+	CodeVerifier string    `db:"code_verifier"`
+	Nonce        string    `db:"nonce"`
+	UserID       string    `db:"user_id"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// CatalogEntry describes an activity type or an exercise. Built-in entries
+// have no owner, custom ones are visible to their owner only
+type CatalogEntry struct {
+	ID           string         `db:"id"`
+	UserID       *string        `db:"user_id"`
+	Type         string         `db:"type"`
+	Name         string         `db:"name"`
+	Category     string         `db:"category"`
+	MuscleGroups pq.StringArray `db:"muscle_groups"`
+	Aliases      pq.StringArray `db:"aliases"`
+	MET          float64        `db:"met"`
+	CreatedAt    time.Time      `db:"created_at"`
+}
+
+type APIKey struct {
+	ID         string         `db:"id"`
+	UserID     string         `db:"user_id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// Follow is a relation of a follower to a followed user. Follows of private
+// users are requests, until they are accepted
+type Follow struct {
+	FollowerID string    `db:"follower_id"`
+	FolloweeID string    `db:"followee_id"`
+	IsAccepted bool      `db:"is_accepted"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// FollowUser is a user on the other side of a follow
+type FollowUser struct {
+	ID          string    `db:"id"`
+	Username    string    `db:"username"`
+	DisplayName string    `db:"display_name"`
+	AvatarURL   string    `db:"avatar_url"`
+	FollowedAt  time.Time `db:"followed_at"`
+}
+
+type FollowCounts struct {
+	Followers int `db:"followers"`
+	Following int `db:"following"`
+}
+
+// ListedUser is a user on a block or a mute list of another user
+type ListedUser struct {
+	ID          string    `db:"id"`
+	Username    string    `db:"username"`
+	DisplayName string    `db:"display_name"`
+	AvatarURL   string    `db:"avatar_url"`
+	ListedAt    time.Time `db:"listed_at"`
+}
+
+type Kudos struct {
+	WorkoutID string    `db:"workout_id"`
+	UserID    string    `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+	Author
+}
+
+// Comment is a comment on a workout or a reply to another comment of the
+// same workout
+type Comment struct {
+	ID        string     `db:"id"`
+	WorkoutID string     `db:"workout_id"`
+	UserID    string     `db:"user_id"`
+	ParentID  *string    `db:"parent_id"`
+	Text      string     `db:"text"`
+	EditedAt  *time.Time `db:"edited_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	Author
+}
@@ -3,8 +3,29 @@ package errors
 import "errors"
 
 var (
-	ErrUserNotFound      = errors.New("repository.User: user not found")
-	ErrUserAlreadyExists = errors.New("repository.User: user already exists")
-	ErrRequestNotFound   = errors.New("repository.User: request not found")
-	ErrWorkoutNotFound   = errors.New("repository.Workout: workout not found")
+	ErrUserNotFound          = errors.New("repository.User: user not found")
+	ErrUserAlreadyExists     = errors.New("repository.User: user already exists")
+	ErrRequestNotFound       = errors.New("repository.User: request not found")
+	ErrRecoveryCodeNotFound  = errors.New("repository.User: recovery code not found")
+	ErrTOTPCodeUsed          = errors.New("repository.User: TOTP code already used")
+	ErrChallengeUsed         = errors.New("repository.User: challenge already used")
+	ErrWorkoutNotFound       = errors.New("repository.Workout: workout not found")
+	ErrTrackNotFound         = errors.New("repository.Workout: track not found")
+	ErrSessionNotFound       = errors.New("repository.Session: session not found")
+	ErrIdentityNotFound      = errors.New("repository.Identity: identity not found")
+	ErrIdentityAlreadyExists = errors.New("repository.Identity: identity already exists")
+	ErrAPIKeyNotFound        = errors.New("repository.APIKey: API key not found")
+	ErrOAuthStateNotFound    = errors.New("repository.Identity: state not found")
+	ErrCatalogEntryNotFound  = errors.New("repository.Catalog: entry not found")
+	ErrCatalogEntryExists    = errors.New("repository.Catalog: entry already exists")
+	ErrCatalogEntryInUse     = errors.New("repository.Catalog: entry is in use")
+	ErrFollowNotFound        = errors.New("repository.Follow: follow not found")
+	ErrFollowExists          = errors.New("repository.Follow: follow already exists")
+	ErrKudosNotFound         = errors.New("repository.Kudos: kudos not found")
+	ErrKudosExists           = errors.New("repository.Kudos: kudos already exists")
+	ErrCommentNotFound       = errors.New("repository.Comment: comment not found")
+	ErrBlockNotFound         = errors.New("repository.Block: block not found")
+	ErrBlockExists           = errors.New("repository.Block: block already exists")
+	ErrMuteNotFound          = errors.New("repository.Mute: mute not found")
+	ErrMuteExists            = errors.New("repository.Mute: mute already exists")
 )
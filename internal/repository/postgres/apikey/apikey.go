@@ -0,0 +1,98 @@
+package apikey
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Create(ctx context.Context, userID string, name string, prefix string, keyHash string, scopes []string, expiresAt *time.Time) (*entity.APIKey, error) {
+	query := "INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *"
+
+	var key entity.APIKey
+	err := p.db.QueryRowxContext(ctx, query, userID, name, prefix, keyHash, pq.Array(scopes), expiresAt).StructScan(&key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (p *Postgres) GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	query := "SELECT * FROM api_keys WHERE key_hash = $1"
+
+	var key entity.APIKey
+	err := p.db.GetContext(ctx, &key, query, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (p *Postgres) GetByUserID(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	query := "SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC"
+
+	var keys []entity.APIKey
+	err := p.db.SelectContext(ctx, &keys, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Touch updates last usage time of an API key and reports whether its owner
+// is suspended
+func (p *Postgres) Touch(ctx context.Context, id string) (suspended bool, err error) {
+	query := "UPDATE api_keys SET last_used_at = now() FROM users WHERE api_keys.id = $1 AND users.id = api_keys.user_id RETURNING users.suspended_at IS NOT NULL"
+
+	err = p.db.GetContext(ctx, &suspended, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, repoerr.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return suspended, nil
+}
+
+func (p *Postgres) Delete(ctx context.Context, userID string, id string) error {
+	query := "DELETE FROM api_keys WHERE id = $1 AND user_id = $2"
+
+	result, err := p.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package block
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Create blocks a user and removes follows and follow requests between the
+// two users in both directions
+func (p *Postgres) Create(ctx context.Context, blockerID string, blockedID string) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)"
+
+	_, err = tx.ExecContext(ctx, query, blockerID, blockedID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repoerr.ErrBlockExists
+	}
+	if err != nil {
+		return err
+	}
+
+	query = "DELETE FROM follows WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)"
+
+	_, err = tx.ExecContext(ctx, query, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *Postgres) Delete(ctx context.Context, blockerID string, blockedID string) error {
+	query := "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2"
+
+	result, err := p.db.ExecContext(ctx, query, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrBlockNotFound
+	}
+
+	return nil
+}
+
+// Exists tells, whether either of two users blocked the other one
+func (p *Postgres) Exists(ctx context.Context, userID string, otherID string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))"
+
+	var exists bool
+	err := p.db.GetContext(ctx, &exists, query, userID, otherID)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Blocked returns users blocked by given one, the latest blocked first
+func (p *Postgres) Blocked(ctx context.Context, userID string) ([]entity.ListedUser, error) {
+	query := "SELECT users.id, users.username, users.display_name, users.avatar_url, blocks.created_at AS listed_at FROM blocks JOIN users ON users.id = blocks.blocked_id WHERE blocks.blocker_id = $1 ORDER BY blocks.created_at DESC"
+
+	var users []entity.ListedUser
+	err := p.db.SelectContext(ctx, &users, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
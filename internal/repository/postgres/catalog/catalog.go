@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// likeEscaper escapes wildcards of LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Create creates a custom entry, visible to given user only
+func (p *Postgres) Create(ctx context.Context, userID string, entryType string, name string, category string, muscleGroups []string, met float64) (*entity.CatalogEntry, error) {
+	query := "INSERT INTO catalog (user_id, type, name, category, muscle_groups, met) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *"
+
+	var entry entity.CatalogEntry
+	err := p.db.QueryRowxContext(ctx, query, userID, entryType, name, category, pq.Array(muscleGroups), met).StructScan(&entry)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, repoerr.ErrCatalogEntryExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetByID returns a built-in entry or a custom one of given user
+func (p *Postgres) GetByID(ctx context.Context, userID string, id string) (*entity.CatalogEntry, error) {
+	query := "SELECT * FROM catalog WHERE id = $1 AND (user_id IS NULL OR user_id = $2)"
+
+	var entry entity.CatalogEntry
+	err := p.db.GetContext(ctx, &entry, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrCatalogEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetByName finds an entry by its name or alias regardless of case. Custom
+// entries of given user take precedence over built-in ones
+func (p *Postgres) GetByName(ctx context.Context, userID string, entryType string, name string) (*entity.CatalogEntry, error) {
+	query := "SELECT * FROM catalog WHERE type = $1 AND (user_id IS NULL OR user_id = $2) AND (lower(name) = lower($3) OR lower($3) = ANY(aliases)) ORDER BY user_id NULLS LAST LIMIT 1"
+
+	var entry entity.CatalogEntry
+	err := p.db.GetContext(ctx, &entry, query, entryType, userID, strings.TrimSpace(name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrCatalogEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Search returns built-in entries and custom ones of given user, whose name
+// or alias contains the query. Empty type, category and query match anything
+func (p *Postgres) Search(ctx context.Context, userID string, entryType string, category string, q string) ([]entity.CatalogEntry, error) {
+	query := "SELECT * FROM catalog WHERE (user_id IS NULL OR user_id = $1) AND ($2 = '' OR type = $2) AND ($3 = '' OR category = $3) AND (name ILIKE $4 OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE alias ILIKE $4)) ORDER BY type, name"
+
+	entries := make([]entity.CatalogEntry, 0)
+	err := p.db.SelectContext(ctx, &entries, query, userID, entryType, category, "%"+likeEscaper.Replace(q)+"%")
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Delete deletes a custom entry of given user. Activities, that workouts
+// are attached to, can't be deleted
+func (p *Postgres) Delete(ctx context.Context, userID string, id string) error {
+	query := "DELETE FROM catalog WHERE id = $1 AND user_id = $2"
+
+	result, err := p.db.ExecContext(ctx, query, id, userID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return repoerr.ErrCatalogEntryInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrCatalogEntryNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package comment
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Create creates a comment and returns it together with its author. A reply
+// to a comment, that was removed meanwhile, is not created
+func (p *Postgres) Create(ctx context.Context, workoutID string, userID string, parentID *string, text string) (*entity.Comment, error) {
+	query := "WITH comment AS (INSERT INTO comments (workout_id, user_id, parent_id, text) VALUES ($1, $2, $3, $4) RETURNING *) SELECT comment.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comment JOIN users ON users.id = comment.user_id"
+
+	var comment entity.Comment
+	err := p.db.GetContext(ctx, &comment, query, workoutID, userID, parentID, text)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return nil, repoerr.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+func (p *Postgres) GetByID(ctx context.Context, id string) (*entity.Comment, error) {
+	query := "SELECT comments.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comments JOIN users ON users.id = comments.user_id WHERE comments.id = $1"
+
+	var comment entity.Comment
+	err := p.db.GetContext(ctx, &comment, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// GetByWorkout returns comments of a workout together with their authors, the
+// oldest first. Comments of users, that block each other with the viewer or
+// are muted by the viewer, are skipped. The viewer is empty for anonymous
+// requests
+func (p *Postgres) GetByWorkout(ctx context.Context, workoutID string, viewerID string) ([]entity.Comment, error) {
+	query := "SELECT comments.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comments JOIN users ON users.id = comments.user_id WHERE comments.workout_id = $1 AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = comments.user_id) OR (blocks.blocker_id = comments.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = comments.user_id) ORDER BY comments.created_at ASC"
+
+	var viewer any
+	if viewerID != "" {
+		viewer = viewerID
+	}
+
+	var comments []entity.Comment
+	err := p.db.SelectContext(ctx, &comments, query, workoutID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Update changes a text of a comment and marks it as edited
+func (p *Postgres) Update(ctx context.Context, id string, text string) (*entity.Comment, error) {
+	query := "WITH comment AS (UPDATE comments SET text = $1, edited_at = now() WHERE id = $2 RETURNING *) SELECT comment.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM comment JOIN users ON users.id = comment.user_id"
+
+	var comment entity.Comment
+	err := p.db.GetContext(ctx, &comment, query, text, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// Delete removes a comment together with replies to it
+func (p *Postgres) Delete(ctx context.Context, id string) error {
+	query := "DELETE FROM comments WHERE id = $1"
+
+	result, err := p.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrCommentNotFound
+	}
+
+	return nil
+}
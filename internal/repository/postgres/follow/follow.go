@@ -0,0 +1,140 @@
+package follow
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Create(ctx context.Context, followerID string, followeeID string, isAccepted bool) (*entity.Follow, error) {
+	query := "INSERT INTO follows (follower_id, followee_id, is_accepted) VALUES ($1, $2, $3) RETURNING *"
+
+	var follow entity.Follow
+	err := p.db.QueryRowxContext(ctx, query, followerID, followeeID, isAccepted).StructScan(&follow)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, repoerr.ErrFollowExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &follow, nil
+}
+
+func (p *Postgres) Get(ctx context.Context, followerID string, followeeID string) (*entity.Follow, error) {
+	query := "SELECT * FROM follows WHERE follower_id = $1 AND followee_id = $2"
+
+	var follow entity.Follow
+	err := p.db.GetContext(ctx, &follow, query, followerID, followeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrFollowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &follow, nil
+}
+
+// Accept accepts a pending follow request
+func (p *Postgres) Accept(ctx context.Context, followerID string, followeeID string) error {
+	query := "UPDATE follows SET is_accepted = true WHERE follower_id = $1 AND followee_id = $2 AND is_accepted = false"
+
+	return p.exec(ctx, query, followerID, followeeID)
+}
+
+// Reject removes a pending follow request
+func (p *Postgres) Reject(ctx context.Context, followerID string, followeeID string) error {
+	query := "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2 AND is_accepted = false"
+
+	return p.exec(ctx, query, followerID, followeeID)
+}
+
+// Delete removes a follow or a follow request
+func (p *Postgres) Delete(ctx context.Context, followerID string, followeeID string) error {
+	query := "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2"
+
+	return p.exec(ctx, query, followerID, followeeID)
+}
+
+// Followers returns users following given one, or the ones requested to
+// follow, the newest first. Users, that block each other with the viewer,
+// are skipped
+func (p *Postgres) Followers(ctx context.Context, userID string, isAccepted bool, viewerID string) ([]entity.FollowUser, error) {
+	query := "SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at FROM follows JOIN users ON users.id = follows.follower_id WHERE follows.followee_id = $1 AND follows.is_accepted = $2 AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $3 AND blocks.blocked_id = users.id) OR (blocks.blocker_id = users.id AND blocks.blocked_id = $3)) ORDER BY follows.created_at DESC"
+
+	var viewer any
+	if viewerID != "" {
+		viewer = viewerID
+	}
+
+	var users []entity.FollowUser
+	err := p.db.SelectContext(ctx, &users, query, userID, isAccepted, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Following returns users followed by given one, the newest first. Users,
+// that block each other with the viewer, are skipped
+func (p *Postgres) Following(ctx context.Context, userID string, viewerID string) ([]entity.FollowUser, error) {
+	query := "SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at FROM follows JOIN users ON users.id = follows.followee_id WHERE follows.follower_id = $1 AND follows.is_accepted = true AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = users.id) OR (blocks.blocker_id = users.id AND blocks.blocked_id = $2)) ORDER BY follows.created_at DESC"
+
+	var viewer any
+	if viewerID != "" {
+		viewer = viewerID
+	}
+
+	var users []entity.FollowUser
+	err := p.db.SelectContext(ctx, &users, query, userID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Counts returns numbers of accepted followers and followed users
+func (p *Postgres) Counts(ctx context.Context, userID string) (*entity.FollowCounts, error) {
+	query := "SELECT (SELECT count(*) FROM follows WHERE followee_id = $1 AND is_accepted = true) AS followers, (SELECT count(*) FROM follows WHERE follower_id = $1 AND is_accepted = true) AS following"
+
+	var counts entity.FollowCounts
+	err := p.db.GetContext(ctx, &counts, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+func (p *Postgres) exec(ctx context.Context, query string, followerID string, followeeID string) error {
+	result, err := p.db.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrFollowNotFound
+	}
+
+	return nil
+}
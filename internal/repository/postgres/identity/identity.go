@@ -0,0 +1,125 @@
+package identity
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Create(ctx context.Context, userID string, provider string, subject string, email string) (*entity.UserIdentity, error) {
+	query := "INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING *"
+	row := p.db.QueryRowxContext(ctx, query, userID, provider, subject, email)
+	if pqErr, ok := row.Err().(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, repoerr.ErrIdentityAlreadyExists
+	}
+	if row.Err() != nil {
+		return nil, row.Err()
+	}
+
+	var identity entity.UserIdentity
+	err := row.StructScan(&identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (p *Postgres) GetByProviderSubject(ctx context.Context, provider string, subject string) (*entity.UserIdentity, error) {
+	query := "SELECT * FROM user_identities WHERE provider = $1 AND subject = $2"
+
+	var identity entity.UserIdentity
+	err := p.db.GetContext(ctx, &identity, query, provider, subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (p *Postgres) GetByUserID(ctx context.Context, userID string) ([]entity.UserIdentity, error) {
+	query := "SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at ASC"
+
+	var identities []entity.UserIdentity
+	err := p.db.SelectContext(ctx, &identities, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return identities, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (p *Postgres) Delete(ctx context.Context, userID string, provider string) error {
+	query := "DELETE FROM user_identities WHERE user_id = $1 AND provider = $2"
+
+	result, err := p.db.ExecContext(ctx, query, userID, provider)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+// CreateState saves a pending authorization request. userID is empty for
+// sign in, and set when linking a provider to an existing account
+func (p *Postgres) CreateState(ctx context.Context, state string, provider string, codeVerifier string, nonce string, userID string, expiresAt time.Time) error {
+	query := "INSERT INTO oauth_states (state, provider, code_verifier, nonce, user_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6)"
+
+	_, err := p.db.ExecContext(ctx, query, state, provider, codeVerifier, nonce, userID, expiresAt)
+	return err
+}
+
+// ConsumeState removes a pending authorization request and returns it, so
+// every state can be used only once
+func (p *Postgres) ConsumeState(ctx context.Context, state string) (*entity.OAuthState, error) {
+	query := "DELETE FROM oauth_states WHERE state = $1 RETURNING *"
+
+	var s entity.OAuthState
+	err := p.db.GetContext(ctx, &s, query, state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrOAuthStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (p *Postgres) RemoveExpiredRecords(ctx context.Context) (n int64, err error) {
+	query := "DELETE FROM oauth_states WHERE expires_at < now()"
+
+	result, err := p.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,68 @@
+package kudos
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Create(ctx context.Context, workoutID string, userID string) error {
+	query := "INSERT INTO kudos (workout_id, user_id) VALUES ($1, $2)"
+
+	_, err := p.db.ExecContext(ctx, query, workoutID, userID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repoerr.ErrKudosExists
+	}
+
+	return err
+}
+
+func (p *Postgres) Delete(ctx context.Context, workoutID string, userID string) error {
+	query := "DELETE FROM kudos WHERE workout_id = $1 AND user_id = $2"
+
+	result, err := p.db.ExecContext(ctx, query, workoutID, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrKudosNotFound
+	}
+
+	return nil
+}
+
+// GetByWorkout returns kudos of a workout together with their authors, the
+// newest first. Kudos of users, that block each other with the viewer or are
+// muted by the viewer, are skipped. The viewer is empty for anonymous requests
+func (p *Postgres) GetByWorkout(ctx context.Context, workoutID string, viewerID string) ([]entity.Kudos, error) {
+	query := "SELECT kudos.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM kudos JOIN users ON users.id = kudos.user_id WHERE kudos.workout_id = $1 AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = kudos.user_id) OR (blocks.blocker_id = kudos.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = kudos.user_id) ORDER BY kudos.created_at DESC"
+
+	var viewer any
+	if viewerID != "" {
+		viewer = viewerID
+	}
+
+	var kudos []entity.Kudos
+	err := p.db.SelectContext(ctx, &kudos, query, workoutID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return kudos, nil
+}
@@ -0,0 +1,61 @@
+package mute
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Create(ctx context.Context, muterID string, mutedID string) error {
+	query := "INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2)"
+
+	_, err := p.db.ExecContext(ctx, query, muterID, mutedID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repoerr.ErrMuteExists
+	}
+
+	return err
+}
+
+func (p *Postgres) Delete(ctx context.Context, muterID string, mutedID string) error {
+	query := "DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2"
+
+	result, err := p.db.ExecContext(ctx, query, muterID, mutedID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrMuteNotFound
+	}
+
+	return nil
+}
+
+// Muted returns users muted by given one, the latest muted first
+func (p *Postgres) Muted(ctx context.Context, userID string) ([]entity.ListedUser, error) {
+	query := "SELECT users.id, users.username, users.display_name, users.avatar_url, mutes.created_at AS listed_at FROM mutes JOIN users ON users.id = mutes.muted_id WHERE mutes.muter_id = $1 ORDER BY mutes.created_at DESC"
+
+	var users []entity.ListedUser
+	err := p.db.SelectContext(ctx, &users, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"api/pkg/ratelimit"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Postgres is a ratelimit.Limiter that shares buckets between API replicas
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Take(ctx context.Context, key string, rule ratelimit.Rule) (ratelimit.Result, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO rate_limits (key, tokens) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING"
+	_, err = tx.ExecContext(ctx, query, key, rule.Limit)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	// Database clock is used, so replicas with skewed clocks agree on refills
+	query = "SELECT tokens, updated_at, now()::timestamp FROM rate_limits WHERE key = $1 FOR UPDATE"
+
+	var bucket ratelimit.Bucket
+	var now time.Time
+	err = tx.QueryRowxContext(ctx, query, key).Scan(&bucket.Tokens, &bucket.UpdatedAt, &now)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	bucket, result := bucket.Take(rule, now)
+
+	query = "UPDATE rate_limits SET tokens = $1, updated_at = $2 WHERE key = $3"
+	_, err = tx.ExecContext(ctx, query, bucket.Tokens, bucket.UpdatedAt, key)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return result, tx.Commit()
+}
+
+// RemoveExpiredRecords removes buckets untouched for a day. Rules are not
+// stored, so the day is expected to be longer than any rule's period
+func (p *Postgres) RemoveExpiredRecords(ctx context.Context) (n int64, err error) {
+	query := "DELETE FROM rate_limits WHERE updated_at < now() - interval '1 day'"
+
+	result, err := p.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
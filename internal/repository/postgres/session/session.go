@@ -0,0 +1,157 @@
+package session
+
+import (
+	"api/internal/repository/entity"
+	repoerr "api/internal/repository/errors"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Create(ctx context.Context, userID string, familyID string, refreshTokenHash string, expiresAt time.Time, device string, userAgent string, ipAddress string) (*entity.Session, error) {
+	query := "INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *"
+	row := p.db.QueryRowContext(ctx, query, userID, familyID, refreshTokenHash, expiresAt, device, userAgent, ipAddress)
+	if row.Err() != nil {
+		return nil, row.Err()
+	}
+
+	var session entity.Session
+	err := row.Scan(&session.ID, &session.FamilyID, &session.UserID, &session.RefreshTokenHash, &session.IsUsed, &session.ExpiresAt, &session.CreatedAt, &session.Device, &session.UserAgent, &session.IPAddress, &session.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (p *Postgres) GetByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entity.Session, error) {
+	query := "SELECT * FROM sessions WHERE refresh_token_hash = $1"
+
+	var session entity.Session
+	err := p.db.GetContext(ctx, &session, query, refreshTokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Rotate marks refresh token as used and stores the next one of the same
+// family in a single transaction, so the family always has an active token.
+// Returns repoerr.ErrSessionNotFound if the token was already used, so
+// concurrent refreshes can't both succeed
+func (p *Postgres) Rotate(ctx context.Context, id string, userID string, familyID string, refreshTokenHash string, expiresAt time.Time, device string, userAgent string, ipAddress string) (*entity.Session, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE sessions SET is_used = true WHERE id = $1 AND is_used = false"
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, repoerr.ErrSessionNotFound
+	}
+
+	query = "INSERT INTO sessions (user_id, family_id, refresh_token_hash, expires_at, device, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *"
+
+	var session entity.Session
+	err = tx.GetContext(ctx, &session, query, userID, familyID, refreshTokenHash, expiresAt, device, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetActiveByUserID returns the current refresh token of every active session family of the user
+func (p *Postgres) GetActiveByUserID(ctx context.Context, userID string) ([]entity.Session, error) {
+	query := "SELECT * FROM sessions WHERE user_id = $1 AND is_used = false AND expires_at > now() ORDER BY last_seen_at DESC"
+
+	var sessions []entity.Session
+	err := p.db.SelectContext(ctx, &sessions, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch updates last seen information of an active session family and reports
+// whether the user is suspended. Last seen information is written at most
+// once a minute. Returns repoerr.ErrSessionNotFound if the session was revoked
+// or has expired
+func (p *Postgres) Touch(ctx context.Context, familyID string, userAgent string, ipAddress string) (suspended bool, err error) {
+	query := "WITH session AS (SELECT sessions.id, sessions.last_seen_at, users.suspended_at FROM sessions JOIN users ON users.id = sessions.user_id WHERE sessions.family_id = $3 AND sessions.is_used = false AND sessions.expires_at > now()), touched AS (UPDATE sessions SET last_seen_at = now(), user_agent = $1, ip_address = $2 FROM session WHERE sessions.id = session.id AND session.last_seen_at < now() - interval '1 minute') SELECT suspended_at IS NOT NULL FROM session"
+
+	err = p.db.GetContext(ctx, &suspended, query, userAgent, ipAddress, familyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, repoerr.ErrSessionNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return suspended, nil
+}
+
+func (p *Postgres) RevokeFamily(ctx context.Context, familyID string) error {
+	query := "DELETE FROM sessions WHERE family_id = $1"
+
+	_, err := p.db.ExecContext(ctx, query, familyID)
+	return err
+}
+
+func (p *Postgres) RevokeAllUserSessions(ctx context.Context, userID string) error {
+	query := "DELETE FROM sessions WHERE user_id = $1"
+
+	_, err := p.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+func (p *Postgres) RevokeOtherUserSessions(ctx context.Context, userID string, familyID string) error {
+	query := "DELETE FROM sessions WHERE user_id = $1 AND family_id <> $2"
+
+	_, err := p.db.ExecContext(ctx, query, userID, familyID)
+	return err
+}
+
+func (p *Postgres) RemoveExpiredRecords(ctx context.Context) (n int64, err error) {
+	query := "DELETE FROM sessions WHERE expires_at < now()"
+
+	result, err := p.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,70 @@
+package statistics
+
+import (
+	"api/internal/repository/entity"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Postgres struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Summary(ctx context.Context, userID string, begin time.Time, end time.Time) (*entity.WorkoutSummary, error) {
+	query := "SELECT count(*) AS count, COALESCE(sum(duration), 0) AS minutes, COALESCE(max(duration), 0) AS longest, COALESCE(sum(distance), 0) AS distance, count(distance) AS distance_count, count(DISTINCT date) AS active_days, min(date) AS first_date FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3"
+
+	var summary entity.WorkoutSummary
+	err := p.db.GetContext(ctx, &summary, query, userID, begin, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+func (p *Postgres) Kinds(ctx context.Context, userID string, begin time.Time, end time.Time) ([]entity.KindSummary, error) {
+	query := "SELECT kind, count(*) AS count, sum(duration) AS minutes, COALESCE(sum(distance), 0) AS distance, min(duration * 60 / distance) FILTER (WHERE distance > 0) AS best_pace FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 GROUP BY kind ORDER BY kind"
+
+	kinds := make([]entity.KindSummary, 0)
+	err := p.db.SelectContext(ctx, &kinds, query, userID, begin, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return kinds, nil
+}
+
+// Periods aggregates workouts by days, weeks, months or years. Weeks start
+// on Monday. Periods without workouts are omitted
+func (p *Postgres) Periods(ctx context.Context, userID string, begin time.Time, end time.Time, period string) ([]entity.PeriodSummary, error) {
+	query := "SELECT date_trunc($4, date::timestamp)::date AS start, count(*) AS count, sum(duration) AS minutes, COALESCE(sum(distance), 0) AS distance FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 GROUP BY 1 ORDER BY 1"
+
+	periods := make([]entity.PeriodSummary, 0)
+	err := p.db.SelectContext(ctx, &periods, query, userID, begin, end, period)
+	if err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// Streaks finds runs of consecutive days with workouts among all workouts
+// of a user. Subtracting a row number from a date gives the same value for
+// all days of a run
+func (p *Postgres) Streaks(ctx context.Context, userID string, today time.Time) (*entity.Streaks, error) {
+	query := "WITH days AS (SELECT DISTINCT date FROM workouts WHERE user_id = $1 AND date <= $2), runs AS (SELECT max(date) AS last, count(*) AS length FROM (SELECT date, date - (row_number() OVER (ORDER BY date))::int AS run FROM days) AS numbered GROUP BY run) SELECT COALESCE(max(length) FILTER (WHERE last >= $2::date - 1), 0) AS current, COALESCE(max(length), 0) AS longest FROM runs"
+
+	var streaks entity.Streaks
+	err := p.db.GetContext(ctx, &streaks, query, userID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streaks, nil
+}
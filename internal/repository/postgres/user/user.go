@@ -6,12 +6,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
+// likeEscaper escapes wildcards of LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type Postgres struct {
 	db *sqlx.DB
 }
@@ -22,7 +26,7 @@ func New(db *sqlx.DB) *Postgres {
 
 func (p *Postgres) Create(ctx context.Context, email string, username string, passwordHash string) (*entity.User, error) {
 	query := "INSERT INTO users (email, username, password_hash) VALUES ($1, $2, $3) RETURNING *"
-	row := p.db.QueryRowContext(ctx, query, email, username, passwordHash)
+	row := p.db.QueryRowxContext(ctx, query, email, username, passwordHash)
 	if pqErr, ok := row.Err().(*pq.Error); ok && pqErr.Code == "23505" {
 		return nil, repoerr.ErrUserAlreadyExists
 	}
@@ -31,7 +35,7 @@ func (p *Postgres) Create(ctx context.Context, email string, username string, pa
 	}
 
 	var user entity.User
-	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.DisplayName, &user.AvatarURL, &user.PasswordHash, &user.IsPrivate, &user.IsConfirmed, &user.ConfirmationToken, &user.CreatedAt)
+	err := row.StructScan(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -61,36 +65,6 @@ func (p *Postgres) GetByID(ctx context.Context, id string) (*entity.User, error)
 	return &user, nil
 }
 
-func (p *Postgres) GetByCredentialsWithEmail(ctx context.Context, email string, passwordHash string) (*entity.User, error) {
-	query := "SELECT * FROM users WHERE email = $1 AND password_hash = $2"
-
-	var user entity.User
-	err := p.db.GetContext(ctx, &user, query, email, passwordHash)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, repoerr.ErrUserNotFound
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	return &user, nil
-}
-
-func (p *Postgres) GetByCredentialsWithUsername(ctx context.Context, username string, passwordHash string) (*entity.User, error) {
-	query := "SELECT * FROM users WHERE username = $1 AND password_hash = $2"
-
-	var user entity.User
-	err := p.db.GetContext(ctx, &user, query, username, passwordHash)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, repoerr.ErrUserNotFound
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	return &user, nil
-}
-
 func (p *Postgres) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
 	query := "SELECT * FROM users WHERE email = $1"
 
@@ -203,12 +177,322 @@ func (p *Postgres) SetUserConfirmed(ctx context.Context, email string, token str
 	return err
 }
 
+// RemoveExpiredRecords removes expired password reset requests and used
+// two-factor challenges
 func (p *Postgres) RemoveExpiredRecords(ctx context.Context) (n int64, err error) {
-	query := "DELETE FROM reset_password_requests WHERE expires_at < now()"
+	for _, query := range []string{
+		"DELETE FROM reset_password_requests WHERE expires_at < now()",
+		"DELETE FROM used_challenges WHERE expires_at < now()",
+	} {
+		result, err := p.db.ExecContext(ctx, query)
+		if err != nil {
+			return n, err
+		}
+
+		removed, err := result.RowsAffected()
+		if err != nil {
+			return n, err
+		}
+		n += removed
+	}
+
+	return n, nil
+}
+
+// SetTOTPSecret saves a new secret for two-factor authentication, that is
+// not enabled yet
+func (p *Postgres) SetTOTPSecret(ctx context.Context, userID string, secret string) error {
+	query := "UPDATE users SET totp_secret = $1 WHERE id = $2 AND is_totp_enabled = false"
+
+	_, err := p.db.ExecContext(ctx, query, secret, userID)
+	return err
+}
 
-	result, err := p.db.ExecContext(ctx, query)
+// EnableTOTP enables two-factor authentication and replaces user's recovery codes
+func (p *Postgres) EnableTOTP(ctx context.Context, userID string, recoveryCodeHashes []string) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET is_totp_enabled = true WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])", userID, pq.Array(recoveryCodeHashes))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP disables two-factor authentication and removes user's recovery codes
+func (p *Postgres) DisableTOTP(ctx context.Context, userID string) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET totp_secret = '', is_totp_enabled = false WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks recovery code as used. Returns repoerr.ErrRecoveryCodeNotFound
+// if there is no such unused code
+func (p *Postgres) UseRecoveryCode(ctx context.Context, userID string, codeHash string) error {
+	query := "UPDATE recovery_codes SET is_used = true WHERE user_id = $1 AND code_hash = $2 AND is_used = false"
+
+	result, err := p.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrRecoveryCodeNotFound
+	}
+
+	return nil
+}
+
+// RegisterFailedLogin increments user's failed login attempts counter and returns its new value
+func (p *Postgres) RegisterFailedLogin(ctx context.Context, userID string) (attempts int, err error) {
+	query := "UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE id = $1 RETURNING failed_login_attempts"
+
+	err = p.db.QueryRowxContext(ctx, query, userID).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repoerr.ErrUserNotFound
+	}
 	if err != nil {
 		return 0, err
 	}
-	return result.RowsAffected()
+
+	return attempts, nil
+}
+
+func (p *Postgres) Lock(ctx context.Context, userID string, until time.Time) error {
+	query := "UPDATE users SET locked_until = $1 WHERE id = $2"
+
+	_, err := p.db.ExecContext(ctx, query, until, userID)
+	return err
+}
+
+// ResetFailedLogins resets failed login attempts counter and removes a lock
+func (p *Postgres) ResetFailedLogins(ctx context.Context, userID string) error {
+	query := "UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1"
+
+	_, err := p.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// CreateWithIdentity creates a confirmed user without a password, that signs
+// in with an external provider
+func (p *Postgres) CreateWithIdentity(ctx context.Context, email string, username string, provider string, subject string) (*entity.User, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO users (email, username, password_hash, is_confirmed) VALUES ($1, $2, '', true) RETURNING *"
+	row := tx.QueryRowxContext(ctx, query, email, username)
+	if pqErr, ok := row.Err().(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, repoerr.ErrUserAlreadyExists
+	}
+	if row.Err() != nil {
+		return nil, row.Err()
+	}
+
+	var user entity.User
+	err = row.StructScan(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	query = "INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)"
+	_, err = tx.ExecContext(ctx, query, user.ID, provider, subject, email)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, repoerr.ErrIdentityAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, tx.Commit()
+}
+
+// Search returns users, whose email or username contain given query, newest first
+func (p *Postgres) Search(ctx context.Context, query string, limit int, offset int) ([]entity.User, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	q := "SELECT * FROM users WHERE email ILIKE $1 OR username ILIKE $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3"
+
+	var users []entity.User
+	err := p.db.SelectContext(ctx, &users, q, pattern, limit, offset)
+	if errors.Is(err, sql.ErrNoRows) {
+		return users, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Confirm marks user's email as confirmed without a confirmation token
+func (p *Postgres) Confirm(ctx context.Context, userID string) error {
+	query := "UPDATE users SET is_confirmed = true WHERE id = $1"
+	return p.updateOne(ctx, query, userID)
+}
+
+// UpdateAccount saves user's fields and given settings of the account in a
+// single transaction. Returns repoerr.ErrUserNotFound if there is no such user
+func (p *Postgres) UpdateAccount(ctx context.Context, user *entity.User, update entity.AccountUpdate) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET email = $1, username = $2, display_name = $3, avatar_url = $4, password_hash = $5, is_private = $6, is_confirmed = $7, confirmation_token = $8 WHERE id = $9"
+
+	result, err := tx.ExecContext(ctx, query, user.Email, user.Username, user.DisplayName, user.AvatarURL, user.PasswordHash, user.IsPrivate, user.IsConfirmed, user.ConfirmationToken, user.ID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrUserNotFound
+	}
+
+	if update.AcceptFollows {
+		_, err = tx.ExecContext(ctx, "UPDATE follows SET is_accepted = true WHERE followee_id = $1 AND is_accepted = false", user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if update.Units != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET units = $1 WHERE id = $2", *update.Units, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if update.TimeZone != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET time_zone = $1 WHERE id = $2", *update.TimeZone, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if update.DefaultVisibility != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET default_visibility = $1 WHERE id = $2", *update.DefaultVisibility, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if update.ApplyToWorkouts {
+			_, err = tx.ExecContext(ctx, "UPDATE workouts SET visibility = $1 WHERE user_id = $2", *update.DefaultVisibility, user.ID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (p *Postgres) SetRole(ctx context.Context, userID string, role string) error {
+	query := "UPDATE users SET role = $1 WHERE id = $2"
+	return p.updateOne(ctx, query, role, userID)
+}
+
+func (p *Postgres) Suspend(ctx context.Context, userID string) error {
+	query := "UPDATE users SET suspended_at = now() WHERE id = $1"
+	return p.updateOne(ctx, query, userID)
+}
+
+func (p *Postgres) Unsuspend(ctx context.Context, userID string) error {
+	query := "UPDATE users SET suspended_at = NULL WHERE id = $1"
+	return p.updateOne(ctx, query, userID)
+}
+
+// updateOne executes an update of a single user. Returns repoerr.ErrUserNotFound
+// if no user was updated
+func (p *Postgres) updateOne(ctx context.Context, query string, args ...any) error {
+	result, err := p.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UseTOTPCounter records a time step of an accepted TOTP code. Returns
+// repoerr.ErrTOTPCodeUsed if a code of the same or a later step was accepted
+// before, so a code can't be replayed within its validity window
+func (p *Postgres) UseTOTPCounter(ctx context.Context, userID string, counter int64) error {
+	query := "UPDATE users SET totp_last_counter = $1 WHERE id = $2 AND totp_last_counter < $1"
+
+	result, err := p.db.ExecContext(ctx, query, counter, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrTOTPCodeUsed
+	}
+
+	return nil
+}
+
+// UseChallenge records ID of a completed two-factor challenge until it
+// expires. Returns repoerr.ErrChallengeUsed if the challenge was completed
+// before
+func (p *Postgres) UseChallenge(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := "INSERT INTO used_challenges (jti, expires_at) VALUES ($1, $2)"
+
+	_, err := p.db.ExecContext(ctx, query, jti, expiresAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repoerr.ErrChallengeUsed
+	}
+
+	return err
 }
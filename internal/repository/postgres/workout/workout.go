@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Postgres struct {
@@ -19,33 +20,137 @@ func New(db *sqlx.DB) *Postgres {
 	return &Postgres{db: db}
 }
 
-func (p *Postgres) Create(ctx context.Context, userID string, date time.Time, duration int, kind string) (*entity.Workout, error) {
-	query := "INSERT INTO workouts (user_id, date, duration, kind) VALUES ($1, $2, $3, $4) RETURNING *"
-	row := p.db.QueryRowContext(ctx, query, userID, date, duration, kind)
-	if row.Err() != nil {
-		return nil, row.Err()
+// Create creates a workout together with its exercises and their sets. An
+// empty visibility falls back to the user's default one
+func (p *Postgres) Create(ctx context.Context, userID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, visibility string, metrics entity.Metrics, exercises []entity.Exercise) (*entity.Workout, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	workout, err := insertWorkout(ctx, tx, userID, activityID, date, startedAt, duration, kind, visibility, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	workout.Exercises, err = insertExercises(ctx, tx, workout.ID, exercises)
+	if err != nil {
+		return nil, err
+	}
+
+	return workout, tx.Commit()
+}
+
+// CreateBatch creates workouts without exercises in a single transaction,
+// so either all of them are created or none
+func (p *Postgres) CreateBatch(ctx context.Context, userID string, workouts []entity.Workout) ([]entity.Workout, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created := make([]entity.Workout, 0, len(workouts))
+	for _, w := range workouts {
+		workout, err := insertWorkout(ctx, tx, userID, w.ActivityID, w.Date, w.StartedAt, w.Duration, w.Kind, w.Visibility, w.Metrics)
+		if err != nil {
+			return nil, err
+		}
+
+		created = append(created, *workout)
+	}
+
+	return created, tx.Commit()
+}
+
+// Import creates a workout together with a raw track, it was recorded in,
+// and laps of the track. The workout gets the user's default visibility
+func (p *Postgres) Import(ctx context.Context, userID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, metrics entity.Metrics, track entity.Track, laps []entity.Lap) (*entity.Workout, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	workout, err := insertWorkout(ctx, tx, userID, activityID, date, startedAt, duration, kind, "", metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO workout_tracks (workout_id, format, data) VALUES ($1, $2, $3)"
+
+	_, err = tx.ExecContext(ctx, query, workout.ID, track.Format, track.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, lap := range laps {
+		query := "INSERT INTO workout_laps (workout_id, position, start_time, duration, distance, avg_heart_rate, max_heart_rate, calories) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *"
+
+		var l entity.Lap
+		err := tx.QueryRowxContext(ctx, query, workout.ID, i+1, lap.StartTime, lap.Duration, lap.Distance, lap.AvgHeartRate, lap.MaxHeartRate, lap.Calories).StructScan(&l)
+		if err != nil {
+			return nil, err
+		}
+
+		workout.Laps = append(workout.Laps, l)
 	}
 
+	return workout, tx.Commit()
+}
+
+// Update updates a workout. Exercises are replaced only if not nil
+func (p *Postgres) Update(ctx context.Context, workoutID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, visibility string, metrics entity.Metrics, exercises []entity.Exercise) (*entity.Workout, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE workouts SET activity_id = $1, date = $2, duration = $3, kind = $4, distance = $5, elevation_gain = $6, avg_heart_rate = $7, max_heart_rate = $8, cadence = $9, calories = $10, started_at = $11, visibility = $12, updated_at = now() WHERE id = $13 RETURNING *"
+
 	var workout entity.Workout
-	err := row.Scan(&workout.ID, &workout.UserID, &workout.Date, &workout.Duration, &workout.Kind, &workout.CreatedAt)
+	err = tx.QueryRowxContext(ctx, query, activityID, date, duration, kind, metrics.Distance, metrics.ElevationGain, metrics.AvgHeartRate, metrics.MaxHeartRate, metrics.Cadence, metrics.Calories, startedAt, visibility, workoutID).StructScan(&workout)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrWorkoutNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &workout, err
+	if exercises != nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM workout_exercises WHERE workout_id = $1", workoutID)
+		if err != nil {
+			return nil, err
+		}
+
+		workout.Exercises, err = insertExercises(ctx, tx, workoutID, exercises)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &workout, tx.Commit()
 }
 
 func (p *Postgres) Delete(ctx context.Context, workoutID string) error {
 	query := "DELETE FROM workouts WHERE id = $1"
 
-	var err error
-	// TODO: Return repoerr.ErrWorkoutNotFound if nothing to delete
-	_, err = p.db.ExecContext(ctx, query, workoutID)
+	result, err := p.db.ExecContext(ctx, query, workoutID)
 	if err != nil {
-		return nil
+		return err
 	}
 
-	return err
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repoerr.ErrWorkoutNotFound
+	}
+
+	return nil
 }
 
 func (p *Postgres) GetByID(ctx context.Context, id string) (*entity.Workout, error) {
@@ -63,6 +168,21 @@ func (p *Postgres) GetByID(ctx context.Context, id string) (*entity.Workout, err
 	return &workout, nil
 }
 
+func (p *Postgres) GetTrack(ctx context.Context, workoutID string) (*entity.Track, error) {
+	query := "SELECT * FROM workout_tracks WHERE workout_id = $1"
+
+	var track entity.Track
+	err := p.db.GetContext(ctx, &track, query, workoutID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repoerr.ErrTrackNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &track, nil
+}
+
 func (p *Postgres) GetUserWorkouts(ctx context.Context, userID string, begin time.Time, end time.Time) ([]entity.Workout, error) {
 	query := "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC"
 
@@ -78,17 +198,213 @@ func (p *Postgres) GetUserWorkouts(ctx context.Context, userID string, begin tim
 	return workouts, nil
 }
 
-func (p *Postgres) GetAllUserWorkouts(ctx context.Context, userID string) ([]entity.Workout, error) {
-	query := "SELECT * FROM workouts WHERE user_id = $1 ORDER BY date ASC"
+// ListUserWorkouts returns a page of user's workouts, that match a filter.
+// Pages are ordered by date, then by creation time and id, so a position of
+// the last workout is enough to get the next page
+func (p *Postgres) ListUserWorkouts(ctx context.Context, userID string, filter entity.WorkoutFilter) ([]entity.Workout, error) {
+	query := "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND ($4 = '' OR lower(kind) = lower($4)) AND ($5 = 0 OR duration >= $5) AND ($6 = 0 OR duration <= $6) AND ($7::date IS NULL OR (date, created_at, id) > ($7, $8::timestamp, $9::uuid)) ORDER BY date ASC, created_at ASC, id ASC LIMIT $10"
+	if filter.Descending {
+		query = "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND ($4 = '' OR lower(kind) = lower($4)) AND ($5 = 0 OR duration >= $5) AND ($6 = 0 OR duration <= $6) AND ($7::date IS NULL OR (date, created_at, id) < ($7, $8::timestamp, $9::uuid)) ORDER BY date DESC, created_at DESC, id DESC LIMIT $10"
+	}
 
-	var workouts []entity.Workout
-	err := p.db.SelectContext(ctx, &workouts, query, userID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return workouts, nil
+	var date, createdAt, id any
+	if filter.After != nil {
+		date, createdAt, id = filter.After.Date, filter.After.CreatedAt, filter.After.ID
 	}
+
+	workouts := make([]entity.Workout, 0)
+	err := p.db.SelectContext(ctx, &workouts, query, userID, filter.Begin, filter.End, filter.Kind, filter.MinDuration, filter.MaxDuration, date, createdAt, id, filter.Limit)
 	if err != nil {
 		return nil, err
 	}
 
 	return workouts, nil
 }
+
+// Feed returns a page of workouts of users followed by given one, the newest
+// first. Only accepted follows count, so private users show up only for their
+// followers, and workouts visible only to their owners are skipped. Blocking
+// removes follows, so blocked users never show up, and muted ones are
+// skipped. Every followed user gives at most a page of workouts, read by the
+// position index, and only these are merged and sorted
+func (p *Postgres) Feed(ctx context.Context, userID string, after *entity.WorkoutPosition, limit int) ([]entity.FeedWorkout, error) {
+	query := "SELECT workouts.*, users.username AS author_username, users.display_name AS author_display_name, users.avatar_url AS author_avatar_url FROM follows JOIN users ON users.id = follows.followee_id CROSS JOIN LATERAL (SELECT * FROM workouts WHERE workouts.user_id = follows.followee_id AND workouts.visibility <> 'only_me' AND ($2::date IS NULL OR (workouts.date, workouts.created_at, workouts.id) < ($2, $3::timestamp, $4::uuid)) ORDER BY workouts.date DESC, workouts.created_at DESC, workouts.id DESC LIMIT $5) AS workouts WHERE follows.follower_id = $1 AND follows.is_accepted = true AND users.suspended_at IS NULL AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $1 AND mutes.muted_id = follows.followee_id) ORDER BY workouts.date DESC, workouts.created_at DESC, workouts.id DESC LIMIT $5"
+
+	var date, createdAt, id any
+	if after != nil {
+		date, createdAt, id = after.Date, after.CreatedAt, after.ID
+	}
+
+	workouts := make([]entity.FeedWorkout, 0)
+	err := p.db.SelectContext(ctx, &workouts, query, userID, date, createdAt, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return workouts, nil
+}
+
+// exportRow is a workout together with a raw track, if it has one
+type exportRow struct {
+	entity.Workout
+	TrackFormat *string `db:"track_format"`
+	TrackData   []byte  `db:"track_data"`
+}
+
+// Export calls fn for every workout of a user in a date range, reading them
+// one by one, so they are never loaded into memory at once. Raw tracks are
+// read only if withTracks is set
+func (p *Postgres) Export(ctx context.Context, userID string, begin time.Time, end time.Time, withTracks bool, fn func(workout *entity.Workout) error) error {
+	query := "SELECT * FROM workouts WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date ASC, created_at ASC"
+	if withTracks {
+		query = "SELECT workouts.*, workout_tracks.format AS track_format, workout_tracks.data AS track_data FROM workouts LEFT JOIN workout_tracks ON workout_tracks.workout_id = workouts.id WHERE workouts.user_id = $1 AND workouts.date BETWEEN $2 AND $3 ORDER BY workouts.date ASC, workouts.created_at ASC"
+	}
+
+	rows, err := p.db.QueryxContext(ctx, query, userID, begin, end)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row exportRow
+		if err := rows.StructScan(&row); err != nil {
+			return err
+		}
+
+		workout := row.Workout
+		if row.TrackFormat != nil {
+			workout.Track = &entity.Track{WorkoutID: workout.ID, Format: *row.TrackFormat, Data: row.TrackData}
+		}
+
+		if err := fn(&workout); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetExercises returns exercises of given workouts with their sets, ordered
+// as they were recorded
+func (p *Postgres) GetExercises(ctx context.Context, workoutIDs []string) ([]entity.Exercise, error) {
+	if len(workoutIDs) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT * FROM workout_exercises WHERE workout_id = ANY($1) ORDER BY workout_id, position"
+
+	var exercises []entity.Exercise
+	err := p.db.SelectContext(ctx, &exercises, query, pq.Array(workoutIDs))
+	if err != nil {
+		return nil, err
+	}
+	if len(exercises) == 0 {
+		return exercises, nil
+	}
+
+	query = "SELECT exercise_sets.* FROM exercise_sets JOIN workout_exercises ON workout_exercises.id = exercise_sets.exercise_id WHERE workout_exercises.workout_id = ANY($1) ORDER BY exercise_sets.exercise_id, exercise_sets.position"
+
+	var sets []entity.ExerciseSet
+	err = p.db.SelectContext(ctx, &sets, query, pq.Array(workoutIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*entity.Exercise, len(exercises))
+	for i := range exercises {
+		byID[exercises[i].ID] = &exercises[i]
+	}
+
+	for _, set := range sets {
+		if exercise, ok := byID[set.ExerciseID]; ok {
+			exercise.Sets = append(exercise.Sets, set)
+		}
+	}
+
+	return exercises, nil
+}
+
+// GetLaps returns laps of given workouts in order
+func (p *Postgres) GetLaps(ctx context.Context, workoutIDs []string) ([]entity.Lap, error) {
+	if len(workoutIDs) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT * FROM workout_laps WHERE workout_id = ANY($1) ORDER BY workout_id, position"
+
+	var laps []entity.Lap
+	err := p.db.SelectContext(ctx, &laps, query, pq.Array(workoutIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	return laps, nil
+}
+
+// GetCounts returns numbers of kudos and comments of workouts. Like the lists
+// of kudos and comments, it skips users, that block each other with the viewer
+// or are muted by the viewer, and replies to skipped comments
+func (p *Postgres) GetCounts(ctx context.Context, workoutIDs []string, viewerID string) ([]entity.Counts, error) {
+	if len(workoutIDs) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT workouts.id AS workout_id, (SELECT count(*) FROM kudos WHERE kudos.workout_id = workouts.id AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = kudos.user_id) OR (blocks.blocker_id = kudos.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = kudos.user_id)) AS kudos, (WITH RECURSIVE shown AS (SELECT comments.id FROM comments WHERE comments.workout_id = workouts.id AND comments.parent_id IS NULL AND NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = comments.user_id) OR (blocks.blocker_id = comments.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = comments.user_id) UNION ALL SELECT comments.id FROM comments JOIN shown ON comments.parent_id = shown.id WHERE NOT EXISTS (SELECT 1 FROM blocks WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = comments.user_id) OR (blocks.blocker_id = comments.user_id AND blocks.blocked_id = $2)) AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = comments.user_id)) SELECT count(*) FROM shown) AS comments FROM workouts WHERE workouts.id = ANY($1)"
+
+	var viewer any
+	if viewerID != "" {
+		viewer = viewerID
+	}
+
+	var counts []entity.Counts
+	err := p.db.SelectContext(ctx, &counts, query, pq.Array(workoutIDs), viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// insertWorkout inserts a workout. An empty visibility is replaced with the
+// user's default one
+func insertWorkout(ctx context.Context, tx *sqlx.Tx, userID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, visibility string, metrics entity.Metrics) (*entity.Workout, error) {
+	query := "INSERT INTO workouts (user_id, activity_id, date, duration, kind, distance, elevation_gain, avg_heart_rate, max_heart_rate, cadence, calories, started_at, visibility) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE(NULLIF($13, ''), (SELECT default_visibility FROM users WHERE id = $1))) RETURNING *"
+
+	var workout entity.Workout
+	err := tx.QueryRowxContext(ctx, query, userID, activityID, date, duration, kind, metrics.Distance, metrics.ElevationGain, metrics.AvgHeartRate, metrics.MaxHeartRate, metrics.Cadence, metrics.Calories, startedAt, visibility).StructScan(&workout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workout, nil
+}
+
+func insertExercises(ctx context.Context, tx *sqlx.Tx, workoutID string, exercises []entity.Exercise) ([]entity.Exercise, error) {
+	inserted := make([]entity.Exercise, 0, len(exercises))
+	for i, exercise := range exercises {
+		query := "INSERT INTO workout_exercises (workout_id, exercise_id, position, name) VALUES ($1, $2, $3, $4) RETURNING *"
+
+		var e entity.Exercise
+		err := tx.QueryRowxContext(ctx, query, workoutID, exercise.ExerciseID, i+1, exercise.Name).StructScan(&e)
+		if err != nil {
+			return nil, err
+		}
+
+		for j, set := range exercise.Sets {
+			query := "INSERT INTO exercise_sets (exercise_id, position, reps, weight, weight_unit, rpe, rest_seconds) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *"
+
+			var s entity.ExerciseSet
+			err := tx.QueryRowxContext(ctx, query, e.ID, j+1, set.Reps, set.Weight, set.WeightUnit, set.RPE, set.RestSeconds).StructScan(&s)
+			if err != nil {
+				return nil, err
+			}
+
+			e.Sets = append(e.Sets, s)
+		}
+
+		inserted = append(inserted, e)
+	}
+
+	return inserted, nil
+}
@@ -2,8 +2,20 @@ package repository
 
 import (
 	"api/internal/repository/entity"
+	"api/internal/repository/postgres/apikey"
+	"api/internal/repository/postgres/block"
+	"api/internal/repository/postgres/catalog"
+	"api/internal/repository/postgres/comment"
+	"api/internal/repository/postgres/follow"
+	"api/internal/repository/postgres/identity"
+	"api/internal/repository/postgres/kudos"
+	"api/internal/repository/postgres/mute"
+	ratelimitrepo "api/internal/repository/postgres/ratelimit"
+	"api/internal/repository/postgres/session"
+	"api/internal/repository/postgres/statistics"
 	"api/internal/repository/postgres/user"
 	"api/internal/repository/postgres/workout"
+	"api/pkg/ratelimit"
 	"context"
 	"time"
 
@@ -15,8 +27,6 @@ type User interface {
 	SetUserConfirmed(ctx context.Context, email string, token string) error
 	UpdateUser(ctx context.Context, userID string, email string, username string, displayName string, avatarURL string, passwordHash string, isPrivate bool, isConfirmed bool, confirmationToken string) error
 	GetByID(ctx context.Context, id string) (*entity.User, error)
-	GetByCredentialsWithEmail(ctx context.Context, email string, passwordHash string) (*entity.User, error)
-	GetByCredentialsWithUsername(ctx context.Context, email string, passwordHash string) (*entity.User, error)
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetByUsername(ctx context.Context, username string) (*entity.User, error)
 	GetByConfirmationToken(ctx context.Context, token string) (*entity.User, error)
@@ -25,26 +35,164 @@ type User interface {
 	GetRequestByToken(ctx context.Context, token string) (*entity.Request, error)
 	GetRequestByEmail(ctx context.Context, email string) (*entity.Request, error)
 	MarkRequestAsUsed(ctx context.Context, token string) error
+	SetTOTPSecret(ctx context.Context, userID string, secret string) error
+	EnableTOTP(ctx context.Context, userID string, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID string) error
+	UseRecoveryCode(ctx context.Context, userID string, codeHash string) error
+	UseTOTPCounter(ctx context.Context, userID string, counter int64) error
+	UseChallenge(ctx context.Context, jti string, expiresAt time.Time) error
+	RegisterFailedLogin(ctx context.Context, userID string) (attempts int, err error)
+	Lock(ctx context.Context, userID string, until time.Time) error
+	ResetFailedLogins(ctx context.Context, userID string) error
+	CreateWithIdentity(ctx context.Context, email string, username string, provider string, subject string) (*entity.User, error)
+	Search(ctx context.Context, query string, limit int, offset int) ([]entity.User, error)
+	Confirm(ctx context.Context, userID string) error
+	UpdateAccount(ctx context.Context, user *entity.User, update entity.AccountUpdate) error
+	SetRole(ctx context.Context, userID string, role string) error
+	Suspend(ctx context.Context, userID string) error
+	Unsuspend(ctx context.Context, userID string) error
 
 	RemoveExpiredRecords(ctx context.Context) (n int64, err error)
 }
 
 type Workout interface {
-	Create(ctx context.Context, userID string, date time.Time, duration int, kind string) (*entity.Workout, error)
+	Create(ctx context.Context, userID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, visibility string, metrics entity.Metrics, exercises []entity.Exercise) (*entity.Workout, error)
+	CreateBatch(ctx context.Context, userID string, workouts []entity.Workout) ([]entity.Workout, error)
+	Update(ctx context.Context, workoutID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, visibility string, metrics entity.Metrics, exercises []entity.Exercise) (*entity.Workout, error)
+	Import(ctx context.Context, userID string, activityID string, date time.Time, startedAt *time.Time, duration int, kind string, metrics entity.Metrics, track entity.Track, laps []entity.Lap) (*entity.Workout, error)
 	Delete(ctx context.Context, workoutID string) error
 	GetByID(ctx context.Context, id string) (*entity.Workout, error)
-	GetAllUserWorkouts(ctx context.Context, userID string) ([]entity.Workout, error)
+	GetTrack(ctx context.Context, workoutID string) (*entity.Track, error)
 	GetUserWorkouts(ctx context.Context, userID string, bedginDate time.Time, endDate time.Time) ([]entity.Workout, error)
+	ListUserWorkouts(ctx context.Context, userID string, filter entity.WorkoutFilter) ([]entity.Workout, error)
+	Feed(ctx context.Context, userID string, after *entity.WorkoutPosition, limit int) ([]entity.FeedWorkout, error)
+	Export(ctx context.Context, userID string, begin time.Time, end time.Time, withTracks bool, fn func(workout *entity.Workout) error) error
+	GetExercises(ctx context.Context, workoutIDs []string) ([]entity.Exercise, error)
+	GetLaps(ctx context.Context, workoutIDs []string) ([]entity.Lap, error)
+	GetCounts(ctx context.Context, workoutIDs []string, viewerID string) ([]entity.Counts, error)
+}
+
+type Statistics interface {
+	Summary(ctx context.Context, userID string, begin time.Time, end time.Time) (*entity.WorkoutSummary, error)
+	Kinds(ctx context.Context, userID string, begin time.Time, end time.Time) ([]entity.KindSummary, error)
+	Periods(ctx context.Context, userID string, begin time.Time, end time.Time, period string) ([]entity.PeriodSummary, error)
+	Streaks(ctx context.Context, userID string, today time.Time) (*entity.Streaks, error)
+}
+
+type Session interface {
+	Create(ctx context.Context, userID string, familyID string, refreshTokenHash string, expiresAt time.Time, device string, userAgent string, ipAddress string) (*entity.Session, error)
+	GetByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entity.Session, error)
+	GetActiveByUserID(ctx context.Context, userID string) ([]entity.Session, error)
+	Rotate(ctx context.Context, id string, userID string, familyID string, refreshTokenHash string, expiresAt time.Time, device string, userAgent string, ipAddress string) (*entity.Session, error)
+	Touch(ctx context.Context, familyID string, userAgent string, ipAddress string) (suspended bool, err error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllUserSessions(ctx context.Context, userID string) error
+	RevokeOtherUserSessions(ctx context.Context, userID string, familyID string) error
+
+	RemoveExpiredRecords(ctx context.Context) (n int64, err error)
+}
+
+type Identity interface {
+	Create(ctx context.Context, userID string, provider string, subject string, email string) (*entity.UserIdentity, error)
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*entity.UserIdentity, error)
+	GetByUserID(ctx context.Context, userID string) ([]entity.UserIdentity, error)
+	Delete(ctx context.Context, userID string, provider string) error
+	CreateState(ctx context.Context, state string, provider string, codeVerifier string, nonce string, userID string, expiresAt time.Time) error
+	ConsumeState(ctx context.Context, state string) (*entity.OAuthState, error)
+
+	RemoveExpiredRecords(ctx context.Context) (n int64, err error)
+}
+
+type APIKey interface {
+	Create(ctx context.Context, userID string, name string, prefix string, keyHash string, scopes []string, expiresAt *time.Time) (*entity.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	GetByUserID(ctx context.Context, userID string) ([]entity.APIKey, error)
+	Touch(ctx context.Context, id string) (suspended bool, err error)
+	Delete(ctx context.Context, userID string, id string) error
+}
+
+type Catalog interface {
+	Create(ctx context.Context, userID string, entryType string, name string, category string, muscleGroups []string, met float64) (*entity.CatalogEntry, error)
+	GetByID(ctx context.Context, userID string, id string) (*entity.CatalogEntry, error)
+	GetByName(ctx context.Context, userID string, entryType string, name string) (*entity.CatalogEntry, error)
+	Search(ctx context.Context, userID string, entryType string, category string, query string) ([]entity.CatalogEntry, error)
+	Delete(ctx context.Context, userID string, id string) error
+}
+
+type Follow interface {
+	Create(ctx context.Context, followerID string, followeeID string, isAccepted bool) (*entity.Follow, error)
+	Get(ctx context.Context, followerID string, followeeID string) (*entity.Follow, error)
+	Accept(ctx context.Context, followerID string, followeeID string) error
+	Reject(ctx context.Context, followerID string, followeeID string) error
+	Delete(ctx context.Context, followerID string, followeeID string) error
+	Followers(ctx context.Context, userID string, isAccepted bool, viewerID string) ([]entity.FollowUser, error)
+	Following(ctx context.Context, userID string, viewerID string) ([]entity.FollowUser, error)
+	Counts(ctx context.Context, userID string) (*entity.FollowCounts, error)
+}
+
+type Kudos interface {
+	Create(ctx context.Context, workoutID string, userID string) error
+	Delete(ctx context.Context, workoutID string, userID string) error
+	GetByWorkout(ctx context.Context, workoutID string, viewerID string) ([]entity.Kudos, error)
+}
+
+type Comment interface {
+	Create(ctx context.Context, workoutID string, userID string, parentID *string, text string) (*entity.Comment, error)
+	GetByID(ctx context.Context, id string) (*entity.Comment, error)
+	GetByWorkout(ctx context.Context, workoutID string, viewerID string) ([]entity.Comment, error)
+	Update(ctx context.Context, id string, text string) (*entity.Comment, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type Block interface {
+	Create(ctx context.Context, blockerID string, blockedID string) error
+	Delete(ctx context.Context, blockerID string, blockedID string) error
+	Exists(ctx context.Context, userID string, otherID string) (bool, error)
+	Blocked(ctx context.Context, userID string) ([]entity.ListedUser, error)
+}
+
+type Mute interface {
+	Create(ctx context.Context, muterID string, mutedID string) error
+	Delete(ctx context.Context, muterID string, mutedID string) error
+	Muted(ctx context.Context, userID string) ([]entity.ListedUser, error)
+}
+
+type RateLimit interface {
+	ratelimit.Limiter
+
+	RemoveExpiredRecords(ctx context.Context) (n int64, err error)
 }
 
 type Repository struct {
-	User    User
-	Workout Workout
+	User       User
+	Workout    Workout
+	Statistics Statistics
+	Session    Session
+	Identity   Identity
+	APIKey     APIKey
+	Catalog    Catalog
+	Follow     Follow
+	Kudos      Kudos
+	Comment    Comment
+	Block      Block
+	Mute       Mute
+	RateLimit  RateLimit
 }
 
 func New(pdb *sqlx.DB) *Repository {
 	return &Repository{
-		User:    user.New(pdb),
-		Workout: workout.New(pdb),
+		User:       user.New(pdb),
+		Workout:    workout.New(pdb),
+		Statistics: statistics.New(pdb),
+		Session:    session.New(pdb),
+		Identity:   identity.New(pdb),
+		APIKey:     apikey.New(pdb),
+		Catalog:    catalog.New(pdb),
+		Follow:     follow.New(pdb),
+		Kudos:      kudos.New(pdb),
+		Comment:    comment.New(pdb),
+		Block:      block.New(pdb),
+		Mute:       mute.New(pdb),
+		RateLimit:  ratelimitrepo.New(pdb),
 	}
 }
@@ -0,0 +1,218 @@
+package token
+
+import (
+	"api/internal/config"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	minRSAKeyBits = 2048
+)
+
+// JWK describes a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+
+	// RSA public key parameters
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519 public key parameters
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS describes a set of public keys in JSON Web Key Set format
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type key struct {
+	id         string
+	method     jwt.SigningMethod
+	signKey    any
+	verifyKey  any
+	activeFrom time.Time
+}
+
+// keySet is a list of keys, sorted by the time they become active
+type keySet []key
+
+func hmacKeySet(secret []byte) keySet {
+	return keySet{{
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}}
+}
+
+// signing returns the key, that was activated last
+func (s keySet) signing(now time.Time) key {
+	current := s[0]
+	for _, k := range s[1:] {
+		if k.activeFrom.After(now) {
+			break
+		}
+		current = k
+	}
+
+	return current
+}
+
+func (s keySet) sign(claims jwt.Claims) (string, error) {
+	k := s.signing(time.Now())
+
+	jsonwebtoken := jwt.NewWithClaims(k.method, claims)
+	if k.id != "" {
+		jsonwebtoken.Header["kid"] = k.id
+	}
+
+	return jsonwebtoken.SignedString(k.signKey)
+}
+
+// verificationKey looks up a key by token's `kid` header. Tokens are only
+// accepted if signed with the algorithm of that key
+func (s keySet) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	for _, k := range s {
+		if k.id != kid {
+			continue
+		}
+
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return k.verifyKey, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key: %q", kid)
+}
+
+func (s keySet) jwks() JWKS {
+	keys := make([]JWK, 0, len(s))
+	for _, k := range s {
+		switch public := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				KeyType:   "RSA",
+				Use:       "sig",
+				Algorithm: k.method.Alg(),
+				KeyID:     k.id,
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				KeyType:   "OKP",
+				Use:       "sig",
+				Algorithm: k.method.Alg(),
+				KeyID:     k.id,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return JWKS{Keys: keys}
+}
+
+func loadKeys(algorithm string, configured []config.TokenKey) (keySet, error) {
+	if len(configured) == 0 {
+		return nil, fmt.Errorf("token.loadKeys: no signing keys configured for %s", algorithm)
+	}
+
+	keys := make(keySet, 0, len(configured))
+	ids := make(map[string]bool, len(configured))
+	for _, c := range configured {
+		if c.ID == "" {
+			return nil, fmt.Errorf("token.loadKeys: signing key %s has no id", c.PrivateKeyFile)
+		}
+		if ids[c.ID] {
+			return nil, fmt.Errorf("token.loadKeys: duplicate signing key id %q", c.ID)
+		}
+		ids[c.ID] = true
+
+		data, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("token.loadKeys: can't read signing key %q: %w", c.ID, err)
+		}
+
+		k, err := parseKey(algorithm, data)
+		if err != nil {
+			return nil, fmt.Errorf("token.loadKeys: signing key %q: %w", c.ID, err)
+		}
+
+		k.id = c.ID
+		k.activeFrom = c.ActiveFrom
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].activeFrom.Before(keys[j].activeFrom)
+	})
+
+	if keys[0].activeFrom.After(time.Now()) {
+		return nil, fmt.Errorf("token.loadKeys: no signing key is active yet")
+	}
+
+	return keys, nil
+}
+
+// parseKey decodes PEM-encoded PKCS #8 private key, or PKCS #1 one for RSA
+func parseKey(algorithm string, data []byte) (key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return key{}, fmt.Errorf("no PEM data found")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		var pkcs1Err error
+		private, pkcs1Err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if pkcs1Err != nil {
+			return key{}, fmt.Errorf("can't parse private key: %w", err)
+		}
+	}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		rsaKey, ok := private.(*rsa.PrivateKey)
+		if !ok {
+			return key{}, fmt.Errorf("%s requires an RSA key, got %T", algorithm, private)
+		}
+		if rsaKey.N.BitLen() < minRSAKeyBits {
+			return key{}, fmt.Errorf("RSA key must be at least %d bits long", minRSAKeyBits)
+		}
+
+		return key{method: jwt.SigningMethodRS256, signKey: rsaKey, verifyKey: &rsaKey.PublicKey}, nil
+	case AlgorithmEdDSA:
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return key{}, fmt.Errorf("%s requires an Ed25519 key, got %T", algorithm, private)
+		}
+
+		return key{method: jwt.SigningMethodEdDSA, signKey: edKey, verifyKey: edKey.Public()}, nil
+	}
+
+	return key{}, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+}
@@ -2,7 +2,7 @@ package mock
 
 import (
 	"api/internal/config"
-	"fmt"
+	"api/internal/token"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,41 +18,39 @@ func New(c config.Token) *Config {
 	}
 }
 
-func (c *Config) GenerateJWT(id string) (token string, err error) {
-	jsonwebtoken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iat": time.Now().Unix(),
-		"id":  id,
+func (c *Config) GenerateJWT(userID string, sessionID string, role string) (t string, err error) {
+	now := time.Now()
+	jsonwebtoken := jwt.NewWithClaims(jwt.SigningMethodHS256, token.Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(token.DefaultAccessTTL)),
+		},
 	})
 
-	token, err = jsonwebtoken.SignedString([]byte(c.secret))
-	if err != nil {
-		return "", nil
-	}
-	return token, err
+	return jsonwebtoken.SignedString(c.secret)
 }
 
-func (c *Config) ParseJWT(token string) (id string, err error) {
-	jsonwebtoken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+func (c *Config) ParseJWT(t string) (claims *token.Claims, err error) {
+	return token.Parse(t, c.secret)
+}
 
-		return []byte(c.secret), nil
-	})
-	if err != nil {
-		return "", err
-	}
+func (c *Config) GenerateRefreshToken() (t string, expiresAt time.Time) {
+	return "REFRESH_TOKEN", time.Now().Add(token.DefaultRefreshTTL)
+}
 
-	claims, ok := jsonwebtoken.Claims.(jwt.MapClaims)
-	if !ok || !jsonwebtoken.Valid {
-		return "", fmt.Errorf("token.ParseToID: can't parse invalid jsonwebtoken")
-	}
-	userID, exists := claims["id"].(string)
-	if !exists {
-		return "", fmt.Errorf("token.ParseToID: no `id` field found in token's claims")
-	}
+func (c *Config) GenerateChallenge(userID string, device string) (t string, err error) {
+	return token.NewChallenge(userID, device, c.secret)
+}
+
+func (c *Config) ParseChallenge(t string) (claims *token.ChallengeClaims, err error) {
+	return token.ParseChallenge(t, c.secret)
+}
 
-	return userID, nil
+func (c *Config) JWKS() token.JWKS {
+	return token.JWKS{Keys: []token.JWK{}}
 }
 
 func (c *Config) Long() string {
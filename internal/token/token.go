@@ -3,65 +3,214 @@ package token
 import (
 	"api/internal/config"
 	"api/pkg/random"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+	ChallengeTTL      = 5 * time.Minute
+
+	purposeTwoFactor = "2fa"
+)
+
+var ErrTokenExpired = errors.New("token: token expired")
+
 type Manager interface {
-	GenerateJWT(id string) (token string, err error)
-	ParseJWT(token string) (id string, err error)
+	GenerateJWT(userID string, sessionID string, role string) (token string, err error)
+	ParseJWT(token string) (claims *Claims, err error)
+	GenerateRefreshToken() (token string, expiresAt time.Time)
+	GenerateChallenge(userID string, device string) (token string, err error)
+	ParseChallenge(token string) (claims *ChallengeClaims, err error)
+	JWKS() JWKS
 	Long() string
 }
 
+// Claims describes a payload of an access token
+type Claims struct {
+	UserID    string `json:"id"`
+	SessionID string `json:"sid"`
+	Role      string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// ChallengeClaims describes a payload of a token, issued after a password
+// check for accounts with two-factor authentication enabled
+type ChallengeClaims struct {
+	UserID  string `json:"id"`
+	Device  string `json:"device"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
 type Config struct {
-	secret []byte
+	keys       keySet
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
+// New returns token manager, that signs tokens with HS256 and a shared secret
 func New(c config.Token) *Config {
+	return newConfig(c, hmacKeySet([]byte(c.Secret)))
+}
+
+// NewAsymmetric returns token manager, that signs tokens with RS256 or EdDSA
+// private keys from configuration. Every configured key is accepted for
+// verification and published in JWKS, the one activated last signs new tokens
+func NewAsymmetric(c config.Token) (*Config, error) {
+	keys, err := loadKeys(c.Algorithm, c.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConfig(c, keys), nil
+}
+
+func newConfig(c config.Token, keys keySet) *Config {
+	accessTTL := c.AccessTTL
+	if accessTTL == 0 {
+		accessTTL = DefaultAccessTTL
+	}
+
+	refreshTTL := c.RefreshTTL
+	if refreshTTL == 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+
 	return &Config{
-		secret: []byte(c.Secret),
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
 	}
 }
 
-func (c *Config) GenerateJWT(id string) (token string, err error) {
-	jsonwebtoken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iat": time.Now().Unix(),
-		"id":  id,
+func (c *Config) GenerateJWT(userID string, sessionID string, role string) (token string, err error) {
+	now := time.Now()
+	return c.keys.sign(Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(c.accessTTL)),
+		},
 	})
+}
 
-	token, err = jsonwebtoken.SignedString([]byte(c.secret))
-	if err != nil {
-		return "", nil
-	}
-	return token, err
+func (c *Config) ParseJWT(token string) (claims *Claims, err error) {
+	return parseAccess(token, c.keys)
 }
 
-func (c *Config) ParseJWT(token string) (id string, err error) {
-	jsonwebtoken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+// GenerateRefreshToken returns a new opaque refresh token and the time it expires at
+func (c *Config) GenerateRefreshToken() (token string, expiresAt time.Time) {
+	b := make([]byte, 32)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b), time.Now().Add(c.refreshTTL)
+}
+
+func (c *Config) GenerateChallenge(userID string, device string) (token string, err error) {
+	return newChallenge(userID, device, c.keys)
+}
+
+func (c *Config) ParseChallenge(token string) (claims *ChallengeClaims, err error) {
+	return parseChallenge(token, c.keys)
+}
+
+// JWKS returns public keys, that can be used to verify issued tokens. It's
+// empty for HS256, as the shared secret must never be published
+func (c *Config) JWKS() JWKS {
+	return c.keys.jwks()
+}
 
-		return []byte(c.secret), nil
+func (c *Config) Long() string {
+	return random.String(64)
+}
+
+// Parse validates HS256-signed access token with given secret and returns its claims
+func Parse(token string, secret []byte) (*Claims, error) {
+	return parseAccess(token, hmacKeySet(secret))
+}
+
+// NewChallenge returns HS256-signed two-factor authentication challenge token
+func NewChallenge(userID string, device string, secret []byte) (string, error) {
+	return newChallenge(userID, device, hmacKeySet(secret))
+}
+
+// ParseChallenge validates HS256-signed two-factor authentication challenge token and returns its claims
+func ParseChallenge(token string, secret []byte) (*ChallengeClaims, error) {
+	return parseChallenge(token, hmacKeySet(secret))
+}
+
+func parseAccess(token string, keys keySet) (*Claims, error) {
+	var claims Claims
+	if err := parse(token, keys, &claims); err != nil {
+		return nil, err
+	}
+
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("token.Parse: no `id` field found in token's claims")
+	}
+	if claims.SessionID == "" {
+		return nil, fmt.Errorf("token.Parse: no `sid` field found in token's claims")
+	}
+
+	return &claims, nil
+}
+
+func newChallenge(userID string, device string, keys keySet) (string, error) {
+	now := time.Now()
+	return keys.sign(ChallengeClaims{
+		UserID:  userID,
+		Device:  device,
+		Purpose: purposeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeTTL)),
+		},
 	})
-	if err != nil {
-		return "", err
+}
+
+func parseChallenge(token string, keys keySet) (*ChallengeClaims, error) {
+	var claims ChallengeClaims
+	if err := parse(token, keys, &claims); err != nil {
+		return nil, err
 	}
 
-	claims, ok := jsonwebtoken.Claims.(jwt.MapClaims)
-	if !ok || !jsonwebtoken.Valid {
-		return "", fmt.Errorf("token.ParseToID: can't parse invalid jsonwebtoken")
+	if claims.Purpose != purposeTwoFactor {
+		return nil, fmt.Errorf("token.ParseChallenge: token is not a two-factor challenge")
+	}
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("token.ParseChallenge: no `id` field found in token's claims")
 	}
-	userID, exists := claims["id"].(string)
-	if !exists {
-		return "", fmt.Errorf("token.ParseToID: no `id` field found in token's claims")
+	if claims.ID == "" {
+		return nil, fmt.Errorf("token.ParseChallenge: no `jti` field found in token's claims")
 	}
 
-	return userID, nil
+	return &claims, nil
 }
 
-func (c *Config) Long() string {
-	return random.String(64)
+func parse(token string, keys keySet, claims jwt.Claims) error {
+	jsonwebtoken, err := jwt.ParseWithClaims(token, claims, keys.verificationKey, jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return ErrTokenExpired
+	}
+	if err != nil {
+		return err
+	}
+
+	if !jsonwebtoken.Valid {
+		return fmt.Errorf("token.parse: can't parse invalid jsonwebtoken")
+	}
+
+	return nil
 }
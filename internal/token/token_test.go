@@ -0,0 +1,288 @@
+package token
+
+import (
+	"api/internal/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, private any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	return path
+}
+
+func writeEd25519Key(t *testing.T) string {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return writeKey(t, private)
+}
+
+func header(t *testing.T, token string) map[string]any {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+
+	return parsed.Header
+}
+
+func TestHS256(t *testing.T) {
+	manager := New(config.Token{Secret: "secret"})
+
+	token, err := manager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	require.NoError(t, err)
+
+	assert.NotContains(t, header(t, token), "kid")
+
+	claims, err := manager.ParseJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, "USER_ID", claims.UserID)
+	assert.Equal(t, "SESSION_ID", claims.SessionID)
+
+	_, err = New(config.Token{Secret: "another"}).ParseJWT(token)
+	assert.Error(t, err)
+
+	assert.Empty(t, manager.JWKS().Keys)
+}
+
+func TestEdDSA(t *testing.T) {
+	manager, err := NewAsymmetric(config.Token{
+		Algorithm: AlgorithmEdDSA,
+		Keys: []config.TokenKey{
+			{ID: "first", PrivateKeyFile: writeEd25519Key(t), ActiveFrom: time.Now().Add(-time.Hour)},
+		},
+	})
+	require.NoError(t, err)
+
+	token, err := manager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	require.NoError(t, err)
+
+	h := header(t, token)
+	assert.Equal(t, "first", h["kid"])
+	assert.Equal(t, "EdDSA", h["alg"])
+
+	claims, err := manager.ParseJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, "USER_ID", claims.UserID)
+
+	challenge, err := manager.GenerateChallenge("USER_ID", "iPhone")
+	require.NoError(t, err)
+
+	challengeClaims, err := manager.ParseChallenge(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, "iPhone", challengeClaims.Device)
+	assert.NotEmpty(t, challengeClaims.ID)
+
+	jwks := manager.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, JWK{
+		KeyType:   "OKP",
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		KeyID:     "first",
+		Curve:     "Ed25519",
+		X:         jwks.Keys[0].X,
+	}, jwks.Keys[0])
+
+	x, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+	require.NoError(t, err)
+	assert.Len(t, x, ed25519.PublicKeySize)
+}
+
+func TestRS256(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	manager, err := NewAsymmetric(config.Token{
+		Algorithm: AlgorithmRS256,
+		Keys: []config.TokenKey{
+			{ID: "rsa", PrivateKeyFile: writeKey(t, private)},
+		},
+	})
+	require.NoError(t, err)
+
+	token, err := manager.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", header(t, token)["alg"])
+
+	_, err = manager.ParseJWT(token)
+	require.NoError(t, err)
+
+	jwks := manager.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+
+	n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+	require.NoError(t, err)
+	assert.Equal(t, private.N.Bytes(), n)
+}
+
+func TestRotation(t *testing.T) {
+	oldKey := writeEd25519Key(t)
+	newKey := writeEd25519Key(t)
+
+	before, err := NewAsymmetric(config.Token{
+		Algorithm: AlgorithmEdDSA,
+		Keys: []config.TokenKey{
+			{ID: "old", PrivateKeyFile: oldKey, ActiveFrom: time.Now().Add(-time.Hour)},
+			{ID: "new", PrivateKeyFile: newKey, ActiveFrom: time.Now().Add(time.Hour)},
+		},
+	})
+	require.NoError(t, err)
+
+	oldToken, err := before.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	require.NoError(t, err)
+	assert.Equal(t, "old", header(t, oldToken)["kid"], "scheduled key must not sign before it's active")
+	assert.Len(t, before.JWKS().Keys, 2, "scheduled key must be published in advance")
+
+	after, err := NewAsymmetric(config.Token{
+		Algorithm: AlgorithmEdDSA,
+		Keys: []config.TokenKey{
+			{ID: "new", PrivateKeyFile: newKey, ActiveFrom: time.Now().Add(-time.Minute)},
+			{ID: "old", PrivateKeyFile: oldKey, ActiveFrom: time.Now().Add(-time.Hour)},
+		},
+	})
+	require.NoError(t, err)
+
+	newToken, err := after.GenerateJWT("USER_ID", "SESSION_ID", "user")
+	require.NoError(t, err)
+	assert.Equal(t, "new", header(t, newToken)["kid"])
+
+	_, err = after.ParseJWT(oldToken)
+	assert.NoError(t, err, "tokens signed with previous key must stay valid")
+
+	_, err = before.ParseJWT(newToken)
+	assert.NoError(t, err)
+
+	retired, err := NewAsymmetric(config.Token{
+		Algorithm: AlgorithmEdDSA,
+		Keys: []config.TokenKey{
+			{ID: "new", PrivateKeyFile: newKey},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = retired.ParseJWT(oldToken)
+	assert.Error(t, err, "tokens signed with removed key must be rejected")
+}
+
+func TestParseRejectsForeignTokens(t *testing.T) {
+	manager, err := NewAsymmetric(config.Token{
+		Algorithm: AlgorithmEdDSA,
+		Keys: []config.TokenKey{
+			{ID: "first", PrivateKeyFile: writeEd25519Key(t)},
+		},
+	})
+	require.NoError(t, err)
+
+	claims := Claims{
+		UserID:    "USER_ID",
+		SessionID: "SESSION_ID",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	// HS256 token with a known key id, signed with the public key as a secret
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confused.Header["kid"] = "first"
+	token, err := confused.SignedString([]byte(manager.JWKS().Keys[0].X))
+	require.NoError(t, err)
+
+	_, err = manager.ParseJWT(token)
+	assert.Error(t, err)
+
+	hs256, err := New(config.Token{Secret: "secret"}).GenerateJWT("USER_ID", "SESSION_ID", "user")
+	require.NoError(t, err)
+
+	_, err = manager.ParseJWT(hs256)
+	assert.Error(t, err)
+}
+
+func TestNewAsymmetricErrors(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	edKey := writeEd25519Key(t)
+
+	tests := []struct {
+		name   string
+		config config.Token
+		err    string
+	}{
+		{
+			name:   "no keys",
+			config: config.Token{Algorithm: AlgorithmEdDSA},
+			err:    "no signing keys configured",
+		},
+		{
+			name: "missing id",
+			config: config.Token{Algorithm: AlgorithmEdDSA, Keys: []config.TokenKey{
+				{PrivateKeyFile: edKey},
+			}},
+			err: "has no id",
+		},
+		{
+			name: "duplicate id",
+			config: config.Token{Algorithm: AlgorithmEdDSA, Keys: []config.TokenKey{
+				{ID: "a", PrivateKeyFile: edKey},
+				{ID: "a", PrivateKeyFile: edKey},
+			}},
+			err: "duplicate signing key id",
+		},
+		{
+			name: "wrong key type",
+			config: config.Token{Algorithm: AlgorithmEdDSA, Keys: []config.TokenKey{
+				{ID: "a", PrivateKeyFile: writeKey(t, rsaKey)},
+			}},
+			err: "requires an Ed25519 key",
+		},
+		{
+			name: "missing file",
+			config: config.Token{Algorithm: AlgorithmEdDSA, Keys: []config.TokenKey{
+				{ID: "a", PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+			}},
+			err: "can't read signing key",
+		},
+		{
+			name: "no active key",
+			config: config.Token{Algorithm: AlgorithmEdDSA, Keys: []config.TokenKey{
+				{ID: "a", PrivateKeyFile: edKey, ActiveFrom: time.Now().Add(time.Hour)},
+			}},
+			err: "no signing key is active yet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAsymmetric(tt.config)
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), tt.err), err.Error())
+		})
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE sessions
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    family_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash CHAR(64) NOT NULL UNIQUE,
+    is_used BOOLEAN DEFAULT false NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
+
+CREATE INDEX sessions_family_id_idx ON sessions (family_id);
@@ -0,0 +1,6 @@
+ALTER TABLE sessions ADD COLUMN device VARCHAR(100) DEFAULT '' NOT NULL;
+ALTER TABLE sessions ADD COLUMN user_agent TEXT DEFAULT '' NOT NULL;
+ALTER TABLE sessions ADD COLUMN ip_address VARCHAR(45) DEFAULT '' NOT NULL;
+ALTER TABLE sessions ADD COLUMN last_seen_at TIMESTAMP DEFAULT now() NOT NULL;
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
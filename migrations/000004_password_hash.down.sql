@@ -0,0 +1,2 @@
+-- Fails if there are encoded hashes longer than legacy SHA-256 digests
+ALTER TABLE users ALTER COLUMN password_hash TYPE CHAR(64);
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64) DEFAULT '' NOT NULL;
+ALTER TABLE users ADD COLUMN is_totp_enabled BOOLEAN DEFAULT false NOT NULL;
+
+CREATE TABLE recovery_codes
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    is_used BOOLEAN DEFAULT false NOT NULL,
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
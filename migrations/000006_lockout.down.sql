@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS rate_limits;
+
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_attempts;
@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN failed_login_attempts INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP;
+
+CREATE TABLE rate_limits
+(
+    key VARCHAR(255) NOT NULL PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP DEFAULT now() NOT NULL
+);
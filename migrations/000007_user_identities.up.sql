@@ -0,0 +1,22 @@
+CREATE TABLE user_identities
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(254) DEFAULT '' NOT NULL,
+    created_at TIMESTAMP DEFAULT now() NOT NULL,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+CREATE TABLE oauth_states
+(
+    state VARCHAR(64) NOT NULL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    user_id VARCHAR(36) DEFAULT '' NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
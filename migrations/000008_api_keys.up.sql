@@ -0,0 +1,14 @@
+CREATE TABLE api_keys
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] DEFAULT '{}' NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(16) DEFAULT 'user' NOT NULL CHECK (role IN ('user', 'moderator', 'admin'));
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
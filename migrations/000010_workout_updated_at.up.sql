@@ -0,0 +1 @@
+ALTER TABLE workouts ADD COLUMN updated_at TIMESTAMP DEFAULT now() NOT NULL;
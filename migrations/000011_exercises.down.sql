@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS exercise_sets;
+DROP TABLE IF EXISTS workout_exercises;
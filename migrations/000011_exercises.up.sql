@@ -0,0 +1,21 @@
+CREATE TABLE workout_exercises
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    workout_id UUID NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    UNIQUE (workout_id, position)
+);
+
+CREATE TABLE exercise_sets
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    exercise_id UUID NOT NULL REFERENCES workout_exercises(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    reps INTEGER CHECK (reps >= 0),
+    weight NUMERIC(7, 2) CHECK (weight >= 0),
+    weight_unit VARCHAR(2) DEFAULT '' NOT NULL CHECK (weight_unit IN ('', 'kg', 'lb')),
+    rpe NUMERIC(3, 1) CHECK (rpe BETWEEN 1 AND 10),
+    rest_seconds INTEGER CHECK (rest_seconds >= 0),
+    UNIQUE (exercise_id, position)
+);
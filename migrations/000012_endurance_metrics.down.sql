@@ -0,0 +1,8 @@
+ALTER TABLE users DROP COLUMN IF EXISTS units;
+
+ALTER TABLE workouts DROP COLUMN IF EXISTS calories;
+ALTER TABLE workouts DROP COLUMN IF EXISTS cadence;
+ALTER TABLE workouts DROP COLUMN IF EXISTS max_heart_rate;
+ALTER TABLE workouts DROP COLUMN IF EXISTS avg_heart_rate;
+ALTER TABLE workouts DROP COLUMN IF EXISTS elevation_gain;
+ALTER TABLE workouts DROP COLUMN IF EXISTS distance;
@@ -0,0 +1,8 @@
+ALTER TABLE workouts ADD COLUMN distance DOUBLE PRECISION CHECK (distance >= 0);
+ALTER TABLE workouts ADD COLUMN elevation_gain DOUBLE PRECISION CHECK (elevation_gain >= 0);
+ALTER TABLE workouts ADD COLUMN avg_heart_rate SMALLINT CHECK (avg_heart_rate > 0);
+ALTER TABLE workouts ADD COLUMN max_heart_rate SMALLINT CHECK (max_heart_rate > 0);
+ALTER TABLE workouts ADD COLUMN cadence SMALLINT CHECK (cadence >= 0);
+ALTER TABLE workouts ADD COLUMN calories INTEGER CHECK (calories >= 0);
+
+ALTER TABLE users ADD COLUMN units VARCHAR(8) DEFAULT 'metric' NOT NULL CHECK (units IN ('metric', 'imperial'));
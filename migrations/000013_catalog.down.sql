@@ -0,0 +1,4 @@
+ALTER TABLE workout_exercises DROP COLUMN IF EXISTS exercise_id;
+ALTER TABLE workouts DROP COLUMN IF EXISTS activity_id;
+
+DROP TABLE IF EXISTS catalog;
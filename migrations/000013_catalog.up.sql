@@ -0,0 +1,81 @@
+CREATE TABLE catalog
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('activity', 'exercise')),
+    name VARCHAR(50) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    muscle_groups TEXT[] DEFAULT '{}' NOT NULL,
+    aliases TEXT[] DEFAULT '{}' NOT NULL,
+    met NUMERIC(4, 1) NOT NULL CHECK (met > 0),
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
+
+-- Built-in entries have no owner, custom ones are unique per user
+CREATE UNIQUE INDEX catalog_builtin_name_idx ON catalog (type, lower(name)) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX catalog_custom_name_idx ON catalog (user_id, type, lower(name)) WHERE user_id IS NOT NULL;
+
+INSERT INTO catalog (type, name, category, aliases, met) VALUES
+    ('activity', 'Running', 'cardio', '{run,jogging,jog}', 9.8),
+    ('activity', 'Walking', 'cardio', '{walk}', 3.5),
+    ('activity', 'Hiking', 'cardio', '{hike,trekking}', 6.0),
+    ('activity', 'Cycling', 'cardio', '{bike,biking,ride,bicycle}', 7.5),
+    ('activity', 'Swimming', 'cardio', '{swim,pool}', 6.0),
+    ('activity', 'Rowing', 'cardio', '{row}', 7.0),
+    ('activity', 'Elliptical', 'cardio', '{}', 5.0),
+    ('activity', 'HIIT', 'cardio', '{interval training}', 8.0),
+    ('activity', 'Strength training', 'strength', '{strength,gym,weights,weightlifting,lifting}', 5.0),
+    ('activity', 'Calisthenics', 'strength', '{bodyweight}', 8.0),
+    ('activity', 'CrossFit', 'strength', '{}', 8.0),
+    ('activity', 'Yoga', 'flexibility', '{}', 2.5),
+    ('activity', 'Pilates', 'flexibility', '{}', 3.0),
+    ('activity', 'Stretching', 'flexibility', '{mobility}', 2.3),
+    ('activity', 'Boxing', 'sports', '{kickboxing}', 7.8),
+    ('activity', 'Dancing', 'sports', '{dance}', 5.0),
+    ('activity', 'Football', 'sports', '{soccer}', 7.0),
+    ('activity', 'Basketball', 'sports', '{}', 6.5),
+    ('activity', 'Tennis', 'sports', '{}', 7.3),
+    ('activity', 'Climbing', 'sports', '{bouldering,rock climbing}', 8.0),
+    ('activity', 'Skiing', 'sports', '{ski,snowboarding}', 7.0),
+    ('activity', 'Other', 'other', '{}', 4.0);
+
+INSERT INTO catalog (type, name, category, muscle_groups, aliases, met) VALUES
+    ('exercise', 'Squat', 'barbell', '{quadriceps,glutes,hamstrings}', '{back squat}', 5.0),
+    ('exercise', 'Deadlift', 'barbell', '{hamstrings,glutes,back}', '{}', 6.0),
+    ('exercise', 'Bench press', 'barbell', '{chest,triceps,shoulders}', '{bench}', 5.0),
+    ('exercise', 'Overhead press', 'barbell', '{shoulders,triceps}', '{ohp,military press}', 5.0),
+    ('exercise', 'Barbell row', 'barbell', '{back,biceps}', '{bent over row}', 5.0),
+    ('exercise', 'Hip thrust', 'barbell', '{glutes,hamstrings}', '{}', 5.0),
+    ('exercise', 'Bicep curl', 'dumbbell', '{biceps,forearms}', '{curl}', 3.5),
+    ('exercise', 'Lunge', 'dumbbell', '{quadriceps,glutes}', '{}', 4.0),
+    ('exercise', 'Lateral raise', 'dumbbell', '{shoulders}', '{}', 3.5),
+    ('exercise', 'Pull-up', 'bodyweight', '{back,biceps}', '{pullup,chin-up}', 8.0),
+    ('exercise', 'Push-up', 'bodyweight', '{chest,triceps,shoulders}', '{pushup}', 3.8),
+    ('exercise', 'Dip', 'bodyweight', '{chest,triceps}', '{dips}', 5.0),
+    ('exercise', 'Plank', 'bodyweight', '{core}', '{}', 3.0),
+    ('exercise', 'Burpee', 'bodyweight', '{full_body}', '{burpees}', 8.0),
+    ('exercise', 'Leg press', 'machine', '{quadriceps,glutes}', '{}', 5.0),
+    ('exercise', 'Lat pulldown', 'cable', '{back,biceps}', '{}', 4.0),
+    ('exercise', 'Tricep pushdown', 'cable', '{triceps}', '{}', 3.5),
+    ('exercise', 'Calf raise', 'machine', '{calves}', '{}', 3.5);
+
+-- Map existing free-text kinds onto catalog entries, keeping unknown ones as
+-- a label of the fallback entry
+ALTER TABLE workouts ADD COLUMN activity_id UUID REFERENCES catalog(id);
+
+UPDATE workouts SET activity_id = catalog.id, kind = catalog.name
+FROM catalog
+WHERE catalog.user_id IS NULL AND catalog.type = 'activity'
+    AND (lower(trim(workouts.kind)) = lower(catalog.name) OR lower(trim(workouts.kind)) = ANY(catalog.aliases));
+
+UPDATE workouts SET activity_id = (SELECT id FROM catalog WHERE user_id IS NULL AND type = 'activity' AND name = 'Other')
+WHERE activity_id IS NULL;
+
+ALTER TABLE workouts ALTER COLUMN activity_id SET NOT NULL;
+
+ALTER TABLE workout_exercises ADD COLUMN exercise_id UUID REFERENCES catalog(id) ON DELETE SET NULL;
+
+UPDATE workout_exercises SET exercise_id = catalog.id
+FROM catalog
+WHERE catalog.user_id IS NULL AND catalog.type = 'exercise'
+    AND (lower(trim(workout_exercises.name)) = lower(catalog.name) OR lower(trim(workout_exercises.name)) = ANY(catalog.aliases));
@@ -0,0 +1,7 @@
+CREATE TABLE workout_tracks
+(
+    workout_id UUID NOT NULL UNIQUE REFERENCES workouts(id) ON DELETE CASCADE,
+    format VARCHAR(8) NOT NULL CHECK (format IN ('gpx', 'tcx')),
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
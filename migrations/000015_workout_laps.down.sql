@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS workout_laps;
+
+DELETE FROM workout_tracks WHERE format = 'fit';
+ALTER TABLE workout_tracks DROP CONSTRAINT IF EXISTS workout_tracks_format_check;
+ALTER TABLE workout_tracks ADD CONSTRAINT workout_tracks_format_check CHECK (format IN ('gpx', 'tcx'));
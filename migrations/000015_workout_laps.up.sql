@@ -0,0 +1,16 @@
+ALTER TABLE workout_tracks DROP CONSTRAINT IF EXISTS workout_tracks_format_check;
+ALTER TABLE workout_tracks ADD CONSTRAINT workout_tracks_format_check CHECK (format IN ('gpx', 'tcx', 'fit'));
+
+CREATE TABLE workout_laps
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    workout_id UUID NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    duration INTEGER NOT NULL CHECK (duration >= 0),
+    distance DOUBLE PRECISION CHECK (distance >= 0),
+    avg_heart_rate SMALLINT CHECK (avg_heart_rate > 0),
+    max_heart_rate SMALLINT CHECK (max_heart_rate > 0),
+    calories INTEGER CHECK (calories >= 0),
+    UNIQUE (workout_id, position)
+);
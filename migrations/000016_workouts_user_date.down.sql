@@ -0,0 +1 @@
+DROP INDEX IF EXISTS workouts_user_id_date_idx;
@@ -0,0 +1 @@
+CREATE INDEX workouts_user_id_date_idx ON workouts (user_id, date);
@@ -0,0 +1,3 @@
+ALTER TABLE workouts DROP COLUMN IF EXISTS started_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS time_zone;
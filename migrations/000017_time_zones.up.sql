@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN time_zone VARCHAR(64) DEFAULT 'UTC' NOT NULL;
+
+ALTER TABLE workouts ADD COLUMN started_at TIMESTAMPTZ;
@@ -0,0 +1,11 @@
+CREATE TABLE follows
+(
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_accepted BOOLEAN DEFAULT false NOT NULL,
+    created_at TIMESTAMP DEFAULT now() NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS workouts_user_id_date_idx;
+CREATE INDEX workouts_user_id_position_idx ON workouts (user_id, date DESC, created_at DESC, id DESC);
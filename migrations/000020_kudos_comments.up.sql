@@ -0,0 +1,20 @@
+CREATE TABLE kudos
+(
+    workout_id UUID NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT now() NOT NULL,
+    PRIMARY KEY (workout_id, user_id)
+);
+
+CREATE TABLE comments
+(
+    id UUID DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    workout_id UUID NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    text VARCHAR(1000) NOT NULL,
+    edited_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now() NOT NULL
+);
+
+CREATE INDEX comments_workout_id_idx ON comments (workout_id, created_at);
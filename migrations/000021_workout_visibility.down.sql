@@ -0,0 +1,3 @@
+ALTER TABLE workouts DROP COLUMN IF EXISTS visibility;
+
+ALTER TABLE users DROP COLUMN IF EXISTS default_visibility;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN default_visibility VARCHAR(16) DEFAULT 'public' NOT NULL CHECK (default_visibility IN ('public', 'followers', 'only_me'));
+
+ALTER TABLE workouts ADD COLUMN visibility VARCHAR(16) DEFAULT 'public' NOT NULL CHECK (visibility IN ('public', 'followers', 'only_me'));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS used_challenges;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_counter;
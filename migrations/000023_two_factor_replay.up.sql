@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN totp_last_counter BIGINT DEFAULT 0 NOT NULL;
+
+CREATE TABLE used_challenges
+(
+    jti VARCHAR(64) NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
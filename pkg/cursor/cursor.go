@@ -0,0 +1,36 @@
+// Package cursor encodes positions of keyset pagination into opaque tokens,
+// that clients pass back as is to get the next page
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalid = errors.New("invalid cursor")
+
+// Encode encodes a position as a URL safe token
+func Encode(position any) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decode decodes a token into a position. Any malformed token is reported
+// as ErrInvalid, since it came from a client
+func Decode(token string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalid
+	}
+
+	if err := json.Unmarshal(data, position); err != nil {
+		return ErrInvalid
+	}
+
+	return nil
+}
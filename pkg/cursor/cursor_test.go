@@ -0,0 +1,50 @@
+package cursor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type position struct {
+	Date time.Time `json:"d"`
+	ID   string    `json:"i"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	want := position{Date: time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC), ID: "WORKOUT_ID"}
+
+	token, err := Encode(want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	var got position
+	if err := Decode(token, &got); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if !got.Date.Equal(want.Date) || got.ID != want.ID {
+		t.Fatalf("unexpected position: got %+v, want %+v\n", got, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tt := []struct {
+		name  string
+		token string
+	}{
+		{name: "Not base64", token: "%%%"},
+		{name: "Not JSON", token: "bm90IGpzb24"},
+		{name: "Wrong type", token: "WzFd"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got position
+			if err := Decode(tc.token, &got); !errors.Is(err, ErrInvalid) {
+				t.Fatalf("unexpected error: got %v, want %v\n", err, ErrInvalid)
+			}
+		})
+	}
+}
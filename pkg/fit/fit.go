@@ -0,0 +1,373 @@
+// Package fit decodes activity files in Garmin's Flexible and Interoperable
+// Data Transfer format. Only activity, session, lap and record messages are
+// read, the rest are skipped
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFIT   = errors.New("fit: not a FIT file")
+	ErrChecksum = errors.New("fit: checksum mismatch")
+)
+
+// epoch is a start of FIT timestamps, 1989-12-31T00:00:00Z
+var epoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// Global message numbers
+const (
+	messageSession  = 18
+	messageLap      = 19
+	messageRecord   = 20
+	messageActivity = 34
+
+	fieldTimestamp = 253
+)
+
+type Activity struct {
+	Timestamp      time.Time
+	TotalTimerTime time.Duration
+	NumSessions    *int
+}
+
+type Session struct {
+	Sport        string
+	StartTime    time.Time
+	ElapsedTime  time.Duration
+	TimerTime    time.Duration
+	Distance     *float64
+	TotalAscent  *float64
+	Calories     *int
+	AvgHeartRate *int
+	MaxHeartRate *int
+	AvgCadence   *int
+}
+
+type Lap struct {
+	StartTime    time.Time
+	ElapsedTime  time.Duration
+	TimerTime    time.Duration
+	Distance     *float64
+	TotalAscent  *float64
+	Calories     *int
+	AvgHeartRate *int
+	MaxHeartRate *int
+	AvgCadence   *int
+}
+
+// Record is a single sample of an activity. Position is in degrees,
+// altitude and distance are in meters, speed is in meters per second
+type Record struct {
+	Timestamp time.Time
+	Latitude  *float64
+	Longitude *float64
+	Altitude  *float64
+	HeartRate *int
+	Cadence   *int
+	Distance  *float64
+	Speed     *float64
+}
+
+type File struct {
+	Activity *Activity
+	Sessions []Session
+	Laps     []Lap
+	Records  []Record
+}
+
+// Decode reads a whole FIT file and decodes its messages. Both checksums of
+// the file are verified. Chained files are not supported, only the first one
+// is decoded
+func Decode(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 12 {
+		return nil, ErrNotFIT
+	}
+
+	headerSize := int(data[0])
+	if headerSize != 12 && headerSize != 14 || len(data) < headerSize || string(data[8:12]) != ".FIT" {
+		return nil, ErrNotFIT
+	}
+
+	if headerSize == 14 {
+		headerCRC := binary.LittleEndian.Uint16(data[12:14])
+		if headerCRC != 0 && headerCRC != checksum(data[:12]) {
+			return nil, ErrChecksum
+		}
+	}
+
+	end := uint64(headerSize) + uint64(binary.LittleEndian.Uint32(data[4:8]))
+	if end+2 > uint64(len(data)) {
+		return nil, fmt.Errorf("fit.Decode: %w", io.ErrUnexpectedEOF)
+	}
+
+	if checksum(data[:end]) != binary.LittleEndian.Uint16(data[end:end+2]) {
+		return nil, ErrChecksum
+	}
+
+	d := decoder{data: data[headerSize:end]}
+	if err := d.decode(); err != nil {
+		return nil, fmt.Errorf("fit.Decode: %w", err)
+	}
+
+	return &d.file, nil
+}
+
+type fieldDefinition struct {
+	number   byte
+	size     int
+	baseType byte
+}
+
+type definition struct {
+	global        uint16
+	order         binary.ByteOrder
+	fields        []fieldDefinition
+	developerSize int
+}
+
+// message holds valid numeric fields of a data message by their numbers
+type message map[byte]float64
+
+type decoder struct {
+	data        []byte
+	pos         int
+	definitions [16]*definition
+
+	// timestamp is the last one read, compressed timestamps are offsets
+	// from it
+	timestamp uint32
+
+	file File
+}
+
+func (d *decoder) decode() error {
+	for d.pos < len(d.data) {
+		header, err := d.next(1)
+		if err != nil {
+			return err
+		}
+
+		switch h := header[0]; {
+		case h&0x80 != 0:
+			offset := uint32(h & 0x1f)
+			timestamp := d.timestamp&^0x1f + offset
+			if offset < d.timestamp&0x1f {
+				timestamp += 0x20
+			}
+
+			if err := d.readData((h>>5)&0x03, &timestamp); err != nil {
+				return err
+			}
+		case h&0x40 != 0:
+			if err := d.readDefinition(h&0x0f, h&0x20 != 0); err != nil {
+				return err
+			}
+		default:
+			if err := d.readData(h&0x0f, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *decoder) readDefinition(local byte, developer bool) error {
+	header, err := d.next(5)
+	if err != nil {
+		return err
+	}
+
+	def := definition{order: binary.LittleEndian}
+	if header[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(header[2:4])
+
+	fields, err := d.next(int(header[4]) * 3)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fieldDefinition{
+			number:   fields[i],
+			size:     int(fields[i+1]),
+			baseType: fields[i+2],
+		})
+	}
+
+	if developer {
+		count, err := d.next(1)
+		if err != nil {
+			return err
+		}
+
+		fields, err := d.next(int(count[0]) * 3)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(fields); i += 3 {
+			def.developerSize += int(fields[i+1])
+		}
+	}
+
+	d.definitions[local] = &def
+
+	return nil
+}
+
+func (d *decoder) readData(local byte, timestamp *uint32) error {
+	def := d.definitions[local]
+	if def == nil {
+		return fmt.Errorf("data message of undefined local type %d", local)
+	}
+
+	m := make(message, len(def.fields))
+	for _, field := range def.fields {
+		b, err := d.next(field.size)
+		if err != nil {
+			return err
+		}
+
+		if value, ok := decodeValue(b, field.baseType, def.order); ok {
+			m[field.number] = value
+		}
+	}
+
+	if _, err := d.next(def.developerSize); err != nil {
+		return err
+	}
+
+	if timestamp != nil {
+		m[fieldTimestamp] = float64(*timestamp)
+	}
+	if value, ok := m[fieldTimestamp]; ok {
+		d.timestamp = uint32(value)
+	}
+
+	switch def.global {
+	case messageActivity:
+		d.file.Activity = &Activity{
+			Timestamp:      m.time(fieldTimestamp),
+			TotalTimerTime: m.duration(0),
+			NumSessions:    m.int(1),
+		}
+	case messageSession:
+		d.file.Sessions = append(d.file.Sessions, Session{
+			Sport:        sportName(m),
+			StartTime:    m.time(2),
+			ElapsedTime:  m.duration(7),
+			TimerTime:    m.duration(8),
+			Distance:     m.scaled(9, 100, 0),
+			TotalAscent:  m.scaled(22, 1, 0),
+			Calories:     m.int(11),
+			AvgHeartRate: m.int(16),
+			MaxHeartRate: m.int(17),
+			AvgCadence:   m.int(18),
+		})
+	case messageLap:
+		d.file.Laps = append(d.file.Laps, Lap{
+			StartTime:    m.time(2),
+			ElapsedTime:  m.duration(7),
+			TimerTime:    m.duration(8),
+			Distance:     m.scaled(9, 100, 0),
+			TotalAscent:  m.scaled(21, 1, 0),
+			Calories:     m.int(11),
+			AvgHeartRate: m.int(15),
+			MaxHeartRate: m.int(16),
+			AvgCadence:   m.int(17),
+		})
+	case messageRecord:
+		altitude := m.scaled(78, 5, 500)
+		if altitude == nil {
+			altitude = m.scaled(2, 5, 500)
+		}
+
+		speed := m.scaled(73, 1000, 0)
+		if speed == nil {
+			speed = m.scaled(6, 1000, 0)
+		}
+
+		d.file.Records = append(d.file.Records, Record{
+			Timestamp: m.time(fieldTimestamp),
+			Latitude:  m.semicircles(0),
+			Longitude: m.semicircles(1),
+			Altitude:  altitude,
+			HeartRate: m.int(3),
+			Cadence:   m.int(4),
+			Distance:  m.scaled(5, 100, 0),
+			Speed:     speed,
+		})
+	}
+
+	return nil
+}
+
+func (m message) time(number byte) time.Time {
+	value, ok := m[number]
+	if !ok {
+		return time.Time{}
+	}
+
+	return epoch.Add(time.Duration(value) * time.Second)
+}
+
+// duration reads a time in milliseconds
+func (m message) duration(number byte) time.Duration {
+	return time.Duration(m[number]) * time.Millisecond
+}
+
+func (m message) int(number byte) *int {
+	value, ok := m[number]
+	if !ok {
+		return nil
+	}
+
+	v := int(value)
+	return &v
+}
+
+// scaled reads a field, stored as value * scale + offset
+func (m message) scaled(number byte, scale float64, offset float64) *float64 {
+	value, ok := m[number]
+	if !ok {
+		return nil
+	}
+
+	v := value/scale - offset
+	return &v
+}
+
+// semicircles reads a coordinate in degrees, stored as a fraction of 2^31
+func (m message) semicircles(number byte) *float64 {
+	value, ok := m[number]
+	if !ok {
+		return nil
+	}
+
+	v := value * 180 / (1 << 31)
+	return &v
+}
@@ -0,0 +1,135 @@
+package fit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/run.fit")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	file, err := Decode(bytes.NewReader(readFixture(t)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	start := time.Date(2024, time.May, 1, 6, 0, 0, 0, time.UTC)
+
+	if file.Activity == nil || file.Activity.NumSessions == nil || *file.Activity.NumSessions != 1 {
+		t.Fatalf("unexpected activity: %+v\n", file.Activity)
+	}
+	if file.Activity.TotalTimerTime != 30*time.Minute+30*time.Second {
+		t.Fatalf("unexpected total timer time: %v\n", file.Activity.TotalTimerTime)
+	}
+
+	if len(file.Sessions) != 1 {
+		t.Fatalf("unexpected number of sessions: got %d, want 1\n", len(file.Sessions))
+	}
+
+	session := file.Sessions[0]
+	if session.Sport != "running" {
+		t.Fatalf("unexpected sport: got %q, want %q\n", session.Sport, "running")
+	}
+	if !session.StartTime.Equal(start) {
+		t.Fatalf("unexpected start time: %v\n", session.StartTime)
+	}
+	if session.Distance == nil || *session.Distance != 6050 {
+		t.Fatalf("unexpected distance: %v\n", session.Distance)
+	}
+	if session.Calories == nil || *session.Calories != 420 {
+		t.Fatalf("unexpected calories: %v\n", session.Calories)
+	}
+	if session.TotalAscent == nil || *session.TotalAscent != 15 {
+		t.Fatalf("unexpected total ascent: %v\n", session.TotalAscent)
+	}
+
+	// laps are encoded in big endian
+	if len(file.Laps) != 2 {
+		t.Fatalf("unexpected number of laps: got %d, want 2\n", len(file.Laps))
+	}
+
+	lap := file.Laps[1]
+	if !lap.StartTime.Equal(start.Add(15 * time.Minute)) {
+		t.Fatalf("unexpected lap start time: %v\n", lap.StartTime)
+	}
+	if lap.ElapsedTime != 15*time.Minute+30*time.Second {
+		t.Fatalf("unexpected lap elapsed time: %v\n", lap.ElapsedTime)
+	}
+	if lap.Distance == nil || *lap.Distance != 3050 {
+		t.Fatalf("unexpected lap distance: %v\n", lap.Distance)
+	}
+	if lap.AvgHeartRate == nil || *lap.AvgHeartRate != 155 || lap.MaxHeartRate == nil || *lap.MaxHeartRate != 160 {
+		t.Fatalf("unexpected lap heart rate: %v, %v\n", lap.AvgHeartRate, lap.MaxHeartRate)
+	}
+	if lap.AvgCadence != nil {
+		t.Fatalf("invalid cadence should be skipped, got %d\n", *lap.AvgCadence)
+	}
+
+	if len(file.Records) != 8 {
+		t.Fatalf("unexpected number of records: got %d, want 8\n", len(file.Records))
+	}
+
+	first := file.Records[0]
+	if !first.Timestamp.Equal(start) {
+		t.Fatalf("unexpected timestamp: %v\n", first.Timestamp)
+	}
+	if first.Latitude == nil || math.Abs(*first.Latitude-52.5) > 1e-6 {
+		t.Fatalf("unexpected latitude: %v\n", first.Latitude)
+	}
+	if first.Altitude == nil || *first.Altitude != 100 {
+		t.Fatalf("unexpected altitude: %v\n", first.Altitude)
+	}
+	if first.Speed == nil || *first.Speed != 3.333 {
+		t.Fatalf("unexpected speed: %v\n", first.Speed)
+	}
+
+	// the last record has a compressed timestamp
+	last := file.Records[len(file.Records)-1]
+	if !last.Timestamp.Equal(start.Add(30*time.Minute + 30*time.Second)) {
+		t.Fatalf("unexpected compressed timestamp: %v\n", last.Timestamp)
+	}
+	if last.HeartRate == nil || *last.HeartRate != 150 || last.Latitude != nil {
+		t.Fatalf("unexpected record: %+v\n", last)
+	}
+}
+
+func TestDecodeChecksum(t *testing.T) {
+	data := readFixture(t)
+	data[len(data)/2] ^= 0xff
+
+	_, err := Decode(bytes.NewReader(data))
+	if !errors.Is(err, ErrChecksum) {
+		t.Fatalf("unexpected error: got %v, want %v\n", err, ErrChecksum)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data := readFixture(t)
+
+	_, err := Decode(bytes.NewReader(data[:len(data)-10]))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("unexpected error: got %v, want %v\n", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestDecodeNotFIT(t *testing.T) {
+	_, err := Decode(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?><gpx></gpx>`))
+	if !errors.Is(err, ErrNotFIT) {
+		t.Fatalf("unexpected error: got %v, want %v\n", err, ErrNotFIT)
+	}
+}
@@ -0,0 +1,160 @@
+package fit
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Base types of fields. The most significant bit only marks multi-byte types
+// and is ignored
+const (
+	typeEnum    = 0x00
+	typeSint8   = 0x01
+	typeUint8   = 0x02
+	typeSint16  = 0x03
+	typeUint16  = 0x04
+	typeSint32  = 0x05
+	typeUint32  = 0x06
+	typeFloat32 = 0x08
+	typeFloat64 = 0x09
+	typeUint8z  = 0x0a
+	typeUint16z = 0x0b
+	typeUint32z = 0x0c
+	typeByte    = 0x0d
+	typeSint64  = 0x0e
+	typeUint64  = 0x0f
+	typeUint64z = 0x10
+)
+
+// decodeValue decodes a single numeric value of given base type. Invalid
+// values, arrays and strings are reported as not ok
+func decodeValue(b []byte, baseType byte, order binary.ByteOrder) (float64, bool) {
+	switch baseType & 0x1f {
+	case typeEnum, typeUint8, typeByte:
+		if len(b) != 1 || b[0] == 0xff {
+			return 0, false
+		}
+		return float64(b[0]), true
+	case typeUint8z:
+		if len(b) != 1 || b[0] == 0 {
+			return 0, false
+		}
+		return float64(b[0]), true
+	case typeSint8:
+		if len(b) != 1 || b[0] == 0x7f {
+			return 0, false
+		}
+		return float64(int8(b[0])), true
+	case typeUint16, typeUint16z:
+		if len(b) != 2 {
+			return 0, false
+		}
+		v := order.Uint16(b)
+		if v == math.MaxUint16 && baseType&0x1f == typeUint16 || v == 0 && baseType&0x1f == typeUint16z {
+			return 0, false
+		}
+		return float64(v), true
+	case typeSint16:
+		if len(b) != 2 {
+			return 0, false
+		}
+		v := int16(order.Uint16(b))
+		if v == math.MaxInt16 {
+			return 0, false
+		}
+		return float64(v), true
+	case typeUint32, typeUint32z:
+		if len(b) != 4 {
+			return 0, false
+		}
+		v := order.Uint32(b)
+		if v == math.MaxUint32 && baseType&0x1f == typeUint32 || v == 0 && baseType&0x1f == typeUint32z {
+			return 0, false
+		}
+		return float64(v), true
+	case typeSint32:
+		if len(b) != 4 {
+			return 0, false
+		}
+		v := int32(order.Uint32(b))
+		if v == math.MaxInt32 {
+			return 0, false
+		}
+		return float64(v), true
+	case typeUint64, typeUint64z:
+		if len(b) != 8 {
+			return 0, false
+		}
+		v := order.Uint64(b)
+		if v == math.MaxUint64 && baseType&0x1f == typeUint64 || v == 0 && baseType&0x1f == typeUint64z {
+			return 0, false
+		}
+		return float64(v), true
+	case typeSint64:
+		if len(b) != 8 {
+			return 0, false
+		}
+		v := int64(order.Uint64(b))
+		if v == math.MaxInt64 {
+			return 0, false
+		}
+		return float64(v), true
+	case typeFloat32:
+		if len(b) != 4 || order.Uint32(b) == math.MaxUint32 {
+			return 0, false
+		}
+		return float64(math.Float32frombits(order.Uint32(b))), true
+	case typeFloat64:
+		if len(b) != 8 || order.Uint64(b) == math.MaxUint64 {
+			return 0, false
+		}
+		return math.Float64frombits(order.Uint64(b)), true
+	}
+
+	return 0, false
+}
+
+// sports are names of `sport` enum values of FIT profile
+var sports = []string{
+	"generic", "running", "cycling", "transition", "fitness_equipment",
+	"swimming", "basketball", "soccer", "tennis", "american_football",
+	"training", "walking", "cross_country_skiing", "alpine_skiing", "snowboarding",
+	"rowing", "mountaineering", "hiking", "multisport", "paddling",
+	"flying", "e_biking", "motorcycling", "boating", "driving",
+	"golf", "hang_gliding", "horseback_riding", "hunting", "fishing",
+	"inline_skating", "rock_climbing", "sailing", "ice_skating", "sky_diving",
+	"snowshoeing", "snowmobiling", "stand_up_paddleboarding", "surfing", "wakeboarding",
+	"water_skiing", "kayaking", "rafting", "windsurfing", "kitesurfing",
+	"tactical", "jumpmaster", "boxing", "floor_climbing",
+}
+
+// sportName returns a name of session's sport, empty for unknown ones
+func sportName(m message) string {
+	value, ok := m[5]
+	if !ok || int(value) >= len(sports) {
+		return ""
+	}
+
+	return sports[int(value)]
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+	0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+}
+
+// checksum computes CRC-16, that FIT uses for headers and files
+func checksum(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := crcTable[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ crcTable[b&0xf]
+
+		tmp = crcTable[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ crcTable[(b>>4)&0xf]
+	}
+
+	return crc
+}
@@ -0,0 +1,132 @@
+// Package ical writes calendars in iCalendar format (RFC 5545), so they
+// can be subscribed to or imported by calendar apps
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+
+	// maxLineLength is a length of a content line in octets, longer lines
+	// are folded
+	maxLineLength = 75
+)
+
+// Event is a single VEVENT. All-day events use dates of Start and End only,
+// End is exclusive for them
+type Event struct {
+	UID         string
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Summary     string
+	Description string
+	Categories  []string
+}
+
+// Writer writes events of a single calendar one by one, without keeping
+// them in memory
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewWriter writes a calendar header and returns a writer for its events
+func NewWriter(w io.Writer, prodID string) *Writer {
+	cw := &Writer{w: bufio.NewWriter(w)}
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:" + prodID)
+	cw.line("CALSCALE:GREGORIAN")
+
+	return cw
+}
+
+func (w *Writer) WriteEvent(e Event) error {
+	w.line("BEGIN:VEVENT")
+	w.line("UID:" + escape(e.UID))
+	w.line("DTSTAMP:" + e.Stamp.UTC().Format(dateTimeLayout))
+
+	if e.AllDay {
+		w.line("DTSTART;VALUE=DATE:" + e.Start.Format(dateLayout))
+		w.line("DTEND;VALUE=DATE:" + e.End.Format(dateLayout))
+	} else {
+		w.line("DTSTART:" + e.Start.UTC().Format(dateTimeLayout))
+		w.line("DTEND:" + e.End.UTC().Format(dateTimeLayout))
+	}
+
+	w.line("SUMMARY:" + escape(e.Summary))
+	if e.Description != "" {
+		w.line("DESCRIPTION:" + escape(e.Description))
+	}
+
+	if len(e.Categories) > 0 {
+		categories := make([]string, 0, len(e.Categories))
+		for _, category := range e.Categories {
+			categories = append(categories, escape(category))
+		}
+		w.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+
+	w.line("END:VEVENT")
+
+	return w.err
+}
+
+// Flush writes buffered events to the underlying writer
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	return w.w.Flush()
+}
+
+// Close ends the calendar and flushes it
+func (w *Writer) Close() error {
+	w.line("END:VCALENDAR")
+
+	return w.Flush()
+}
+
+// line writes a content line, folded by maxLineLength octets without
+// breaking multi-byte characters
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+
+	limit := maxLineLength
+	for len(s) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+
+		if _, w.err = w.w.WriteString(s[:i] + "\r\n "); w.err != nil {
+			return
+		}
+		s = s[i:]
+
+		// continuation lines start with a space, that takes an octet
+		limit = maxLineLength - 1
+	}
+
+	_, w.err = w.w.WriteString(s + "\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a text value
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,92 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, "-//test//EN")
+
+	err := w.WriteEvent(Event{
+		UID:         "WORKOUT_ID@test",
+		Stamp:       time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC),
+		Start:       time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC),
+		AllDay:      true,
+		Summary:     "Running, 30 min",
+		Description: "Distance: 5 km\nPace: 6:00 /km",
+		Categories:  []string{"Running"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	want := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//test//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:WORKOUT_ID@test\r\n" +
+		"DTSTAMP:20240501T103000Z\r\n" +
+		"DTSTART;VALUE=DATE:20240501\r\n" +
+		"DTEND;VALUE=DATE:20240502\r\n" +
+		"SUMMARY:Running\\, 30 min\r\n" +
+		"DESCRIPTION:Distance: 5 km\\nPace: 6:00 /km\r\n" +
+		"CATEGORIES:Running\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	if buf.String() != want {
+		t.Fatalf("unexpected calendar:\ngot  %q\nwant %q\n", buf.String(), want)
+	}
+}
+
+func TestFolding(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, "-//test//EN")
+
+	summary := strings.Repeat("ж", 100)
+	err := w.WriteEvent(Event{
+		UID:     "ID",
+		Start:   time.Date(2024, time.May, 1, 6, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, time.May, 1, 7, 0, 0, 0, time.UTC),
+		Summary: summary,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	var unfolded string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineLength {
+			t.Fatalf("line is longer than %d octets: %q\n", maxLineLength, line)
+		}
+
+		if strings.HasPrefix(line, " ") {
+			unfolded += line[1:]
+		} else {
+			unfolded += "\n" + line
+		}
+	}
+
+	if !strings.Contains(unfolded, "\nSUMMARY:"+summary+"\n") {
+		t.Fatalf("folded summary is broken: %q\n", unfolded)
+	}
+	if !strings.Contains(unfolded, "\nDTSTART:20240501T060000Z\n") {
+		t.Fatalf("unexpected start: %q\n", unfolded)
+	}
+}
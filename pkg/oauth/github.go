@@ -0,0 +1,67 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// githubIdentity fetches GitHub user, as GitHub doesn't issue ID tokens
+func (p *Provider) githubIdentity(ctx context.Context, accessToken string) (*Identity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.githubGet(ctx, p.config.UserInfoURL, accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("%w: no user id in response", ErrExchangeFailed)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.githubGet(ctx, p.config.UserInfoURL+"/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+		Username: user.Login,
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return identity, nil
+}
+
+func (p *Provider) githubGet(ctx context.Context, endpoint string, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	status, err := p.do(req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%w: GitHub API responded with status %d", ErrExchangeFailed, status)
+	}
+
+	return nil
+}
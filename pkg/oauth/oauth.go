@@ -0,0 +1,193 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	KindOIDC   = "oidc"
+	KindGitHub = "github"
+)
+
+var (
+	ErrExchangeFailed = errors.New("oauth: authorization code exchange failed")
+	ErrInvalidIDToken = errors.New("oauth: invalid id token")
+)
+
+// Config describes a single sign-in provider. OIDC providers only need an
+// Issuer, their endpoints are discovered. Endpoints set explicitly take
+// precedence over discovered ones
+type Config struct {
+	Name         string
+	Kind         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+}
+
+// Identity is a user, authenticated by a provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+}
+
+// Provider performs authorization code flow with PKCE against a single provider
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]any
+}
+
+func New(c Config) *Provider {
+	if c.Kind == "" {
+		c.Kind = KindOIDC
+	}
+
+	if c.Kind == KindGitHub {
+		if c.AuthURL == "" {
+			c.AuthURL = "https://github.com/login/oauth/authorize"
+		}
+		if c.TokenURL == "" {
+			c.TokenURL = "https://github.com/login/oauth/access_token"
+		}
+		if c.UserInfoURL == "" {
+			c.UserInfoURL = "https://api.github.com/user"
+		}
+		if len(c.Scopes) == 0 {
+			c.Scopes = []string{"read:user", "user:email"}
+		}
+	}
+
+	if c.Kind == KindOIDC && len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &Provider{
+		config: c,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   make(map[string]any),
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL returns a URL of provider's consent page
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, verifier string, nonce string) (string, error) {
+	endpoint := p.config.AuthURL
+	if endpoint == "" {
+		m, err := p.discover(ctx)
+		if err != nil {
+			return "", err
+		}
+		endpoint = m.AuthorizationEndpoint
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if p.config.Kind == KindOIDC {
+		query.Set("nonce", nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+
+	return endpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems authorization code and returns authenticated identity
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Identity, error) {
+	endpoint := p.config.TokenURL
+	if endpoint == "" {
+		m, err := p.discover(ctx)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = m.TokenEndpoint
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("%w: %d %s %s", ErrExchangeFailed, status, tokens.Error, tokens.ErrorDescription)
+	}
+
+	if p.config.Kind == KindGitHub {
+		return p.githubIdentity(ctx, tokens.AccessToken)
+	}
+
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchangeFailed)
+	}
+
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// do sends a request and decodes JSON response body into v
+func (p *Provider) do(req *http.Request, v any) (status int, err error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return resp.StatusCode, fmt.Errorf("oauth: can't decode response from %s: %w", req.URL, err)
+	}
+
+	return resp.StatusCode, nil
+}
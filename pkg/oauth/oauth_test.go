@@ -0,0 +1,98 @@
+package oauth_test
+
+import (
+	"api/pkg/oauth"
+	"api/pkg/oauth/oauthtest"
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oauth.Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestExchange(t *testing.T) {
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	provider := oauth.New(server.Config("test", "app://callback"))
+	ctx := context.Background()
+
+	user := oauthtest.User{
+		Subject:       "SUBJECT",
+		Email:         "john.doe@example.com",
+		EmailVerified: true,
+		Username:      "johndoe",
+	}
+
+	verifier := oauth.NewVerifier()
+	nonce := oauth.NewState()
+
+	authURL, err := provider.AuthCodeURL(ctx, "STATE", verifier, nonce)
+	assert.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "STATE", u.Query().Get("state"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+
+	t.Run("ok", func(t *testing.T) {
+		code, err := server.AuthorizeURL(authURL, user)
+		assert.NoError(t, err)
+
+		identity, err := provider.Exchange(ctx, code, verifier, nonce)
+		assert.NoError(t, err)
+		assert.Equal(t, &oauth.Identity{
+			Subject:       user.Subject,
+			Email:         user.Email,
+			EmailVerified: true,
+			Username:      user.Username,
+		}, identity)
+	})
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		code, err := server.AuthorizeURL(authURL, user)
+		assert.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, oauth.NewVerifier(), nonce)
+		assert.True(t, errors.Is(err, oauth.ErrExchangeFailed), err)
+	})
+
+	t.Run("code reused", func(t *testing.T) {
+		code, err := server.AuthorizeURL(authURL, user)
+		assert.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, verifier, nonce)
+		assert.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, verifier, nonce)
+		assert.True(t, errors.Is(err, oauth.ErrExchangeFailed), err)
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		code, err := server.AuthorizeURL(authURL, user)
+		assert.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, verifier, "ANOTHER_NONCE")
+		assert.True(t, errors.Is(err, oauth.ErrInvalidIDToken), err)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		config := server.Config("test", "app://callback")
+		config.ClientID = "another-client"
+		another := oauth.New(config)
+
+		code, err := server.AuthorizeURL(authURL, user)
+		assert.NoError(t, err)
+
+		_, err = another.Exchange(ctx, code, verifier, nonce)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,31 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// NewVerifier returns a random PKCE code verifier (RFC 7636)
+func NewVerifier() string {
+	return randomString(32)
+}
+
+// NewState returns a random value suitable for `state` and `nonce` parameters
+func NewState() string {
+	return randomString(24)
+}
+
+// Challenge returns S256 PKCE code challenge for given verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,56 @@
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt algorithm
+type Bcrypt struct {
+	Cost int
+}
+
+func NewBcrypt(cost int) *Bcrypt {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &Bcrypt{
+		Cost: cost,
+	}
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (b *Bcrypt) Verify(password string, encoded string) (bool, error) {
+	return Verify(password, encoded)
+}
+
+func (b *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+
+	return cost != b.Cost
+}
+
+func verifyBcrypt(password string, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+
+	return true, nil
+}
@@ -0,0 +1,51 @@
+package password
+
+import (
+	"api/pkg/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUnknownFormat = errors.New("password: unknown hash format")
+	ErrInvalidHash   = errors.New("password: invalid encoded hash")
+)
+
+// Hasher produces encoded password hashes. Every hasher can verify hashes
+// of any supported algorithm, so switching the algorithm does not lock out
+// existing users
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (bool, error)
+	NeedsRehash(encoded string) bool
+}
+
+// Verify checks if password matches encoded hash. Supported formats are
+// argon2id and bcrypt in PHC string format and legacy unsalted SHA-256 hex digests
+func Verify(password string, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return verifyBcrypt(password, encoded)
+	case IsLegacy(encoded):
+		return subtle.ConstantTimeCompare([]byte(sha256.String(password)), []byte(encoded)) == 1, nil
+	}
+
+	return false, ErrUnknownFormat
+}
+
+// IsLegacy reports whether encoded hash is an unsalted SHA-256 digest
+func IsLegacy(encoded string) bool {
+	if len(encoded) != 64 {
+		return false
+	}
+
+	for _, r := range encoded {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
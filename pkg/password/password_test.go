@@ -0,0 +1,185 @@
+package password
+
+import (
+	"api/pkg/sha256"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	argon, err := NewArgon2id().Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	bcrypt, err := NewBcrypt(4).Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	tt := []struct {
+		name     string
+		password string
+		encoded  string
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "argon2id: match",
+			password: "testword",
+			encoded:  argon,
+			want:     true,
+		},
+		{
+			name:     "argon2id: mismatch",
+			password: "wrong-password",
+			encoded:  argon,
+			want:     false,
+		},
+		{
+			name:     "bcrypt: match",
+			password: "testword",
+			encoded:  bcrypt,
+			want:     true,
+		},
+		{
+			name:     "bcrypt: mismatch",
+			password: "wrong-password",
+			encoded:  bcrypt,
+			want:     false,
+		},
+		{
+			name:     "legacy sha256: match",
+			password: "testword",
+			encoded:  sha256.String("testword"),
+			want:     true,
+		},
+		{
+			name:     "legacy sha256: mismatch",
+			password: "wrong-password",
+			encoded:  sha256.String("testword"),
+			want:     false,
+		},
+		{
+			name:     "unknown format",
+			password: "testword",
+			encoded:  "plain-text-password",
+			wantErr:  ErrUnknownFormat,
+		},
+		{
+			name:     "corrupted argon2id hash",
+			password: "testword",
+			encoded:  "$argon2id$v=19$m=19456,t=2,p=1$not-base64!$",
+			wantErr:  ErrInvalidHash,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Verify(tc.password, tc.encoded)
+			if err != tc.wantErr {
+				t.Fatalf("unexpected error: got %v, want %v\n", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("unexpected result of password.Verify: got %v, want %v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idHash(t *testing.T) {
+	hasher := NewArgon2id()
+
+	first, err := hasher.Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	second, err := hasher.Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Fatalf("unexpected encoded hash format: %v\n", first)
+	}
+	if first == second {
+		t.Fatal("hashes of the same password should be salted")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon, err := NewArgon2id().Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	weakArgon, err := (&Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}).Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	bcrypt, err := NewBcrypt(4).Hash("testword")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	tt := []struct {
+		name    string
+		hasher  Hasher
+		encoded string
+		want    bool
+	}{
+		{
+			name:    "argon2id: same parameters",
+			hasher:  NewArgon2id(),
+			encoded: argon,
+			want:    false,
+		},
+		{
+			name:    "argon2id: weaker parameters",
+			hasher:  NewArgon2id(),
+			encoded: weakArgon,
+			want:    true,
+		},
+		{
+			name:    "argon2id: legacy sha256",
+			hasher:  NewArgon2id(),
+			encoded: sha256.String("testword"),
+			want:    true,
+		},
+		{
+			name:    "argon2id: bcrypt",
+			hasher:  NewArgon2id(),
+			encoded: bcrypt,
+			want:    true,
+		},
+		{
+			name:    "bcrypt: same cost",
+			hasher:  NewBcrypt(4),
+			encoded: bcrypt,
+			want:    false,
+		},
+		{
+			name:    "bcrypt: other cost",
+			hasher:  NewBcrypt(5),
+			encoded: bcrypt,
+			want:    true,
+		},
+		{
+			name:    "bcrypt: argon2id",
+			hasher:  NewBcrypt(4),
+			encoded: argon,
+			want:    true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.hasher.NeedsRehash(tc.encoded)
+			if got != tc.want {
+				t.Fatalf("unexpected result of NeedsRehash: got %v, want %v\n", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,9 @@
 package random
 
 import (
+	"crypto/rand"
 	"fmt"
-	"time"
-
-	"math/rand"
+	"math/big"
 )
 
 const (
@@ -36,11 +35,16 @@ func StringWith(length int, opts uint8) string {
 		charset = fmt.Sprintf("%s%s", charset, "0123456789")
 	}
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	// Strings are used as secrets, so they must be cryptographically random
+	max := big.NewInt(int64(len(charset)))
 
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[r.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("random: can't read random bytes: %v", err))
+		}
+		b[i] = charset[n.Int64()]
 	}
 	return string(b)
 }
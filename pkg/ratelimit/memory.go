@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = 10 * time.Minute
+
+type entry struct {
+	bucket Bucket
+	rule   Rule
+}
+
+// Memory is an in-process Limiter. Buckets are not shared between replicas
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	e, ok := m.buckets[key]
+	if !ok {
+		e = entry{bucket: NewBucket(rule, now)}
+	}
+
+	bucket, result := e.bucket.Take(rule, now)
+	m.buckets[key] = entry{bucket: bucket, rule: rule}
+
+	if m.lastSweep.IsZero() {
+		m.lastSweep = now
+	} else if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	return result, nil
+}
+
+// sweep removes buckets that have been refilled completely, as they are
+// indistinguishable from new ones
+func (m *Memory) sweep(now time.Time) {
+	for key, e := range m.buckets {
+		if e.bucket.IsFull(e.rule, now) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastSweep = now
+}
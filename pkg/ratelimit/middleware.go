@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"api/internal/lib/logger/sl"
+	"api/pkg/requestid"
+	"api/pkg/sha256"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc extracts a key to limit requests by. Requests with an empty key
+// are not limited
+type KeyFunc func(c *gin.Context) string
+
+// ByIP limits requests by client's IP address
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// maxJSONBodySize is the most of a request body read to find a key field.
+// Bodies of larger requests share one bucket, so padding a body doesn't help
+// to dodge the limit
+const maxJSONBodySize = 64 * 1024 // 64Kb
+
+// ByJSONField limits requests by a string field of JSON request body, e.g.
+// login or email. The body is left intact for the next handlers
+func ByJSONField(field string) KeyFunc {
+	return ByJSONFieldFunc(field, func(value string) string {
+		return strings.ToLower(strings.TrimSpace(value))
+	})
+}
+
+// ByJSONFieldFunc limits requests by a key that fn derives from a string
+// field of JSON request body, e.g. by user ID of a token. Keys are hashed, so
+// they have the same length regardless of the field's value
+func ByJSONFieldFunc(field string, fn func(value string) string) KeyFunc {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return ""
+		}
+
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxJSONBodySize+1))
+		if err != nil {
+			return ""
+		}
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), c.Request.Body))
+
+		if len(data) > maxJSONBodySize {
+			return "oversized"
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			return ""
+		}
+
+		value, _ := body[field].(string)
+		if key := fn(value); key != "" {
+			return sha256.String(key)
+		}
+		return ""
+	}
+}
+
+// New initializes a middleware that limits requests to rule per key. Buckets
+// are namespaced by name, so different route groups don't share them
+func New(l Limiter, name string, rule Rule, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" || rule.Limit <= 0 {
+			c.Next()
+			return
+		}
+
+		result, err := l.Take(c, fmt.Sprintf("%s:%s", name, k), rule)
+		if err != nil {
+			// Limiter failures should not make the API unavailable
+			slog.Error("can't take a rate limit token",
+				slog.String("op", "ratelimit.New"),
+				slog.String("request_id", requestid.Get(c)),
+				sl.Err(err),
+			)
+			c.Next()
+			return
+		}
+
+		if !result.Allowed {
+			slog.Debug("rate limit exceeded",
+				slog.String("request_id", requestid.Get(c)),
+				slog.String("limit", name),
+			)
+
+			SetRetryAfter(c, result.RetryAfter)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SetRetryAfter sets Retry-After header, rounding given duration up to seconds
+func SetRetryAfter(c *gin.Context, d time.Duration) {
+	c.Header("Retry-After", fmt.Sprintf("%d", int64(math.Max(1, math.Ceil(d.Seconds())))))
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule allows Limit requests per Period, refilled continuously
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Result describes an outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter is a storage of token buckets
+type Limiter interface {
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// Bucket is a state of a single token bucket
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewBucket returns a full bucket for given rule
+func NewBucket(rule Rule, now time.Time) Bucket {
+	return Bucket{
+		Tokens:    float64(rule.Limit),
+		UpdatedAt: now,
+	}
+}
+
+// Take refills the bucket for the time passed since its last update and tries
+// to take a single token from it. Returns an updated bucket state
+func (b Bucket) Take(rule Rule, now time.Time) (Bucket, Result) {
+	rate := rule.rate()
+
+	elapsed := now.Sub(b.UpdatedAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	tokens := math.Min(float64(rule.Limit), b.Tokens+elapsed*rate)
+	if tokens >= 1 {
+		return Bucket{Tokens: tokens - 1, UpdatedAt: now}, Result{
+			Allowed:   true,
+			Remaining: int(tokens - 1),
+		}
+	}
+
+	return Bucket{Tokens: tokens, UpdatedAt: now}, Result{
+		Allowed:    false,
+		RetryAfter: time.Duration((1 - tokens) / rate * float64(time.Second)),
+	}
+}
+
+// IsFull reports whether the bucket would have been refilled completely by now
+func (b Bucket) IsFull(rule Rule, now time.Time) bool {
+	return b.Tokens+now.Sub(b.UpdatedAt).Seconds()*rule.rate() >= float64(rule.Limit)
+}
+
+// rate returns amount of tokens added to a bucket every second
+func (r Rule) rate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
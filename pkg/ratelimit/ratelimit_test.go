@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketTake(t *testing.T) {
+	rule := Rule{Limit: 3, Period: 3 * time.Second}
+	now := time.Unix(1700000000, 0)
+
+	bucket := NewBucket(rule, now)
+
+	for i := 0; i < 3; i++ {
+		var result Result
+		bucket, result = bucket.Take(rule, now)
+		assert.True(t, result.Allowed, "request %d should be allowed", i)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	bucket, result := bucket.Take(rule, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	bucket, result = bucket.Take(rule, now.Add(500*time.Millisecond))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	_, result = bucket.Take(rule, now.Add(time.Second))
+	assert.True(t, result.Allowed)
+}
+
+func TestBucketRefillIsCapped(t *testing.T) {
+	rule := Rule{Limit: 2, Period: time.Minute}
+	now := time.Unix(1700000000, 0)
+
+	bucket, _ := NewBucket(rule, now).Take(rule, now)
+	assert.False(t, bucket.IsFull(rule, now))
+	assert.True(t, bucket.IsFull(rule, now.Add(time.Hour)))
+
+	bucket, result := bucket.Take(rule, now.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, float64(1), bucket.Tokens)
+}
+
+func TestMemory(t *testing.T) {
+	rule := Rule{Limit: 1, Period: time.Minute}
+	now := time.Unix(1700000000, 0)
+
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	result, err := m.Take(context.Background(), "a", rule)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = m.Take(context.Background(), "a", rule)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	result, err = m.Take(context.Background(), "b", rule)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed, "buckets should not be shared between keys")
+
+	now = now.Add(sweepInterval + time.Second)
+	_, err = m.Take(context.Background(), "c", rule)
+	assert.NoError(t, err)
+	assert.Len(t, m.buckets, 1, "refilled buckets should be swept")
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	rule := Rule{Limit: 1, Period: time.Minute}
+	m := NewMemory()
+
+	router.POST("/login", New(m, "login", rule, ByJSONField("login")), func(c *gin.Context) {
+		var body struct {
+			Login string `json:"login"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			return
+		}
+		c.String(http.StatusOK, body.Login)
+	})
+
+	tt := []struct {
+		body   string
+		status int
+	}{
+		{body: `{"login":"johndoe"}`, status: http.StatusOK},
+		{body: `{"login":"JohnDoe "}`, status: http.StatusTooManyRequests},
+		{body: `{"login":"janedoe"}`, status: http.StatusOK},
+		{body: `{}`, status: http.StatusOK},
+		{body: `{"login":"` + strings.Repeat("a", maxJSONBodySize) + `"}`, status: http.StatusOK},
+		{body: `{"login":"` + strings.Repeat("b", maxJSONBodySize) + `"}`, status: http.StatusTooManyRequests},
+	}
+
+	for _, tc := range tt {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/login", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.body)
+		if tc.status == http.StatusOK {
+			// The body should reach the handler intact
+			assert.Contains(t, tc.body, w.Body.String())
+		}
+		if tc.status == http.StatusTooManyRequests {
+			assert.Equal(t, "60", w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestByJSONFieldHashesKey(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"login":"`+strings.Repeat("a", 1000)+`"}`))
+
+	key := ByJSONField("login")(c)
+	assert.Len(t, key, 64)
+}
@@ -0,0 +1,109 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret from RFC 6238 test vectors: ASCII "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestHOTP(t *testing.T) {
+	// Test vectors from RFC 4226, appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for i, code := range want {
+		got := hotp([]byte("12345678901234567890"), uint64(i), 6)
+		if got != code {
+			t.Fatalf("unexpected HOTP value for counter %d: got %v, want %v\n", i, got, code)
+		}
+	}
+}
+
+func TestCode(t *testing.T) {
+	// Test vectors from RFC 6238, appendix B (SHA1), truncated to 6 digits
+	tt := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tc := range tt {
+		got, err := Code(rfcSecret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n", err)
+		}
+		if got != tc.want {
+			t.Fatalf("unexpected TOTP value at %d: got %v, want %v\n", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	code, err := Code(rfcSecret, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	tt := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{name: "same period", code: code, at: now, want: true},
+		{name: "previous period", code: code, at: now.Add(Period), want: true},
+		{name: "next period", code: code, at: now.Add(-Period), want: true},
+		{name: "too late", code: code, at: now.Add(3 * Period), want: false},
+		{name: "wrong code", code: "000000", at: now, want: false},
+		{name: "wrong length", code: "0059", at: now, want: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Validate(tc.code, rfcSecret, tc.at)
+			if got != tc.want {
+				t.Fatalf("unexpected result of totp.Validate: got %v, want %v\n", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	code, err := Code(rfcSecret, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	// A code from the previous period matches the counter it was generated for
+	got, ok := Match(code, rfcSecret, now.Add(Period))
+	if !ok {
+		t.Fatalf("code should match\n")
+	}
+	if want := counter(now); got != want {
+		t.Fatalf("unexpected counter returned by totp.Match: got %v, want %v\n", got, want)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("yodreik", "johndoe", rfcSecret)
+
+	if !strings.HasPrefix(uri, "otpauth://totp/yodreik:johndoe?") {
+		t.Fatalf("unexpected URI prefix: %v\n", uri)
+	}
+	if !strings.Contains(uri, "secret="+rfcSecret) {
+		t.Fatalf("URI should contain the secret: %v\n", uri)
+	}
+}
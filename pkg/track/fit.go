@@ -0,0 +1,54 @@
+package track
+
+import (
+	"api/pkg/fit"
+	"fmt"
+	"io"
+)
+
+// ParseFIT parses a Garmin FIT activity file. Sport and calories are taken
+// from its first session
+func ParseFIT(r io.Reader) (*Activity, error) {
+	file, err := fit.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("track.ParseFIT: %w", err)
+	}
+
+	var activity Activity
+	if len(file.Sessions) > 0 {
+		activity.Sport = file.Sessions[0].Sport
+		activity.Calories = file.Sessions[0].Calories
+	}
+
+	for _, r := range file.Records {
+		if r.Timestamp.IsZero() {
+			continue
+		}
+
+		point := Point{
+			Time:      r.Timestamp,
+			Elevation: r.Altitude,
+			HeartRate: r.HeartRate,
+			Cadence:   r.Cadence,
+			Distance:  r.Distance,
+		}
+		if r.Latitude != nil && r.Longitude != nil {
+			point.Latitude, point.Longitude = r.Latitude, r.Longitude
+		}
+
+		activity.Points = append(activity.Points, point)
+	}
+
+	for _, l := range file.Laps {
+		activity.Laps = append(activity.Laps, Lap{
+			Start:        l.StartTime,
+			Duration:     l.ElapsedTime,
+			Distance:     l.Distance,
+			AvgHeartRate: l.AvgHeartRate,
+			MaxHeartRate: l.MaxHeartRate,
+			Calories:     l.Calories,
+		})
+	}
+
+	return &activity, nil
+}
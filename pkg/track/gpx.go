@@ -0,0 +1,177 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type gpxFile struct {
+	XMLName xml.Name   `xml:"gpx"`
+	Tracks  []gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Type     string       `xml:"type"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+// gpxPoint reads heart rate and cadence from Garmin's TrackPointExtension,
+// that most devices and services use
+type gpxPoint struct {
+	Latitude  float64  `xml:"lat,attr"`
+	Longitude float64  `xml:"lon,attr"`
+	Elevation *float64 `xml:"ele"`
+	Time      string   `xml:"time"`
+	HeartRate *int     `xml:"extensions>TrackPointExtension>hr"`
+	Cadence   *int     `xml:"extensions>TrackPointExtension>cad"`
+}
+
+// ParseGPX parses tracks of a GPX 1.0 or 1.1 file. Points without time,
+// like ones of planned routes, are skipped
+func ParseGPX(r io.Reader) (*Activity, error) {
+	var file gpxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("track.ParseGPX: %w", err)
+	}
+
+	var activity Activity
+	for _, trk := range file.Tracks {
+		if activity.Sport == "" {
+			activity.Sport = strings.TrimSpace(trk.Type)
+		}
+
+		for _, segment := range trk.Segments {
+			for _, p := range segment.Points {
+				if p.Time == "" {
+					continue
+				}
+
+				t, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+				if err != nil {
+					return nil, fmt.Errorf("track.ParseGPX: invalid time of a point: %w", err)
+				}
+
+				latitude, longitude := p.Latitude, p.Longitude
+				activity.Points = append(activity.Points, Point{
+					Time:      t,
+					Latitude:  &latitude,
+					Longitude: &longitude,
+					Elevation: p.Elevation,
+					HeartRate: p.HeartRate,
+					Cadence:   p.Cadence,
+				})
+			}
+		}
+	}
+
+	return &activity, nil
+}
+
+type gpxOutputTrack struct {
+	XMLName  xml.Name           `xml:"trk"`
+	Name     string             `xml:"name,omitempty"`
+	Desc     string             `xml:"desc,omitempty"`
+	Type     string             `xml:"type,omitempty"`
+	Segments []gpxOutputSegment `xml:"trkseg"`
+}
+
+type gpxOutputSegment struct {
+	Points []gpxOutputPoint `xml:"trkpt"`
+}
+
+type gpxOutputPoint struct {
+	Latitude   float64              `xml:"lat,attr"`
+	Longitude  float64              `xml:"lon,attr"`
+	Elevation  *float64             `xml:"ele,omitempty"`
+	Time       string               `xml:"time"`
+	Extensions *gpxOutputExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxOutputExtensions struct {
+	HeartRate *int `xml:"gpxtpx:TrackPointExtension>gpxtpx:hr,omitempty"`
+	Cadence   *int `xml:"gpxtpx:TrackPointExtension>gpxtpx:cad,omitempty"`
+}
+
+// GPXWriter writes tracks of a single GPX 1.1 file one by one, without
+// keeping them in memory
+type GPXWriter struct {
+	enc *xml.Encoder
+}
+
+// NewGPXWriter writes a header of a GPX file and returns a writer for its
+// tracks
+func NewGPXWriter(w io.Writer, creator string) (*GPXWriter, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err := enc.EncodeToken(xml.StartElement{
+		Name: xml.Name{Local: "gpx"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: "1.1"},
+			{Name: xml.Name{Local: "creator"}, Value: creator},
+			{Name: xml.Name{Local: "xmlns"}, Value: "http://www.topografix.com/GPX/1/1"},
+			{Name: xml.Name{Local: "xmlns:gpxtpx"}, Value: "http://www.garmin.com/xmlschemas/TrackPointExtension/v1"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GPXWriter{enc: enc}, nil
+}
+
+// WriteTrack writes a track of given points as a single segment. Points
+// without a position are skipped, as GPX requires one
+func (g *GPXWriter) WriteTrack(name string, description string, sport string, points []Point) error {
+	trk := gpxOutputTrack{Name: name, Desc: description, Type: sport}
+
+	var segment gpxOutputSegment
+	for _, p := range points {
+		if p.Latitude == nil || p.Longitude == nil {
+			continue
+		}
+
+		point := gpxOutputPoint{
+			Latitude:  *p.Latitude,
+			Longitude: *p.Longitude,
+			Elevation: p.Elevation,
+			Time:      p.Time.UTC().Format(time.RFC3339),
+		}
+		if p.HeartRate != nil || p.Cadence != nil {
+			point.Extensions = &gpxOutputExtensions{HeartRate: p.HeartRate, Cadence: p.Cadence}
+		}
+
+		segment.Points = append(segment.Points, point)
+	}
+
+	if len(segment.Points) > 0 {
+		trk.Segments = append(trk.Segments, segment)
+	}
+
+	return g.enc.Encode(trk)
+}
+
+// Flush writes buffered tracks to the underlying writer
+func (g *GPXWriter) Flush() error {
+	return g.enc.Flush()
+}
+
+// Close ends the file and flushes it
+func (g *GPXWriter) Close() error {
+	if err := g.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "gpx"}}); err != nil {
+		return err
+	}
+
+	return g.enc.Close()
+}
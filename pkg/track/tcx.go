@@ -0,0 +1,87 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type tcxFile struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	Calories *int       `xml:"Calories"`
+	Points   []tcxPoint `xml:"Track>Trackpoint"`
+}
+
+type tcxPoint struct {
+	Time      string   `xml:"Time"`
+	Latitude  *float64 `xml:"Position>LatitudeDegrees"`
+	Longitude *float64 `xml:"Position>LongitudeDegrees"`
+	Elevation *float64 `xml:"AltitudeMeters"`
+	Distance  *float64 `xml:"DistanceMeters"`
+	HeartRate *int     `xml:"HeartRateBpm>Value"`
+	Cadence   *int     `xml:"Cadence"`
+}
+
+// ParseTCX parses the first activity of a Garmin Training Center file.
+// Calories are summed up over its laps
+func ParseTCX(r io.Reader) (*Activity, error) {
+	var file tcxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("track.ParseTCX: %w", err)
+	}
+
+	var activity Activity
+	if len(file.Activities) == 0 {
+		return &activity, nil
+	}
+
+	a := file.Activities[0]
+	activity.Sport = strings.TrimSpace(a.Sport)
+
+	for _, lap := range a.Laps {
+		if lap.Calories != nil {
+			calories := *lap.Calories
+			if activity.Calories != nil {
+				calories += *activity.Calories
+			}
+			activity.Calories = &calories
+		}
+
+		for _, p := range lap.Points {
+			if p.Time == "" {
+				continue
+			}
+
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+			if err != nil {
+				return nil, fmt.Errorf("track.ParseTCX: invalid time of a point: %w", err)
+			}
+
+			point := Point{
+				Time:      t,
+				Elevation: p.Elevation,
+				Distance:  p.Distance,
+				HeartRate: p.HeartRate,
+				Cadence:   p.Cadence,
+			}
+			if p.Latitude != nil && p.Longitude != nil {
+				point.Latitude, point.Longitude = p.Latitude, p.Longitude
+			}
+
+			activity.Points = append(activity.Points, point)
+		}
+	}
+
+	return &activity, nil
+}
@@ -0,0 +1,172 @@
+// Package track parses activities, recorded by GPS watches and bike
+// computers, and summarizes them. Distances are in meters
+package track
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	earthRadius = 6371008.8
+
+	// elevationThreshold is a climb, that has to be accumulated before it's
+	// counted, so GPS noise on flat ground doesn't add up to elevation gain
+	elevationThreshold = 2.0
+)
+
+var ErrNoPoints = errors.New("track: less than two timestamped points")
+
+// Point is a single sample of a recorded activity. Fields, that weren't
+// recorded by the device, are nil
+type Point struct {
+	Time      time.Time
+	Latitude  *float64
+	Longitude *float64
+	Elevation *float64
+	HeartRate *int
+	Cadence   *int
+
+	// Distance is a distance covered since the start, as measured by the
+	// device itself
+	Distance *float64
+}
+
+// Lap is a part of an activity, as split by the device
+type Lap struct {
+	Start        time.Time
+	Duration     time.Duration
+	Distance     *float64
+	AvgHeartRate *int
+	MaxHeartRate *int
+	Calories     *int
+}
+
+type Activity struct {
+	Sport    string
+	Calories *int
+	Points   []Point
+	Laps     []Lap
+}
+
+type Summary struct {
+	Start         time.Time
+	Duration      time.Duration
+	Distance      *float64
+	ElevationGain *float64
+	AvgHeartRate  *int
+	MaxHeartRate  *int
+	Cadence       *int
+	Calories      *int
+}
+
+// Summary computes totals and averages of the activity. Distance measured
+// by the device is preferred to the one calculated from positions
+func (a *Activity) Summary() (*Summary, error) {
+	if len(a.Points) < 2 {
+		return nil, ErrNoPoints
+	}
+
+	points := make([]Point, len(a.Points))
+	copy(points, a.Points)
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+
+	first, last := points[0], points[len(points)-1]
+
+	s := Summary{
+		Start:    first.Time,
+		Duration: last.Time.Sub(first.Time),
+		Calories: a.Calories,
+	}
+
+	var (
+		measured, calculated     float64
+		hasMeasured, hasPosition bool
+		previous                 *Point
+
+		gain, reference float64
+		hasElevation    bool
+
+		heartRateSum, heartRateCount, maxHeartRate int
+		cadenceSum, cadenceCount                   int
+	)
+
+	for i := range points {
+		p := &points[i]
+
+		if p.Distance != nil {
+			measured = math.Max(measured, *p.Distance)
+			hasMeasured = true
+		}
+
+		if p.Latitude != nil && p.Longitude != nil {
+			if previous != nil {
+				calculated += haversine(*previous.Latitude, *previous.Longitude, *p.Latitude, *p.Longitude)
+			}
+			previous = p
+			hasPosition = true
+		}
+
+		if p.Elevation != nil {
+			switch {
+			case !hasElevation || *p.Elevation < reference:
+				reference = *p.Elevation
+			case *p.Elevation-reference >= elevationThreshold:
+				gain += *p.Elevation - reference
+				reference = *p.Elevation
+			}
+			hasElevation = true
+		}
+
+		if p.HeartRate != nil && *p.HeartRate > 0 {
+			heartRateSum += *p.HeartRate
+			heartRateCount++
+			maxHeartRate = max(maxHeartRate, *p.HeartRate)
+		}
+
+		if p.Cadence != nil && *p.Cadence > 0 {
+			cadenceSum += *p.Cadence
+			cadenceCount++
+		}
+	}
+
+	switch {
+	case hasMeasured:
+		s.Distance = &measured
+	case hasPosition:
+		s.Distance = &calculated
+	}
+
+	if hasElevation {
+		s.ElevationGain = &gain
+	}
+
+	if heartRateCount > 0 {
+		avg := int(math.Round(float64(heartRateSum) / float64(heartRateCount)))
+		s.AvgHeartRate = &avg
+		s.MaxHeartRate = &maxHeartRate
+	}
+
+	if cadenceCount > 0 {
+		avg := int(math.Round(float64(cadenceSum) / float64(cadenceCount)))
+		s.Cadence = &avg
+	}
+
+	return &s, nil
+}
+
+// haversine returns a great-circle distance between two points in meters
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
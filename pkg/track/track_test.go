@@ -0,0 +1,271 @@
+package track
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const gpx = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <trk>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="0" lon="0"><ele>10</ele><time>2024-05-01T06:00:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>140</gpxtpx:hr><gpxtpx:cad>85</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="0" lon="0.01"><ele>11</ele><time>2024-05-01T06:05:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>150</gpxtpx:hr><gpxtpx:cad>87</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="0" lon="0.02"><ele>15</ele><time>2024-05-01T06:10:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>171</gpxtpx:hr><gpxtpx:cad>89</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="0" lon="0.03"><ele>12</ele></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+const tcx = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2024-05-01T06:00:00Z</Id>
+      <Lap StartTime="2024-05-01T06:00:00Z">
+        <Calories>120</Calories>
+        <Track>
+          <Trackpoint><Time>2024-05-01T06:00:00Z</Time><DistanceMeters>0</DistanceMeters><HeartRateBpm><Value>120</Value></HeartRateBpm></Trackpoint>
+          <Trackpoint><Time>2024-05-01T06:20:00Z</Time><DistanceMeters>8000</DistanceMeters><HeartRateBpm><Value>140</Value></HeartRateBpm></Trackpoint>
+        </Track>
+      </Lap>
+      <Lap StartTime="2024-05-01T06:20:00Z">
+        <Calories>130</Calories>
+        <Track>
+          <Trackpoint><Time>2024-05-01T06:40:00Z</Time><DistanceMeters>16500</DistanceMeters><HeartRateBpm><Value>161</Value></HeartRateBpm></Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func TestParseGPX(t *testing.T) {
+	activity, err := ParseGPX(strings.NewReader(gpx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if activity.Sport != "running" {
+		t.Fatalf("unexpected sport: got %q, want %q\n", activity.Sport, "running")
+	}
+	if len(activity.Points) != 3 {
+		t.Fatalf("unexpected number of points: got %d, want 3\n", len(activity.Points))
+	}
+
+	s, err := activity.Summary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if !s.Start.Equal(time.Date(2024, time.May, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected start: %v\n", s.Start)
+	}
+	if s.Duration != 10*time.Minute {
+		t.Fatalf("unexpected duration: got %v, want 10m\n", s.Duration)
+	}
+
+	// 0.01 degree of longitude on the equator is about 1112 meters
+	if s.Distance == nil || math.Abs(*s.Distance-2223.9) > 1 {
+		t.Fatalf("unexpected distance: %v\n", s.Distance)
+	}
+
+	// a climb of 1 meter is below the threshold, but counts once it adds up
+	if s.ElevationGain == nil || *s.ElevationGain != 5 {
+		t.Fatalf("unexpected elevation gain: %v\n", s.ElevationGain)
+	}
+
+	if *s.AvgHeartRate != 154 || *s.MaxHeartRate != 171 || *s.Cadence != 87 {
+		t.Fatalf("unexpected aggregates: avg %d, max %d, cadence %d\n", *s.AvgHeartRate, *s.MaxHeartRate, *s.Cadence)
+	}
+	if s.Calories != nil {
+		t.Fatalf("unexpected calories: %d\n", *s.Calories)
+	}
+}
+
+func TestParseTCX(t *testing.T) {
+	activity, err := ParseTCX(strings.NewReader(tcx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if activity.Sport != "Biking" {
+		t.Fatalf("unexpected sport: got %q, want %q\n", activity.Sport, "Biking")
+	}
+
+	s, err := activity.Summary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if s.Duration != 40*time.Minute {
+		t.Fatalf("unexpected duration: got %v, want 40m\n", s.Duration)
+	}
+	if s.Distance == nil || *s.Distance != 16500 {
+		t.Fatalf("unexpected distance: %v\n", s.Distance)
+	}
+	if s.ElevationGain != nil {
+		t.Fatalf("unexpected elevation gain: %v\n", *s.ElevationGain)
+	}
+	if *s.AvgHeartRate != 140 || *s.MaxHeartRate != 161 {
+		t.Fatalf("unexpected heart rate: avg %d, max %d\n", *s.AvgHeartRate, *s.MaxHeartRate)
+	}
+	if s.Calories == nil || *s.Calories != 250 {
+		t.Fatalf("unexpected calories: %v\n", s.Calories)
+	}
+}
+
+func TestParseFIT(t *testing.T) {
+	f, err := os.Open("../fit/testdata/run.fit")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+	defer f.Close()
+
+	activity, err := ParseFIT(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if activity.Sport != "running" {
+		t.Fatalf("unexpected sport: got %q, want %q\n", activity.Sport, "running")
+	}
+	if len(activity.Laps) != 2 {
+		t.Fatalf("unexpected number of laps: got %d, want 2\n", len(activity.Laps))
+	}
+	if activity.Laps[0].Duration != 15*time.Minute || *activity.Laps[0].Distance != 3000 {
+		t.Fatalf("unexpected lap: %+v\n", activity.Laps[0])
+	}
+
+	s, err := activity.Summary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if s.Duration != 30*time.Minute+30*time.Second {
+		t.Fatalf("unexpected duration: got %v, want 30m30s\n", s.Duration)
+	}
+	if s.Distance == nil || *s.Distance != 6050 {
+		t.Fatalf("unexpected distance: %v\n", s.Distance)
+	}
+	if s.ElevationGain == nil || *s.ElevationGain != 20 {
+		t.Fatalf("unexpected elevation gain: %v\n", s.ElevationGain)
+	}
+	if *s.AvgHeartRate != 146 || *s.MaxHeartRate != 160 {
+		t.Fatalf("unexpected heart rate: avg %d, max %d\n", *s.AvgHeartRate, *s.MaxHeartRate)
+	}
+	if s.Calories == nil || *s.Calories != 420 {
+		t.Fatalf("unexpected calories: %v\n", s.Calories)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tt := []struct {
+		name  string
+		parse func(string) (*Activity, error)
+		data  string
+	}{
+		{
+			name:  "Not XML",
+			parse: func(s string) (*Activity, error) { return ParseGPX(strings.NewReader(s)) },
+			data:  "lat,lon\n0,0\n",
+		},
+		{
+			name:  "TCX as GPX",
+			parse: func(s string) (*Activity, error) { return ParseGPX(strings.NewReader(s)) },
+			data:  tcx,
+		},
+		{
+			name:  "Truncated TCX",
+			parse: func(s string) (*Activity, error) { return ParseTCX(strings.NewReader(s)) },
+			data:  tcx[:len(tcx)/2],
+		},
+		{
+			name:  "Invalid time",
+			parse: func(s string) (*Activity, error) { return ParseGPX(strings.NewReader(s)) },
+			data:  `<gpx><trk><trkseg><trkpt lat="0" lon="0"><time>yesterday</time></trkpt></trkseg></trk></gpx>`,
+		},
+		{
+			name:  "GPX as FIT",
+			parse: func(s string) (*Activity, error) { return ParseFIT(strings.NewReader(s)) },
+			data:  gpx,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.parse(tc.data); err == nil {
+				t.Fatal("expected an error\n")
+			}
+		})
+	}
+}
+
+func TestSummaryWithoutPoints(t *testing.T) {
+	activity, err := ParseGPX(strings.NewReader(`<gpx><trk><trkseg><trkpt lat="0" lon="0"/></trkseg></trk></gpx>`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if _, err := activity.Summary(); !errors.Is(err, ErrNoPoints) {
+		t.Fatalf("unexpected error: got %v, want %v\n", err, ErrNoPoints)
+	}
+}
+
+func TestGPXWriter(t *testing.T) {
+	activity, err := ParseTCX(strings.NewReader(tcx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	latitude, longitude := 52.5, 13.4
+	activity.Points[0].Latitude, activity.Points[0].Longitude = &latitude, &longitude
+	activity.Points[2].Latitude, activity.Points[2].Longitude = &latitude, &longitude
+
+	var buf bytes.Buffer
+
+	w, err := NewGPXWriter(&buf, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if err := w.WriteTrack("Morning ride", "", "cycling", activity.Points); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+	if err := w.WriteTrack("Strength & <conditioning>", "without a track", "", nil); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	parsed, err := ParseGPX(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n", err)
+	}
+
+	if parsed.Sport != "cycling" {
+		t.Fatalf("unexpected sport: got %q, want %q\n", parsed.Sport, "cycling")
+	}
+
+	// a point without a position is skipped
+	if len(parsed.Points) != 2 {
+		t.Fatalf("unexpected number of points: got %d, want 2\n", len(parsed.Points))
+	}
+
+	p := parsed.Points[1]
+	if !p.Time.Equal(time.Date(2024, time.May, 1, 6, 40, 0, 0, time.UTC)) || *p.Latitude != latitude || p.HeartRate == nil || *p.HeartRate != 161 {
+		t.Fatalf("unexpected point: %+v\n", p)
+	}
+}
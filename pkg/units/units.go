@@ -0,0 +1,88 @@
+// Package units converts measurements between metric and imperial systems.
+// Values are stored in meters and seconds, and converted only at the edges
+package units
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+
+	metersInKilometer = 1000
+	metersInMile      = 1609.344
+	metersInFoot      = 0.3048
+)
+
+// DistanceToMeters converts distance in kilometers or miles to meters
+func DistanceToMeters(value float64, system string) float64 {
+	if system == Imperial {
+		return value * metersInMile
+	}
+	return value * metersInKilometer
+}
+
+// DistanceFromMeters converts distance in meters to kilometers or miles
+func DistanceFromMeters(meters float64, system string) float64 {
+	if system == Imperial {
+		return meters / metersInMile
+	}
+	return meters / metersInKilometer
+}
+
+// ElevationToMeters converts elevation in meters or feet to meters
+func ElevationToMeters(value float64, system string) float64 {
+	if system == Imperial {
+		return value * metersInFoot
+	}
+	return value
+}
+
+// ElevationFromMeters converts elevation in meters to meters or feet
+func ElevationFromMeters(meters float64, system string) float64 {
+	if system == Imperial {
+		return meters / metersInFoot
+	}
+	return meters
+}
+
+// DistanceUnit returns a short name of distance unit, km or mi
+func DistanceUnit(system string) string {
+	if system == Imperial {
+		return "mi"
+	}
+	return "km"
+}
+
+// ElevationUnit returns a short name of elevation unit, m or ft
+func ElevationUnit(system string) string {
+	if system == Imperial {
+		return "ft"
+	}
+	return "m"
+}
+
+// Pace returns seconds, spent on a kilometer or a mile
+func Pace(meters float64, duration time.Duration, system string) float64 {
+	distance := DistanceFromMeters(meters, system)
+	if distance <= 0 {
+		return 0
+	}
+	return duration.Seconds() / distance
+}
+
+// Speed returns kilometers or miles per hour
+func Speed(meters float64, duration time.Duration, system string) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	return DistanceFromMeters(meters, system) / duration.Hours()
+}
+
+// Round rounds value to given number of decimal places
+func Round(value float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(value*p) / p
+}
@@ -0,0 +1,118 @@
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDistance(t *testing.T) {
+	tt := []struct {
+		name   string
+		value  float64
+		system string
+		meters float64
+	}{
+		{
+			name:   "Kilometers",
+			value:  10,
+			system: Metric,
+			meters: 10000,
+		},
+		{
+			name:   "Miles",
+			value:  26.2,
+			system: Imperial,
+			meters: 42164.8128,
+		},
+		{
+			name:   "Unknown system falls back to metric",
+			value:  5,
+			system: "",
+			meters: 5000,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			meters := DistanceToMeters(tc.value, tc.system)
+			if Round(meters, 4) != tc.meters {
+				t.Fatalf("unexpected result of DistanceToMeters: got %v, want %v\n", meters, tc.meters)
+			}
+
+			value := DistanceFromMeters(meters, tc.system)
+			if Round(value, 4) != tc.value {
+				t.Fatalf("unexpected result of DistanceFromMeters: got %v, want %v\n", value, tc.value)
+			}
+		})
+	}
+}
+
+func TestElevation(t *testing.T) {
+	if got := ElevationToMeters(1000, Imperial); Round(got, 1) != 304.8 {
+		t.Fatalf("unexpected result of ElevationToMeters: got %v, want %v\n", got, 304.8)
+	}
+
+	if got := ElevationFromMeters(304.8, Imperial); Round(got, 1) != 1000 {
+		t.Fatalf("unexpected result of ElevationFromMeters: got %v, want %v\n", got, 1000)
+	}
+
+	if got := ElevationFromMeters(120, Metric); got != 120 {
+		t.Fatalf("unexpected result of ElevationFromMeters: got %v, want %v\n", got, 120)
+	}
+}
+
+func TestPaceAndSpeed(t *testing.T) {
+	tt := []struct {
+		name     string
+		meters   float64
+		duration time.Duration
+		system   string
+		pace     float64
+		speed    float64
+	}{
+		{
+			name:     "10K in 50 minutes",
+			meters:   10000,
+			duration: 50 * time.Minute,
+			system:   Metric,
+			pace:     300,
+			speed:    12,
+		},
+		{
+			name:     "Mile in 8 minutes",
+			meters:   metersInMile,
+			duration: 8 * time.Minute,
+			system:   Imperial,
+			pace:     480,
+			speed:    7.5,
+		},
+		{
+			name:     "No distance",
+			meters:   0,
+			duration: time.Hour,
+			system:   Metric,
+			pace:     0,
+			speed:    0,
+		},
+		{
+			name:     "No duration",
+			meters:   1000,
+			duration: 0,
+			system:   Metric,
+			pace:     0,
+			speed:    0,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Pace(tc.meters, tc.duration, tc.system); Round(got, 2) != tc.pace {
+				t.Fatalf("unexpected result of Pace: got %v, want %v\n", got, tc.pace)
+			}
+
+			if got := Speed(tc.meters, tc.duration, tc.system); Round(got, 2) != tc.speed {
+				t.Fatalf("unexpected result of Speed: got %v, want %v\n", got, tc.speed)
+			}
+		})
+	}
+}